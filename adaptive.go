@@ -0,0 +1,54 @@
+package main
+
+import (
+	"vpsentinel-agent/config"
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+const (
+	// idleCPUPercent is the CPU usage below which a cycle counts as idle
+	idleCPUPercent = 5.0
+
+	// idleCyclesBeforeBackoff is how many idle cycles in a row double the interval
+	idleCyclesBeforeBackoff = 3
+)
+
+// adaptiveInterval stretches the collection interval while the server is idle
+type adaptiveInterval struct {
+	idleCycles int
+	seconds    int // Current effective interval (0 = interval_seconds)
+}
+
+// next returns the interval to use after a cycle
+// Idle cycles double the interval up to the configured maximum; any busy or
+// failed cycle resets it to interval_seconds right away
+func (a *adaptiveInterval) next(cfg *config.Config, payload models.Payload, err error) int {
+	if !cfg.AdaptiveInterval {
+		a.idleCycles, a.seconds = 0, 0
+		return cfg.IntervalSeconds
+	}
+
+	idle := err == nil && payload.System.CPUPercent < idleCPUPercent && len(payload.Anomalies) == 0
+	if !idle {
+		if a.seconds > cfg.IntervalSeconds {
+			logging.Infof("Activity detected, collection interval back to %ds", cfg.IntervalSeconds)
+		}
+		a.idleCycles, a.seconds = 0, cfg.IntervalSeconds
+		return a.seconds
+	}
+
+	if a.seconds < cfg.IntervalSeconds {
+		a.seconds = cfg.IntervalSeconds
+	}
+	a.idleCycles++
+	if a.idleCycles >= idleCyclesBeforeBackoff && a.seconds < cfg.MaxAdaptiveInterval() {
+		a.idleCycles = 0
+		a.seconds *= 2
+		if a.seconds > cfg.MaxAdaptiveInterval() {
+			a.seconds = cfg.MaxAdaptiveInterval()
+		}
+		logging.Infof("Server idle, collection interval raised to %ds", a.seconds)
+	}
+	return a.seconds
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+const (
+	benchmarkHashRounds  = 100000
+	benchmarkMemoryBytes = 64 * 1024 * 1024
+	benchmarkDiskBytes   = 10 * 1024 * 1024
+)
+
+// handleBenchmark handles the benchmark command
+// Runs quick CPU, memory and disk micro-benchmarks to baseline a host
+func (h *Handler) handleBenchmark(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if !cfg.EnableBenchmarkCommand {
+		return "", fmt.Errorf("benchmark command is disabled (enable_benchmark_command=false)")
+	}
+
+	logging.Infof("Running host benchmark...")
+
+	cpuMs := benchmarkCPU()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	memoryMs := benchmarkMemory()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	writeMs, readMs, err := benchmarkDisk()
+	if err != nil {
+		return "", fmt.Errorf("disk benchmark failed: %w", err)
+	}
+
+	result, err := json.Marshal(map[string]int64{
+		"cpu_ms":        cpuMs,
+		"memory_ms":     memoryMs,
+		"disk_write_ms": writeMs,
+		"disk_read_ms":  readMs,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// benchmarkCPU computes chained SHA-256 hashes
+func benchmarkCPU() int64 {
+	start := time.Now()
+	sum := sha256.Sum256([]byte("vpsentinel"))
+	for i := 0; i < benchmarkHashRounds; i++ {
+		sum = sha256.Sum256(sum[:])
+	}
+	return time.Since(start).Milliseconds()
+}
+
+// benchmarkMemory allocates and fills a 64 MB slice
+func benchmarkMemory() int64 {
+	start := time.Now()
+	buf := make([]byte, benchmarkMemoryBytes)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	return time.Since(start).Milliseconds()
+}
+
+// benchmarkDisk writes a 10 MB temp file (synced to disk) and reads it back
+func benchmarkDisk() (int64, int64, error) {
+	file, err := os.CreateTemp("", "vpsentinel-benchmark-*")
+	if err != nil {
+		return 0, 0, err
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	data := make([]byte, benchmarkDiskBytes)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	start := time.Now()
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return 0, 0, err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return 0, 0, err
+	}
+	writeMs := time.Since(start).Milliseconds()
+	if err := file.Close(); err != nil {
+		return 0, 0, err
+	}
+
+	start = time.Now()
+	readFile, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	_, err = io.Copy(io.Discard, readFile)
+	readFile.Close()
+	if err != nil {
+		return 0, 0, err
+	}
+	readMs := time.Since(start).Milliseconds()
+
+	return writeMs, readMs, nil
+}
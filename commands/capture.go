@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+const (
+	maxCaptureSeconds = 10
+	maxCapturePackets = 200
+	maxCaptureBytes   = 1024 * 1024
+)
+
+// interfaceNamePattern matches valid network interface names (and "any")
+var interfaceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:@-]*$`)
+
+// handleTcpdumpCapture handles the tcpdump_capture command
+// Runs a short, bounded packet capture and returns the pcap data base64-encoded
+func (h *Handler) handleTcpdumpCapture(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if !cfg.EnablePacketCapture {
+		return "", fmt.Errorf("packet capture is disabled (enable_packet_capture=false)")
+	}
+
+	iface, err := requireString(cmd.Payload, "interface")
+	if err != nil {
+		return "", err
+	}
+	if !interfaceNamePattern.MatchString(iface) {
+		return "", fmt.Errorf("invalid interface name: %s", iface)
+	}
+	filter, _ := payloadString(cmd.Payload, "filter")
+
+	duration, err := requireInt(cmd.Payload, "duration_seconds")
+	if err != nil {
+		return "", err
+	}
+	if duration < 1 || duration > maxCaptureSeconds {
+		return "", fmt.Errorf("duration_seconds must be between 1 and %d", maxCaptureSeconds)
+	}
+	maxPackets, err := requireInt(cmd.Payload, "max_packets")
+	if err != nil {
+		return "", err
+	}
+	if maxPackets < 1 || maxPackets > maxCapturePackets {
+		return "", fmt.Errorf("max_packets must be between 1 and %d", maxCapturePackets)
+	}
+
+	logging.Infof("Capturing up to %d packets on %s for %ds", maxPackets, iface, duration)
+
+	// -G/-W 1 stops after one rotation period; the context is a hard stop
+	// in case no packet arrives to trigger the rotation check
+	// -U flushes each packet so nothing is lost if tcpdump is killed
+	captureCtx, cancel := context.WithTimeout(ctx, time.Duration(duration+2)*time.Second)
+	defer cancel()
+
+	args := []string{
+		"-i", iface,
+		"-c", strconv.Itoa(maxPackets),
+		"-G", strconv.Itoa(duration),
+		"-W", "1",
+		"-U",
+		"-w", "-",
+	}
+	if filter = strings.TrimSpace(filter); filter != "" {
+		// "--" keeps the filter from being parsed as tcpdump options
+		args = append(args, "--", filter)
+	}
+
+	tcpdump := exec.CommandContext(captureCtx, "tcpdump", args...)
+	var stderr bytes.Buffer
+	tcpdump.Stderr = &stderr
+	stdout, err := tcpdump.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("failed to capture output: %w", err)
+	}
+	if err := tcpdump.Start(); err != nil {
+		return "", fmt.Errorf("failed to start tcpdump: %w", err)
+	}
+
+	// Read one byte past the limit to detect truncation
+	pcap, readErr := io.ReadAll(io.LimitReader(stdout, maxCaptureBytes+1))
+	truncated := len(pcap) > maxCaptureBytes
+	if truncated {
+		pcap = pcap[:maxCaptureBytes]
+		cancel() // Stop tcpdump, we have all we can send
+	}
+	io.Copy(io.Discard, stdout) // Unblock tcpdump so Wait can return
+	waitErr := tcpdump.Wait()
+
+	// Being stopped by the timeout is expected; only fail if nothing was captured
+	if len(pcap) == 0 {
+		if readErr != nil {
+			return "", fmt.Errorf("failed to read capture: %w", readErr)
+		}
+		if waitErr != nil {
+			return "", fmt.Errorf("tcpdump failed: %v: %s", waitErr, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"pcap_base64": base64.StdEncoding.EncodeToString(pcap),
+		"bytes":       len(pcap),
+		"truncated":   truncated,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
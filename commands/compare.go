@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"vpsentinel-agent/models"
+)
+
+const (
+	// maxCompareFileBytes limits the size of files compare_file reads
+	maxCompareFileBytes = 1024 * 1024
+	// maxDiffBytes caps the diff returned by compare_file
+	maxDiffBytes = 10 * 1024
+	// maxDiffEdits bounds the work done for files that differ heavily
+	maxDiffEdits = 2000
+)
+
+// handleCompareFile handles the compare_file command
+// Compares a file with a reference SHA-256 and, if reference content is given, returns a unified diff
+func (h *Handler) handleCompareFile(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := requireString(cmd.Payload, "path")
+	if err != nil {
+		return "", err
+	}
+	path = filepath.Clean(path)
+
+	// Resolve symlinks so a link inside an allowed directory can't expose other files
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if !isPathAllowed(resolved, cfg.AllowedReadPaths) {
+		return "", fmt.Errorf("path %s is not in allowed_read_paths", path)
+	}
+
+	referenceSum, err := requireString(cmd.Payload, "reference_sha256")
+	if err != nil {
+		return "", err
+	}
+	referenceSum = strings.ToLower(strings.TrimSpace(referenceSum))
+
+	var reference []byte
+	hasReference := false
+	if encoded, ok := payloadString(cmd.Payload, "reference_content"); ok && encoded != "" {
+		reference, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", fmt.Errorf("reference_content is not valid base64: %w", err)
+		}
+		hasReference = true
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Size() > maxCompareFileBytes {
+		return "", fmt.Errorf("file is too large to compare (%d bytes, max %d)", info.Size(), maxCompareFileBytes)
+	}
+
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	actualSum := hex.EncodeToString(sum[:])
+
+	result := map[string]interface{}{
+		"path":    path,
+		"sha256":  actualSum,
+		"matches": actualSum == referenceSum,
+	}
+
+	// Diff only when there is something to show
+	if hasReference && actualSum != referenceSum {
+		ops, ok := diffLines(splitLines(string(reference)), splitLines(string(content)), maxDiffEdits)
+		if !ok {
+			result["diff_error"] = fmt.Sprintf("files differ by more than %d lines", maxDiffEdits)
+		} else {
+			diff := unifiedDiff(ops, "reference", path)
+			if len(diff) > maxDiffBytes {
+				cut := maxDiffBytes
+				for cut > 0 && !utf8.RuneStart(diff[cut]) {
+					cut--
+				}
+				diff = diff[:cut]
+				result["diff_truncated"] = true
+			}
+			result["diff"] = diff
+		}
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+// splitLines splits text into lines without a trailing empty line
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
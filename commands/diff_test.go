@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns the lines "1" to "n"
+func numberedLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprint(i + 1)
+	}
+	return lines
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	letters := strings.Split("a b c d e f g h i j k l m n o", " ")
+	editedLetters := strings.Split("a b C d e f g h x i j k l n o", " ")
+	numbers := numberedLines(20)
+	editedNumbers := append(append([]string{"1", "two"}, numbers[2:17]...), "19", "20")
+
+	tests := []struct {
+		name string
+		a, b []string
+		want string
+	}{
+		{"empty", nil, nil, ""},
+		{"identical", []string{"a", "b", "c"}, []string{"a", "b", "c"}, ""},
+		{"insert only", nil, []string{"x", "y"}, "@@ -0,0 +1,2 @@\n+x\n+y\n"},
+		{"delete only", []string{"x", "y"}, nil, "@@ -1,2 +0,0 @@\n-x\n-y\n"},
+		{"single deletion", []string{"a", "b", "c"}, []string{"a", "c"}, "@@ -1,3 +1,2 @@\n a\n-b\n c\n"},
+		{"append", []string{"a"}, []string{"a", "b"}, "@@ -1 +1,2 @@\n a\n+b\n"},
+		{
+			// Changes closer than twice the context share one hunk
+			"mixed edit", letters, editedLetters,
+			"@@ -1,15 +1,15 @@\n a\n b\n-c\n+C\n d\n e\n f\n g\n h\n+x\n i\n j\n k\n l\n-m\n n\n o\n",
+		},
+		{
+			"separate hunks", numbers, editedNumbers,
+			"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -15,6 +15,5 @@\n 15\n 16\n 17\n-18\n 19\n 20\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, ok := diffLines(tt.a, tt.b, maxDiffEdits)
+			if !ok {
+				t.Fatal("diffLines() gave up, want an edit script")
+			}
+			want := tt.want
+			if want != "" {
+				want = "--- reference\n+++ current\n" + want
+			}
+			if got := unifiedDiff(ops, "reference", "current"); got != want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+
+	ops, ok := diffLines(a, b, maxDiffEdits)
+	if !ok {
+		t.Fatal("diffLines() gave up, want an edit script")
+	}
+
+	// The edit script must rebuild both inputs with the fewest edits (5 for this pair)
+	var gotA, gotB []string
+	edits := 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			gotA = append(gotA, op.line)
+		}
+		if op.kind != '-' {
+			gotB = append(gotB, op.line)
+		}
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if strings.Join(gotA, " ") != strings.Join(a, " ") || strings.Join(gotB, " ") != strings.Join(b, " ") {
+		t.Errorf("edit script rebuilds %q and %q, want %q and %q", gotA, gotB, a, b)
+	}
+	if edits != 5 {
+		t.Errorf("edit script has %d edits, want 5", edits)
+	}
+}
+
+func TestDiffLinesMaxEdits(t *testing.T) {
+	a := []string{"a", "b", "c"}
+	b := []string{"x", "y", "z"}
+
+	if _, ok := diffLines(a, b, 5); ok {
+		t.Error("diffLines() with 6 edits needed and a limit of 5 succeeded, want false")
+	}
+	if _, ok := diffLines(a, b, 6); !ok {
+		t.Error("diffLines() with 6 edits needed and a limit of 6 gave up, want an edit script")
+	}
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/logs"
+	"vpsentinel-agent/models"
+)
+
+const (
+	defaultDmesgLines = 50
+	maxDmesgLines     = 200
+	dmesgTimeout      = 10 * time.Second
+)
+
+// dmesgLevels maps the requested minimum level to the dmesg levels included
+var dmesgLevels = map[string]string{
+	"err":  "emerg,alert,crit,err",
+	"warn": "emerg,alert,crit,err,warn",
+	"info": "emerg,alert,crit,err,warn,notice,info",
+}
+
+// handleGetDmesg handles the get_dmesg command
+// Returns the last kernel messages at or above a level, sanitized like log files
+func (h *Handler) handleGetDmesg(ctx context.Context, cmd models.Command) (string, error) {
+	lines := defaultDmesgLines
+	if n, ok := payloadInt(cmd.Payload, "lines"); ok {
+		if n < 1 || n > maxDmesgLines {
+			return "", fmt.Errorf("lines must be between 1 and %d", maxDmesgLines)
+		}
+		lines = n
+	}
+
+	level, _ := payloadString(cmd.Payload, "level")
+	if level == "" {
+		level = "err"
+	}
+	levels, ok := dmesgLevels[level]
+	if !ok {
+		return "", fmt.Errorf("invalid level %q (expected \"err\", \"warn\" or \"info\")", level)
+	}
+
+	logging.Infof("Reading last %d kernel messages (level %s)", lines, level)
+
+	dmesgCtx, cancel := context.WithTimeout(ctx, dmesgTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(dmesgCtx, "dmesg", "-T", "--level="+levels).Output()
+	if err != nil {
+		if dmesgCtx.Err() != nil {
+			return "", fmt.Errorf("dmesg timed out after %v", dmesgTimeout)
+		}
+		return "", fmt.Errorf("dmesg failed: %w", err)
+	}
+
+	// Keep the last lines (equivalent of piping through tail)
+	messages := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	if len(messages) > lines {
+		messages = messages[len(messages)-lines:]
+	}
+
+	return logs.Sanitize(strings.Join(messages, "\n")), nil
+}
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+// handleCreateFile handles the create_file command
+// Writes base64 content atomically to an allowed path
+func (h *Handler) handleCreateFile(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := requireString(cmd.Payload, "path")
+	if err != nil {
+		return "", err
+	}
+	path = filepath.Clean(path)
+
+	// Resolve symlinks in the parent directory so a link inside an allowed
+	// directory can't redirect the write elsewhere (the file itself may not exist yet)
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	resolved := filepath.Join(dir, filepath.Base(path))
+	if !isPathAllowed(resolved, cfg.AllowedWritePaths) {
+		return "", fmt.Errorf("path %s is not in allowed_write_paths", path)
+	}
+
+	encoded, _ := payloadString(cmd.Payload, "content")
+	content, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("content is not valid base64: %w", err)
+	}
+
+	// Default to rw-r--r-- when no mode is given
+	mode := os.FileMode(0644)
+	if modeStr, ok := payloadString(cmd.Payload, "mode"); ok && modeStr != "" {
+		parsed, err := strconv.ParseUint(modeStr, 8, 32)
+		if err != nil || parsed > 0777 {
+			return "", fmt.Errorf("invalid mode %q (expected octal like \"0644\")", modeStr)
+		}
+		mode = os.FileMode(parsed)
+	}
+
+	// Resolve the owner before touching the filesystem
+	uid, gid := -1, -1
+	if owner, ok := payloadString(cmd.Payload, "owner"); ok && owner != "" {
+		if os.Geteuid() != 0 {
+			return "", fmt.Errorf("changing owner requires the agent to run as root")
+		}
+		uid, gid, err = lookupOwner(owner)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	logging.Infof("Writing %d bytes to %s", len(content), resolved)
+
+	if err := writeFileAtomic(resolved, content, mode, uid, gid); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	result, err := json.Marshal(map[string]interface{}{
+		"size_bytes": len(content),
+		"sha256":     hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// writeFileAtomic writes to a temp file in the target directory and renames it into place
+// uid/gid of -1 leave ownership unchanged
+func writeFileAtomic(path string, content []byte, mode os.FileMode, uid, gid int) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// Clean up the temp file on any failure
+	success := false
+	defer func() {
+		if !success {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(tmpPath, uid, gid); err != nil {
+			return fmt.Errorf("failed to set owner: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+
+	success = true
+	return nil
+}
+
+// lookupOwner resolves "user" or "user:group" to numeric IDs
+func lookupOwner(owner string) (int, int, error) {
+	userName, groupName, hasGroup := strings.Cut(owner, ":")
+
+	u, err := user.Lookup(userName)
+	if err != nil {
+		return -1, -1, fmt.Errorf("unknown user %q: %w", userName, err)
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return -1, -1, fmt.Errorf("user %q has non-numeric uid %s", userName, u.Uid)
+	}
+
+	// Default to the user's primary group
+	gidStr := u.Gid
+	if hasGroup && groupName != "" {
+		g, err := user.LookupGroup(groupName)
+		if err != nil {
+			return -1, -1, fmt.Errorf("unknown group %q: %w", groupName, err)
+		}
+		gidStr = g.Gid
+	}
+	gid, err := strconv.Atoi(gidStr)
+	if err != nil {
+		return -1, -1, fmt.Errorf("group has non-numeric gid %s", gidStr)
+	}
+
+	return uid, gid, nil
+}
+
+// isPathAllowed checks that a cleaned absolute path is inside one of the allowed directories
+// An allowed entry may also name a single file exactly
+func isPathAllowed(path string, allowed []string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	path = filepath.Clean(path)
+
+	for _, entry := range allowed {
+		if entry == "" {
+			continue
+		}
+		entry = filepath.Clean(entry)
+		if path == entry {
+			return true
+		}
+		if strings.HasPrefix(path, strings.TrimSuffix(entry, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"vpsentinel-agent/models"
+)
+
+// newTestHandler returns a Handler whose config file contains the given extra fields
+func newTestHandler(t *testing.T, extra map[string]interface{}) *Handler {
+	t.Helper()
+	cfg := map[string]interface{}{
+		"api_key":          "test-key",
+		"backend_url":      "https://api.example.com",
+		"interval_seconds": 60,
+	}
+	for key, value := range extra {
+		cfg[key] = value
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return NewHandler(path, nil, nil)
+}
+
+// tempDir returns a new temporary directory with symlinks resolved (e.g. /var on macOS)
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func createFileCommand(path, content string) models.Command {
+	return models.Command{
+		ID:   "cmd-1",
+		Type: "create_file",
+		Payload: map[string]interface{}{
+			"path":    path,
+			"content": base64.StdEncoding.EncodeToString([]byte(content)),
+		},
+	}
+}
+
+func TestCreateFileSymlinkEscape(t *testing.T) {
+	allowed := tempDir(t)
+	outside := tempDir(t)
+
+	// A link inside the allowed directory pointing outside of it
+	if err := os.Symlink(outside, filepath.Join(allowed, "link")); err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHandler(t, map[string]interface{}{"allowed_write_paths": []string{allowed}})
+
+	_, err := h.Execute(context.Background(), createFileCommand(filepath.Join(allowed, "link", "evil.conf"), "pwned"))
+	if err == nil || !strings.Contains(err.Error(), "not in allowed_write_paths") {
+		t.Fatalf("Execute() error = %v, want the symlinked directory rejected", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil.conf")); !os.IsNotExist(err) {
+		t.Error("file was written outside allowed_write_paths through a symlink")
+	}
+}
+
+func TestCreateFileThroughSymlinkInsideAllowed(t *testing.T) {
+	allowed := tempDir(t)
+	if err := os.Mkdir(filepath.Join(allowed, "releases"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A link that stays inside the allowed directory is fine
+	if err := os.Symlink(filepath.Join(allowed, "releases"), filepath.Join(allowed, "current")); err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHandler(t, map[string]interface{}{"allowed_write_paths": []string{allowed}})
+
+	if _, err := h.Execute(context.Background(), createFileCommand(filepath.Join(allowed, "current", "app.conf"), "ok")); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(allowed, "releases", "app.conf"))
+	if err != nil || string(data) != "ok" {
+		t.Errorf("releases/app.conf = %q, %v, want %q", data, err, "ok")
+	}
+}
+
+func TestCreateFilePaths(t *testing.T) {
+	allowed := tempDir(t)
+	h := newTestHandler(t, map[string]interface{}{"allowed_write_paths": []string{allowed}})
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"inside allowed", filepath.Join(allowed, "app.conf"), ""},
+		{"dot-dot escape", filepath.Join(allowed, "..", "app.conf"), "not in allowed_write_paths"},
+		{"relative", "app.conf", "not in allowed_write_paths"},
+		{"missing parent", filepath.Join(allowed, "missing", "app.conf"), "failed to resolve path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.Execute(context.Background(), createFileCommand(tt.path, "content"))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Execute() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
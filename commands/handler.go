@@ -2,34 +2,49 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"time"
 
 	"vpsentinel-agent/config"
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/metrics"
 	"vpsentinel-agent/models"
+	"vpsentinel-agent/network"
+	"vpsentinel-agent/transport"
 )
 
 // Handler handles commands from the backend
 type Handler struct {
-	configPath string
-	shutdown   func()
+	configPath  string
+	client      *transport.Client
+	shutdown    func()
+	history     *metrics.RingBuffer
+	collect     func() models.Payload
+	portScanner *network.PortScanner
+	reload      func(cfg *config.Config) error
 }
 
 // NewHandler creates a new command handler
-func NewHandler(configPath string, shutdown func()) *Handler {
+func NewHandler(configPath string, client *transport.Client, shutdown func()) *Handler {
 	return &Handler{
 		configPath: configPath,
+		client:     client,
 		shutdown:   shutdown,
 	}
 }
 
 // Execute executes a command from the backend
 func (h *Handler) Execute(ctx context.Context, cmd models.Command) (string, error) {
-	log.Printf("Executing command: %s (ID: %s)", cmd.Type, cmd.ID)
+	logging.Infof("Executing command: %s (ID: %s)", cmd.Type, cmd.ID)
+
+	// Reject malformed payloads before any handler runs
+	if err := ValidatePayload(cmd.Type, cmd.Payload); err != nil {
+		return "", err
+	}
 
 	switch cmd.Type {
 	case "stop":
@@ -40,48 +55,161 @@ func (h *Handler) Execute(ctx context.Context, cmd models.Command) (string, erro
 		return h.handleUpdateConfig(ctx, cmd)
 	case "ping":
 		return "pong", nil
+	case "get_environment":
+		return h.handleGetEnvironment(ctx, cmd)
+	case "create_file":
+		return h.handleCreateFile(ctx, cmd)
+	case "rotate_api_key":
+		return h.handleRotateAPIKey(ctx, cmd)
+	case "benchmark":
+		return h.handleBenchmark(ctx, cmd)
+	case "tcpdump_capture":
+		return h.handleTcpdumpCapture(ctx, cmd)
+	case "get_metrics_history":
+		return h.handleGetMetricsHistory(ctx, cmd)
+	case "set_log_level":
+		return h.handleSetLogLevel(ctx, cmd)
+	case "generate_report":
+		return h.handleGenerateReport(ctx, cmd)
+	case "get_network_stats":
+		return h.handleGetNetworkStats(ctx, cmd)
+	case "send_test_payload":
+		return h.handleSendTestPayload(ctx, cmd)
+	case "compare_file":
+		return h.handleCompareFile(ctx, cmd)
+	case "restart_service":
+		return h.handleRestartService(ctx, cmd)
+	case "scan_ports":
+		return h.handleScanPorts(ctx, cmd)
+	case "get_open_files":
+		return h.handleGetOpenFiles(ctx, cmd)
+	case "show_config":
+		return h.handleShowConfig(ctx, cmd)
+	case "truncate_log":
+		return h.handleTruncateLog(ctx, cmd)
+	case "check_cert_renewal":
+		return h.handleCheckCertRenewal(ctx, cmd)
+	case "get_metrics_snapshot":
+		return h.handleGetMetricsSnapshot(ctx, cmd)
+	case "soft_reload":
+		return h.handleSoftReload(ctx, cmd)
+	case "get_dmesg":
+		return h.handleGetDmesg(ctx, cmd)
+	case "sysctl_get":
+		return h.handleSysctlGet(ctx, cmd)
+	case "test_ssl":
+		return h.handleTestSSL(ctx, cmd)
+	case "check_updates":
+		return h.handleCheckUpdates(ctx, cmd)
+	case "kill_process":
+		return h.handleKillProcess(ctx, cmd)
 	default:
 		return "", fmt.Errorf("unknown command type: %s", cmd.Type)
 	}
 }
 
+// ErrCommandTimeout is returned when a command doesn't finish within its timeout
+var ErrCommandTimeout = errors.New("command timed out")
+
+// cancelGracePeriod is how long a command may still finish after ctx is cancelled
+// The stop command cancels the agent context itself and must still report success
+var cancelGracePeriod = 200 * time.Millisecond
+
+// ExecuteWithTimeout executes a command, giving up once timeout has passed
+// The command's context is cancelled on timeout; a handler that ignores it
+// keeps running in the background but its result is discarded
+// Commands are not started at all once ctx is already cancelled (e.g. during shutdown)
+// When ctx is cancelled while a command runs, its result is kept if it finishes
+// within cancelGracePeriod
+func (h *Handler) ExecuteWithTimeout(ctx context.Context, cmd models.Command, timeout time.Duration) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := h.Execute(ctx, cmd)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%w after %v: %v", ErrCommandTimeout, timeout, o.err)
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			logging.Warnf("Command %s (ID: %s) timed out after %v", cmd.Type, cmd.ID, timeout)
+			return "", fmt.Errorf("%w after %v", ErrCommandTimeout, timeout)
+		}
+		select {
+		case o := <-done:
+			return o.result, o.err
+		case <-time.After(cancelGracePeriod):
+			return "", ctx.Err()
+		}
+	}
+}
+
+// SetCollector sets the function commands use to collect a fresh payload
+func (h *Handler) SetCollector(collect func() models.Payload) {
+	h.collect = collect
+}
+
+// loadConfig loads the current config so command gates reflect the latest settings
+func (h *Handler) loadConfig() (*config.Config, error) {
+	cfg, _, err := config.LoadWithDefaults(h.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
 // handleStop handles the stop command
 func (h *Handler) handleStop(ctx context.Context, cmd models.Command) (string, error) {
-	log.Println("Received stop command, initiating graceful shutdown...")
-	
+	logging.Infof("Received stop command, initiating graceful shutdown...")
+
 	// Call shutdown function to gracefully stop the agent
 	if h.shutdown != nil {
 		h.shutdown()
 	}
-	
+
 	return "Agent shutdown initiated", nil
 }
 
 // handleRestart handles the restart command
 func (h *Handler) handleRestart(ctx context.Context, cmd models.Command) (string, error) {
-	log.Println("Received restart command, restarting agent...")
-	
+	logging.Infof("Received restart command, restarting agent...")
+
 	// Get the executable path
 	executable, err := os.Executable()
 	if err != nil {
 		return "", fmt.Errorf("failed to get executable path: %w", err)
 	}
-	
+
 	// Get absolute path
 	execPath, err := filepath.Abs(executable)
 	if err != nil {
 		return "", fmt.Errorf("failed to get absolute path: %w", err)
 	}
-	
-	// Start new instance
+
+	// Start new instance (not tied to the command context, it must outlive this command)
 	restartCmd := exec.Command(execPath)
 	restartCmd.Dir = filepath.Dir(execPath)
 	restartCmd.Env = os.Environ()
-	
+
 	if err := restartCmd.Start(); err != nil {
 		return "", fmt.Errorf("failed to start new instance: %w", err)
 	}
-	
+
 	// Stop current instance after a short delay
 	go func() {
 		time.Sleep(1 * time.Second)
@@ -89,26 +217,26 @@ func (h *Handler) handleRestart(ctx context.Context, cmd models.Command) (string
 			h.shutdown()
 		}
 	}()
-	
+
 	return "Agent restart initiated", nil
 }
 
 // handleUpdateConfig handles the update_config command
 func (h *Handler) handleUpdateConfig(ctx context.Context, cmd models.Command) (string, error) {
-	log.Println("Received update_config command")
-	
+	logging.Infof("Received update_config command")
+
 	// Extract new config from payload
 	newConfig, ok := cmd.Payload["config"].(map[string]interface{})
 	if !ok {
 		return "", fmt.Errorf("invalid config payload")
 	}
-	
-	// Load current config
-	currentCfg, err := config.Load(h.configPath)
+
+	// Load current config (the update is saved back to the file)
+	currentCfg, err := config.LoadForUpdate(h.configPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to load current config: %w", err)
 	}
-	
+
 	// Update config fields (merge with current)
 	if apiKey, ok := newConfig["api_key"].(string); ok && apiKey != "" {
 		currentCfg.APIKey = apiKey
@@ -122,7 +250,7 @@ func (h *Handler) handleUpdateConfig(ctx context.Context, cmd models.Command) (s
 	if hostname, ok := newConfig["hostname"].(string); ok {
 		currentCfg.Hostname = hostname
 	}
-	
+
 	// Update arrays
 	if logPaths, ok := newConfig["log_paths"].([]interface{}); ok {
 		currentCfg.LogPaths = []string{}
@@ -132,7 +260,7 @@ func (h *Handler) handleUpdateConfig(ctx context.Context, cmd models.Command) (s
 			}
 		}
 	}
-	
+
 	if sslDomains, ok := newConfig["ssl_domains"].([]interface{}); ok {
 		currentCfg.SSLDomains = []string{}
 		for _, domain := range sslDomains {
@@ -141,12 +269,12 @@ func (h *Handler) handleUpdateConfig(ctx context.Context, cmd models.Command) (s
 			}
 		}
 	}
-	
+
 	// Update log_max_lines
 	if logMaxLines, ok := newConfig["log_max_lines"].(float64); ok && logMaxLines > 0 {
 		currentCfg.LogMaxLines = int(logMaxLines)
 	}
-	
+
 	// Update ports_to_monitor
 	if portsToMonitor, ok := newConfig["ports_to_monitor"].([]interface{}); ok {
 		currentCfg.PortsToMonitor = []int{}
@@ -156,11 +284,48 @@ func (h *Handler) handleUpdateConfig(ctx context.Context, cmd models.Command) (s
 			}
 		}
 	}
-	
+
 	// Save updated config
-	if err := config.Save(h.configPath, currentCfg); err != nil {
+	if err := config.SaveWithBackup(h.configPath, currentCfg); err != nil {
 		return "", fmt.Errorf("failed to save config: %w", err)
 	}
-	
+
 	return "Config updated successfully", nil
 }
+
+// handleRotateAPIKey handles the rotate_api_key command
+// The new key is only persisted after it has been verified against the backend
+func (h *Handler) handleRotateAPIKey(ctx context.Context, cmd models.Command) (string, error) {
+	logging.Infof("Received rotate_api_key command")
+
+	newKey, err := requireString(cmd.Payload, "new_api_key")
+	if err != nil {
+		return "", err
+	}
+	verifyURL, err := requireString(cmd.Payload, "verify_url")
+	if err != nil {
+		return "", err
+	}
+	if h.client == nil {
+		return "", fmt.Errorf("transport client not available")
+	}
+
+	// Keep the old key if the new one doesn't work
+	if err := h.client.VerifyAPIKey(verifyURL, newKey); err != nil {
+		return "", fmt.Errorf("new API key verification failed, keeping current key: %w", err)
+	}
+
+	currentCfg, err := config.LoadForUpdate(h.configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load current config, keeping current key: %w", err)
+	}
+	currentCfg.APIKey = newKey
+	if err := config.SaveWithBackup(h.configPath, currentCfg); err != nil {
+		return "", fmt.Errorf("failed to save config: %w", err)
+	}
+
+	// Switch in-flight traffic to the new key only after it is persisted
+	h.client.SetAPIKey(newKey)
+
+	return "API key rotated successfully", nil
+}
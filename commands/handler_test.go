@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+func TestExecuteWithTimeoutCancelledContext(t *testing.T) {
+	collected := false
+	h := NewHandler("", nil, nil)
+	h.SetCollector(func() models.Payload {
+		collected = true
+		return models.Payload{}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := h.ExecuteWithTimeout(ctx, models.Command{ID: "cmd-1", Type: "generate_report"}, time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ExecuteWithTimeout() error = %v, want %v", err, context.Canceled)
+	}
+	if collected {
+		t.Error("command ran with an already cancelled context")
+	}
+}
+
+func TestExecuteWithTimeoutDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	h := NewHandler("", nil, nil)
+	h.SetCollector(func() models.Payload {
+		<-release // Never finishes within the timeout
+		return models.Payload{}
+	})
+
+	start := time.Now()
+	_, err := h.ExecuteWithTimeout(context.Background(), models.Command{ID: "cmd-1", Type: "generate_report"}, 50*time.Millisecond)
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Errorf("ExecuteWithTimeout() error = %v, want %v", err, ErrCommandTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ExecuteWithTimeout() returned after %v, want about 50ms", elapsed)
+	}
+}
+
+func TestExecuteWithTimeoutParentCancelled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	h := NewHandler("", nil, nil)
+	h.SetCollector(func() models.Payload {
+		<-release
+		return models.Payload{}
+	})
+
+	// Cancelling the parent (e.g. on shutdown) is not reported as a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := h.ExecuteWithTimeout(ctx, models.Command{ID: "cmd-1", Type: "generate_report"}, time.Minute)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrCommandTimeout) {
+		t.Errorf("ExecuteWithTimeout() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestExecuteWithTimeoutStopCancelsParent(t *testing.T) {
+	// stop cancels the agent context itself; its result must still be reported
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := NewHandler("", nil, cancel)
+
+	result, err := h.ExecuteWithTimeout(ctx, models.Command{ID: "cmd-1", Type: "stop"}, time.Minute)
+	if err != nil {
+		t.Fatalf("ExecuteWithTimeout() error = %v", err)
+	}
+	if result != "Agent shutdown initiated" {
+		t.Errorf("ExecuteWithTimeout() = %q, want %q", result, "Agent shutdown initiated")
+	}
+	if ctx.Err() == nil {
+		t.Error("stop did not cancel the context")
+	}
+}
@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"vpsentinel-agent/metrics"
+	"vpsentinel-agent/models"
+)
+
+// SetMetricsHistory sets the buffer of recent payloads served by get_metrics_history
+func (h *Handler) SetMetricsHistory(history *metrics.RingBuffer) {
+	h.history = history
+}
+
+// handleGetMetricsHistory handles the get_metrics_history command
+// Returns the payloads of the last successful cycles, oldest first
+func (h *Handler) handleGetMetricsHistory(ctx context.Context, cmd models.Command) (string, error) {
+	if h.history == nil {
+		return "", fmt.Errorf("metrics history not available")
+	}
+
+	result, err := json.Marshal(h.history.Items())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode history: %w", err)
+	}
+
+	return string(result), nil
+}
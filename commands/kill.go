@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+// killSignals are the signals the kill_process command may send
+var killSignals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+}
+
+// handleKillProcess handles the kill_process command
+// The process name must match confirm_name so a reused PID is never signaled by mistake
+func (h *Handler) handleKillProcess(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if !cfg.EnableProcessControl {
+		return "", fmt.Errorf("process control is disabled (enable_process_control=false)")
+	}
+
+	pid, err := requireInt(cmd.Payload, "pid")
+	if err != nil {
+		return "", err
+	}
+	signalName, err := requireString(cmd.Payload, "signal")
+	if err != nil {
+		return "", err
+	}
+	confirmName, err := requireString(cmd.Payload, "confirm_name")
+	if err != nil {
+		return "", err
+	}
+
+	sig, ok := killSignals[strings.ToUpper(signalName)]
+	if !ok {
+		return "", fmt.Errorf("invalid signal %q (expected \"TERM\" or \"KILL\")", signalName)
+	}
+
+	// Never signal init, kthreadd or the agent itself
+	if pid <= 2 || pid == os.Getpid() {
+		return "", fmt.Errorf("refusing to signal process %d", pid)
+	}
+
+	proc, err := process.NewProcessWithContext(ctx, int32(pid))
+	if err != nil {
+		return "", fmt.Errorf("process %d is not running", pid)
+	}
+	name, err := proc.NameWithContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read name of process %d: %w", pid, err)
+	}
+	if !strings.EqualFold(name, confirmName) {
+		return "", fmt.Errorf("process %d is %q, not %q", pid, name, confirmName)
+	}
+
+	logging.Infof("Sending SIG%s to process %d (%s)", strings.ToUpper(signalName), pid, name)
+	if err := proc.SendSignalWithContext(ctx, sig); err != nil {
+		return "", fmt.Errorf("failed to signal process %d: %w", pid, err)
+	}
+
+	return fmt.Sprintf("Sent SIG%s to process %d (%s)", strings.ToUpper(signalName), pid, name), nil
+}
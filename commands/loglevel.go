@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+// handleSetLogLevel handles the set_log_level command
+// The new level applies immediately and lasts until the agent restarts
+func (h *Handler) handleSetLogLevel(ctx context.Context, cmd models.Command) (string, error) {
+	name, err := requireString(cmd.Payload, "level")
+	if err != nil {
+		return "", err
+	}
+	level, err := logging.ParseLevel(name)
+	if err != nil {
+		return "", err
+	}
+
+	previous := logging.GetLevel()
+	logging.SetLevel(level)
+	logging.Infof("Log level changed from %s to %s", previous, level)
+
+	return fmt.Sprintf("Log level set to %s", level), nil
+}
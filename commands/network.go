@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/net"
+
+	"vpsentinel-agent/metrics"
+	"vpsentinel-agent/models"
+)
+
+// interfaceStats holds the counters of a single network interface
+// Overruns are FIFO errors (/proc/net/dev "fifo" column on Linux)
+type interfaceStats struct {
+	Name       string `json:"name"`
+	RXBytes    uint64 `json:"rx_bytes"`
+	RXPackets  uint64 `json:"rx_packets"`
+	RXErrors   uint64 `json:"rx_errors"`
+	RXDrops    uint64 `json:"rx_drops"`
+	RXOverruns uint64 `json:"rx_overruns"`
+	TXBytes    uint64 `json:"tx_bytes"`
+	TXPackets  uint64 `json:"tx_packets"`
+	TXErrors   uint64 `json:"tx_errors"`
+	TXDrops    uint64 `json:"tx_drops"`
+	TXOverruns uint64 `json:"tx_overruns"`
+}
+
+// handleGetNetworkStats handles the get_network_stats command
+// Returns per-interface counters and the 'ss -s' socket summary
+func (h *Handler) handleGetNetworkStats(ctx context.Context, cmd models.Command) (string, error) {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to read interface counters: %w", err)
+	}
+
+	interfaces := []interfaceStats{}
+	for _, c := range counters {
+		// Skip loopback and excluded interfaces
+		if metrics.IsInterfaceExcluded(c.Name) {
+			continue
+		}
+		interfaces = append(interfaces, interfaceStats{
+			Name:       c.Name,
+			RXBytes:    c.BytesRecv,
+			RXPackets:  c.PacketsRecv,
+			RXErrors:   c.Errin,
+			RXDrops:    c.Dropin,
+			RXOverruns: c.Fifoin,
+			TXBytes:    c.BytesSent,
+			TXPackets:  c.PacketsSent,
+			TXErrors:   c.Errout,
+			TXDrops:    c.Dropout,
+			TXOverruns: c.Fifoout,
+		})
+	}
+
+	// Socket summary is best effort ('ss' is Linux only)
+	socketSummary := ""
+	if output, err := exec.CommandContext(ctx, "ss", "-s").Output(); err == nil {
+		socketSummary = strings.TrimSpace(string(output))
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"interfaces":     interfaces,
+		"socket_summary": socketSummary,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"fmt"
+)
+
+// payloadString extracts a string field from a command payload
+func payloadString(payload map[string]interface{}, key string) (string, bool) {
+	value, ok := payload[key].(string)
+	return value, ok
+}
+
+// payloadInt extracts an integer field from a command payload
+// JSON numbers are decoded as float64, so whole floats are accepted
+func payloadInt(payload map[string]interface{}, key string) (int, bool) {
+	value, ok := payload[key].(float64)
+	if !ok || value != float64(int(value)) {
+		return 0, false
+	}
+	return int(value), true
+}
+
+// requireInt extracts a required integer field from a command payload
+func requireInt(payload map[string]interface{}, key string) (int, error) {
+	value, ok := payloadInt(payload, key)
+	if !ok {
+		return 0, fmt.Errorf("payload field %q must be an integer", key)
+	}
+	return value, nil
+}
+
+// requireString extracts a required non-empty string field from a command payload
+func requireString(payload map[string]interface{}, key string) (string, error) {
+	value, ok := payloadString(payload, key)
+	if !ok || value == "" {
+		return "", fmt.Errorf("payload field %q is required", key)
+	}
+	return value, nil
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"vpsentinel-agent/models"
+	"vpsentinel-agent/network"
+)
+
+// SetPortScanner sets the port scanner shared with the collection loop
+func (h *Handler) SetPortScanner(scanner *network.PortScanner) {
+	h.portScanner = scanner
+}
+
+// handleScanPorts handles the scan_ports command
+// Discards the cached port list and returns a fresh scan
+func (h *Handler) handleScanPorts(ctx context.Context, cmd models.Command) (string, error) {
+	if h.portScanner == nil {
+		return "", fmt.Errorf("port scanner not available")
+	}
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	h.portScanner.Invalidate()
+	ports, err := h.portScanner.Scan(cfg.PortsToMonitor)
+	if err != nil {
+		return "", fmt.Errorf("port scan failed: %w", err)
+	}
+
+	result, err := json.Marshal(ports)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+// handleGetEnvironment handles the get_environment command
+// Returns the environment of a running process with sensitive variables removed
+func (h *Handler) handleGetEnvironment(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if !cfg.EnableEnvInspection {
+		return "", fmt.Errorf("environment inspection is disabled (enable_env_inspection=false)")
+	}
+
+	pid, err := requireInt(cmd.Payload, "pid")
+	if err != nil {
+		return "", err
+	}
+
+	// Only inspect processes that are actually running
+	exists, err := process.PidExistsWithContext(ctx, int32(pid))
+	if err != nil {
+		return "", fmt.Errorf("failed to check process %d: %w", pid, err)
+	}
+	if !exists {
+		return "", fmt.Errorf("process %d is not running", pid)
+	}
+
+	logging.Infof("Reading environment of process %d", pid)
+
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return "", fmt.Errorf("failed to read process environment: %w", err)
+	}
+
+	env := make(map[string]string)
+	for _, entry := range bytes.Split(data, []byte{0}) {
+		if len(entry) == 0 {
+			continue
+		}
+		key, value, _ := strings.Cut(string(entry), "=")
+		if isDeniedEnvVar(key, cfg.EnvVarDenylist) {
+			continue
+		}
+		env[key] = value
+	}
+
+	result, err := json.Marshal(env)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode environment: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// maxOpenFiles caps the file handles returned by get_open_files
+const maxOpenFiles = 200
+
+// handleGetOpenFiles handles the get_open_files command
+// Lists the open file descriptors of a running process (helps diagnose fd leaks)
+func (h *Handler) handleGetOpenFiles(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if !cfg.EnableProcessInspection {
+		return "", fmt.Errorf("process inspection is disabled (enable_process_inspection=false)")
+	}
+
+	pid, err := requireInt(cmd.Payload, "pid")
+	if err != nil {
+		return "", err
+	}
+
+	fdDir := fmt.Sprintf("/proc/%d/fd", pid)
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read open files of process %d: %w", pid, err)
+	}
+
+	// Sort numerically so the lowest descriptors are kept when truncating
+	sort.Slice(entries, func(i, j int) bool {
+		a, _ := strconv.Atoi(entries[i].Name())
+		b, _ := strconv.Atoi(entries[j].Name())
+		return a < b
+	})
+
+	files := []models.OpenFileInfo{}
+	for _, entry := range entries {
+		if len(files) >= maxOpenFiles {
+			break
+		}
+		target, err := os.Readlink(filepath.Join(fdDir, entry.Name()))
+		if err != nil {
+			continue // Descriptor closed while listing
+		}
+		files = append(files, models.OpenFileInfo{
+			FD:   entry.Name(),
+			Path: target,
+			Type: openFileType(target),
+		})
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"pid":       pid,
+		"total":     len(entries),
+		"truncated": len(entries) > len(files),
+		"files":     files,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode open files: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// openFileType classifies a /proc/<pid>/fd link target
+func openFileType(target string) string {
+	switch {
+	case strings.HasPrefix(target, "socket:"):
+		return "socket"
+	case strings.HasPrefix(target, "pipe:"):
+		return "pipe"
+	case strings.HasPrefix(target, "/"):
+		return "file"
+	default:
+		return "other" // e.g. anon_inode:[eventpoll]
+	}
+}
+
+// isDeniedEnvVar checks a variable name against glob patterns (case-insensitive)
+func isDeniedEnvVar(key string, denylist []string) bool {
+	upperKey := strings.ToUpper(key)
+	for _, pattern := range denylist {
+		if matched, err := filepath.Match(strings.ToUpper(pattern), upperKey); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"vpsentinel-agent/config"
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+// SetConfigReloader sets the function soft_reload uses to apply a new config to the running agent
+func (h *Handler) SetConfigReloader(reload func(cfg *config.Config) error) {
+	h.reload = reload
+}
+
+// handleSoftReload handles the soft_reload command
+// Re-reads the local config file (or the environment when there is none) and
+// applies it without restarting the process
+func (h *Handler) handleSoftReload(ctx context.Context, cmd models.Command) (string, error) {
+	logging.Infof("Received soft_reload command")
+
+	if h.reload == nil {
+		return "", fmt.Errorf("config reload not available")
+	}
+
+	// Loading validates the config, so an invalid one never replaces the running one
+	cfg, _, err := config.LoadWithDefaults(h.configPath)
+	if err != nil {
+		return "", err
+	}
+	if err := h.reload(cfg); err != nil {
+		return "", fmt.Errorf("failed to apply config: %w", err)
+	}
+
+	return "Config reloaded successfully", nil
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vpsentinel-agent/config"
+	"vpsentinel-agent/models"
+)
+
+// newEnvOnlyHandler returns a Handler whose config file doesn't exist, with the
+// core settings in the environment instead
+func newEnvOnlyHandler(t *testing.T) (*Handler, string) {
+	t.Helper()
+	t.Setenv("VPSENTINEL_API_KEY", "env-key")
+	t.Setenv("VPSENTINEL_BACKEND_URL", "https://api.example.com")
+	t.Setenv("VPSENTINEL_INTERVAL_SECONDS", "90")
+	path := filepath.Join(t.TempDir(), "config.json")
+	return NewHandler(path, nil, nil), path
+}
+
+func TestSoftReloadFromEnvironment(t *testing.T) {
+	h, _ := newEnvOnlyHandler(t)
+	var applied *config.Config
+	h.SetConfigReloader(func(cfg *config.Config) error {
+		applied = cfg
+		return nil
+	})
+
+	if _, err := h.Execute(context.Background(), models.Command{ID: "cmd-1", Type: "soft_reload"}); err != nil {
+		t.Fatalf("soft_reload error = %v", err)
+	}
+	if applied == nil || applied.APIKey != "env-key" || applied.IntervalSeconds != 90 {
+		t.Errorf("soft_reload applied %+v, want the environment config", applied)
+	}
+}
+
+func TestUpdateConfigWithoutConfigFile(t *testing.T) {
+	h, path := newEnvOnlyHandler(t)
+
+	cmd := models.Command{ID: "cmd-1", Type: "update_config", Payload: map[string]interface{}{
+		"config": map[string]interface{}{"interval_seconds": float64(120)},
+	}}
+	_, err := h.Execute(context.Background(), cmd)
+	if !errors.Is(err, config.ErrNoConfigFile) {
+		t.Errorf("update_config error = %v, want %v", err, config.ErrNoConfigFile)
+	}
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Errorf("update_config created %s", path)
+	}
+}
+
+func TestUpdateConfigWithConfigFile(t *testing.T) {
+	h := newTestHandler(t, nil)
+
+	cmd := models.Command{ID: "cmd-1", Type: "update_config", Payload: map[string]interface{}{
+		"config": map[string]interface{}{"interval_seconds": float64(120)},
+	}}
+	if _, err := h.Execute(context.Background(), cmd); err != nil {
+		t.Fatalf("update_config error = %v", err)
+	}
+	cfg, err := config.Load(h.configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.IntervalSeconds != 120 {
+		t.Errorf("interval_seconds = %d, want 120", cfg.IntervalSeconds)
+	}
+}
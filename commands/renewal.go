@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+	"vpsentinel-agent/network"
+)
+
+// defaultRenewalDaysThreshold matches the window in which ACME clients usually renew
+const defaultRenewalDaysThreshold = 30
+
+// handleCheckCertRenewal handles the check_cert_renewal command
+// Reports, per configured SSL domain, whether the certificate is due and the HTTP-01 challenge path is reachable
+func (h *Handler) handleCheckCertRenewal(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if len(cfg.SSLDomains) == 0 {
+		return "", fmt.Errorf("no ssl_domains configured")
+	}
+
+	threshold := defaultRenewalDaysThreshold
+	if days, ok := payloadInt(cmd.Payload, "days_threshold"); ok {
+		if days < 0 {
+			return "", fmt.Errorf("days_threshold must not be negative")
+		}
+		threshold = days
+	}
+
+	logging.Infof("Checking certificate renewal readiness for %d domain(s)", len(cfg.SSLDomains))
+	checks := network.CheckCertRenewal(ctx, cfg.SSLDomains, threshold)
+	if err := ctx.Err(); err != nil {
+		return "", err // Timed out or cancelled, the checks are incomplete
+	}
+
+	result, err := json.Marshal(checks)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
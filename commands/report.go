@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+const (
+	// maxReportBytes caps the base64-encoded report in the command response
+	maxReportBytes = 256 * 1024
+	// maxReportLogChars limits each log excerpt in the report
+	maxReportLogChars = 4000
+)
+
+// reportTemplate renders a payload as a standalone HTML page
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"mb":  func(v uint64) string { return fmt.Sprintf("%d MB", v) },
+	"pct": func(v float64) string { return fmt.Sprintf("%.1f%%", v) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>VPSentinel report: {{.Payload.Host}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+pre { background: #f5f5f5; padding: 8px; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Payload.Host}}</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}}</p>
+
+<h2>System</h2>
+<table>
+<tr><th>CPU</th><td>{{pct .Payload.System.CPUPercent}}</td></tr>
+<tr><th>Memory</th><td>{{mb .Payload.System.MemoryUsedMB}} / {{mb .Payload.System.MemoryTotalMB}} ({{pct .Payload.System.MemoryPercent}})</td></tr>
+<tr><th>Swap</th><td>{{mb .Payload.System.SwapUsedMB}} / {{mb .Payload.System.SwapTotalMB}}</td></tr>
+<tr><th>Network</th><td>RX {{mb .Payload.System.NetworkRXMB}}, TX {{mb .Payload.System.NetworkTXMB}}</td></tr>
+</table>
+
+<h3>Disks</h3>
+<table>
+<tr><th>Mount point</th><th>Used</th></tr>
+{{range .Disks}}<tr><td>{{.MountPoint}}</td><td>{{pct .Percent}}</td></tr>
+{{end}}</table>
+
+<h2>Services</h2>
+<table>
+<tr><th>Service</th><th>Version</th><th>Running</th><th>Port</th></tr>
+{{range .Payload.Services}}<tr><td>{{.Name}}</td><td>{{.Version}}</td><td>{{.IsRunning}}</td><td>{{if .Port}}{{.Port}}{{end}}</td></tr>
+{{end}}</table>
+
+<h2>SSL certificates</h2>
+<table>
+<tr><th>Domain</th><th>Issuer</th><th>Valid until</th><th>Days left</th></tr>
+{{range .Payload.SSL}}{{if .Error}}<tr><td>{{.Domain}}</td><td colspan="3">Check failed: {{.Error}}</td></tr>
+{{else}}<tr><td>{{.Domain}}</td><td>{{.Issuer}}</td><td>{{.ValidUntil.Format "2006-01-02"}}</td><td>{{.DaysLeft}}</td></tr>
+{{end}}
+{{end}}</table>
+
+<h2>Open ports</h2>
+<table>
+<tr><th>Protocol</th><th>Port</th><th>Address</th><th>Process</th><th>Service</th></tr>
+{{range .Payload.Ports}}<tr><td>{{.Protocol}}</td><td>{{.Port}}</td><td>{{.ListenAddress}}</td><td>{{.Process}}</td><td>{{.ServiceName}}</td></tr>
+{{end}}</table>
+
+<h2>Recent logs</h2>
+{{if .LogsOmitted}}<p>Log excerpts omitted to keep the report under the size limit.</p>
+{{else}}{{range .Payload.Logs}}<h3>{{.Path}}</h3>
+<pre>{{.Message}}</pre>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// reportDisk is a disk usage row in the report
+type reportDisk struct {
+	MountPoint string
+	Percent    float64
+}
+
+// reportData is the data passed to reportTemplate
+type reportData struct {
+	Payload     models.Payload
+	Generated   time.Time
+	Disks       []reportDisk
+	LogsOmitted bool
+}
+
+// handleGenerateReport handles the generate_report command
+// Collects fresh metrics and returns a base64-encoded HTML report
+func (h *Handler) handleGenerateReport(ctx context.Context, cmd models.Command) (string, error) {
+	format, _ := payloadString(cmd.Payload, "format")
+	if format == "" {
+		format = "html"
+	}
+	if format != "html" {
+		return "", fmt.Errorf("unsupported report format: %s (only \"html\" is supported)", format)
+	}
+	if h.collect == nil {
+		return "", fmt.Errorf("metrics collection not available")
+	}
+
+	logging.Infof("Generating report...")
+	payload := h.collect()
+
+	// Keep the log excerpts short, they dominate the report size
+	for i := range payload.Logs {
+		payload.Logs[i].Message = truncateReportText(payload.Logs[i].Message, maxReportLogChars)
+	}
+
+	data := reportData{
+		Payload:   payload,
+		Generated: time.Now(),
+	}
+	for mountPoint, percent := range payload.System.DiskUsage {
+		data.Disks = append(data.Disks, reportDisk{MountPoint: mountPoint, Percent: percent})
+	}
+	sort.Slice(data.Disks, func(i, j int) bool { return data.Disks[i].MountPoint < data.Disks[j].MountPoint })
+
+	encoded, err := renderReport(data)
+	if err != nil {
+		return "", err
+	}
+	if len(encoded) > maxReportBytes {
+		// Drop the log excerpts and try again
+		data.LogsOmitted = true
+		if encoded, err = renderReport(data); err != nil {
+			return "", err
+		}
+		if len(encoded) > maxReportBytes {
+			return "", fmt.Errorf("report exceeds %d bytes", maxReportBytes)
+		}
+	}
+
+	return encoded, nil
+}
+
+// renderReport renders the HTML report and base64-encodes it
+func renderReport(data reportData) (string, error) {
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// truncateReportText keeps the last n bytes of s (the most recent log lines)
+func truncateReportText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++ // Don't split a multi-byte character
+	}
+	return "…" + s[start:]
+}
@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"fmt"
+)
+
+// fieldType is the expected JSON type of a payload field
+type fieldType string
+
+const (
+	fieldString  fieldType = "a string"
+	fieldNumber  fieldType = "a number"
+	fieldInteger fieldType = "an integer"
+	fieldBool    fieldType = "a boolean"
+	fieldObject  fieldType = "an object"
+	fieldArray   fieldType = "an array"
+)
+
+// fieldSpec describes one payload field
+type fieldSpec struct {
+	name     string
+	typ      fieldType
+	required bool
+	elem     fieldType   // Element type for arrays
+	fields   []fieldSpec // Nested fields for objects
+}
+
+// payloadSchemas lists the payload fields read by each command type
+// Only the listed fields are checked and extra fields are ignored; commands
+// without an entry read no payload fields and their payload is not validated
+var payloadSchemas = map[string][]fieldSpec{
+	"update_config": {
+		{name: "config", typ: fieldObject, required: true, fields: []fieldSpec{
+			{name: "api_key", typ: fieldString},
+			{name: "backend_url", typ: fieldString},
+			{name: "interval_seconds", typ: fieldNumber},
+			{name: "hostname", typ: fieldString},
+			{name: "log_paths", typ: fieldArray, elem: fieldString},
+			{name: "ssl_domains", typ: fieldArray, elem: fieldString},
+			{name: "log_max_lines", typ: fieldNumber},
+			{name: "ports_to_monitor", typ: fieldArray, elem: fieldNumber},
+		}},
+	},
+	"get_environment": {
+		{name: "pid", typ: fieldInteger, required: true},
+	},
+	"get_open_files": {
+		{name: "pid", typ: fieldInteger, required: true},
+	},
+	"create_file": {
+		{name: "path", typ: fieldString, required: true},
+		{name: "content", typ: fieldString},
+		{name: "mode", typ: fieldString},
+		{name: "owner", typ: fieldString},
+	},
+	"truncate_log": {
+		{name: "path", typ: fieldString, required: true},
+		{name: "keep_lines", typ: fieldInteger},
+	},
+	"check_cert_renewal": {
+		{name: "days_threshold", typ: fieldInteger},
+	},
+	"get_dmesg": {
+		{name: "lines", typ: fieldInteger},
+		{name: "level", typ: fieldString},
+	},
+	"sysctl_get": {
+		{name: "keys", typ: fieldArray, required: true, elem: fieldString},
+	},
+	"test_ssl": {
+		{name: "domains", typ: fieldArray, elem: fieldString},
+	},
+	"kill_process": {
+		{name: "pid", typ: fieldInteger, required: true},
+		{name: "signal", typ: fieldString, required: true},
+		{name: "confirm_name", typ: fieldString, required: true},
+	},
+	"rotate_api_key": {
+		{name: "new_api_key", typ: fieldString, required: true},
+		{name: "verify_url", typ: fieldString, required: true},
+	},
+	"tcpdump_capture": {
+		{name: "interface", typ: fieldString, required: true},
+		{name: "filter", typ: fieldString},
+		{name: "duration_seconds", typ: fieldInteger, required: true},
+		{name: "max_packets", typ: fieldInteger, required: true},
+	},
+	"set_log_level": {
+		{name: "level", typ: fieldString, required: true},
+	},
+	"generate_report": {
+		{name: "format", typ: fieldString},
+	},
+	"compare_file": {
+		{name: "path", typ: fieldString, required: true},
+		{name: "reference_sha256", typ: fieldString, required: true},
+		{name: "reference_content", typ: fieldString},
+	},
+	"restart_service": {
+		{name: "service", typ: fieldString, required: true},
+		{name: "health_check_url", typ: fieldString},
+		{name: "health_check_timeout_seconds", typ: fieldInteger},
+	},
+}
+
+// ValidatePayload checks a command payload against the command's schema
+// Commands without a schema, including unknown command types (left to Execute
+// to reject), always pass
+func ValidatePayload(cmdType string, payload map[string]interface{}) error {
+	schema, ok := payloadSchemas[cmdType]
+	if !ok {
+		return nil
+	}
+	if err := validateFields(schema, payload); err != nil {
+		return fmt.Errorf("%s: %w", cmdType, err)
+	}
+	return nil
+}
+
+// validateFields checks the fields of an object against their specs
+func validateFields(specs []fieldSpec, object map[string]interface{}) error {
+	for _, spec := range specs {
+		value, present := object[spec.name]
+		if !present || value == nil {
+			if spec.required {
+				return fmt.Errorf("%s is required", spec.name)
+			}
+			continue
+		}
+		if err := validateValue(spec.name, spec.typ, value); err != nil {
+			return err
+		}
+
+		switch spec.typ {
+		case fieldArray:
+			if spec.elem == "" {
+				continue
+			}
+			for i, item := range value.([]interface{}) {
+				if err := validateValue(fmt.Sprintf("%s[%d]", spec.name, i), spec.elem, item); err != nil {
+					return err
+				}
+			}
+		case fieldObject:
+			if err := validateFields(spec.fields, value.(map[string]interface{})); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateValue checks that a decoded JSON value has the expected type
+func validateValue(name string, typ fieldType, value interface{}) error {
+	valid := false
+	switch typ {
+	case fieldString:
+		_, valid = value.(string)
+	case fieldNumber:
+		_, valid = value.(float64)
+	case fieldInteger:
+		number, ok := value.(float64)
+		valid = ok && number == float64(int(number))
+	case fieldBool:
+		_, valid = value.(bool)
+	case fieldObject:
+		_, valid = value.(map[string]interface{})
+	case fieldArray:
+		_, valid = value.([]interface{})
+	}
+
+	if !valid {
+		return fmt.Errorf("%s must be %s, got %s", name, typ, jsonTypeName(value))
+	}
+	return nil
+}
+
+// jsonTypeName returns the JSON type of a decoded value
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		if v != float64(int(v)) {
+			return fmt.Sprintf("number %v", v)
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// decodePayload decodes a JSON payload the way commands arrive from the backend
+func decodePayload(t *testing.T, document string) map[string]interface{} {
+	t.Helper()
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &payload); err != nil {
+		t.Fatalf("invalid test payload %s: %v", document, err)
+	}
+	return payload
+}
+
+func TestValidatePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdType string
+		payload string
+		wantErr string
+	}{
+		{"valid", "kill_process", `{"pid":42,"signal":"TERM","confirm_name":"nginx"}`, ""},
+		{"missing required", "kill_process", `{"pid":42,"signal":"TERM"}`, "kill_process: confirm_name is required"},
+		{"null required", "get_environment", `{"pid":null}`, "get_environment: pid is required"},
+		{"wrong type", "truncate_log", `{"path":7}`, "truncate_log: path must be a string, got number"},
+		{"fractional integer", "get_open_files", `{"pid":1.5}`, "get_open_files: pid must be an integer, got number 1.5"},
+		{"optional omitted", "get_dmesg", `{}`, ""},
+		{"array element", "sysctl_get", `{"keys":["vm.swappiness",3]}`, "sysctl_get: keys[1] must be a string, got number"},
+		{"nested object", "update_config", `{"config":{"log_paths":"/var/log/syslog"}}`, "update_config: log_paths must be an array, got string"},
+		{"extra field ignored", "set_log_level", `{"level":"debug","reason":"incident"}`, ""},
+		// Commands without a schema read no payload fields and are not validated
+		{"no schema", "ping", `{"pid":"not checked"}`, ""},
+		{"unknown command", "format_disk", `{"device":1}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePayload(tt.cmdType, decodePayload(t, tt.payload))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidatePayload() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidatePayload() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
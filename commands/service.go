@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+const (
+	serviceActiveTimeout      = 30 * time.Second
+	serviceActivePollInterval = 500 * time.Millisecond
+
+	defaultHealthCheckSeconds = 10
+	maxHealthCheckSeconds     = 60
+
+	// healthCheckReserve is left before the command deadline to encode and send the result
+	healthCheckReserve = 2 * time.Second
+)
+
+// serviceNamePattern matches systemd unit names (no options or paths)
+var serviceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.@-]*$`)
+
+// handleRestartService handles the restart_service command
+// Restarts a systemd service, waits for it to become active and optionally runs an HTTP health check
+func (h *Handler) handleRestartService(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	service, err := requireString(cmd.Payload, "service")
+	if err != nil {
+		return "", err
+	}
+	if !serviceNamePattern.MatchString(service) {
+		return "", fmt.Errorf("invalid service name: %s", service)
+	}
+	if !isServiceAllowed(service, cfg.AllowedServiceActions) {
+		return "", fmt.Errorf("service %s is not in allowed_service_actions", service)
+	}
+
+	healthURL, _ := payloadString(cmd.Payload, "health_check_url")
+	healthTimeout := defaultHealthCheckSeconds
+	if seconds, ok := payloadInt(cmd.Payload, "health_check_timeout_seconds"); ok && seconds > 0 {
+		if seconds > maxHealthCheckSeconds {
+			seconds = maxHealthCheckSeconds
+		}
+		healthTimeout = seconds
+	}
+
+	logging.Infof("Restarting service %s", service)
+	restartedAt := time.Now()
+	if output, err := exec.CommandContext(ctx, "systemctl", "restart", service).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("systemctl restart failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	// Wait for the unit to report active
+	activeAfter, err := waitForServiceActive(ctx, service)
+	if err != nil {
+		return "", err
+	}
+
+	result := map[string]interface{}{
+		"restarted_at":        restartedAt.UTC().Format(time.RFC3339),
+		"active_after_ms":     activeAfter.Milliseconds(),
+		"health_check_passed": nil, // No health check requested
+	}
+	if healthURL != "" {
+		passed, err := false, errNoHealthCheckTime
+		if budget := healthCheckBudget(ctx, time.Duration(healthTimeout)*time.Second); budget > 0 {
+			passed, err = checkServiceHealth(ctx, healthURL, budget)
+		}
+		if err != nil {
+			logging.Warnf("Health check for %s failed: %v", service, err)
+			result["health_check_error"] = err.Error()
+		}
+		result["health_check_passed"] = passed
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+// errNoHealthCheckTime is reported when the restart used up the command timeout
+var errNoHealthCheckTime = errors.New("no time left for the health check within the command timeout")
+
+// healthCheckBudget caps the requested health check timeout so the check ends
+// healthCheckReserve before ctx's deadline (the command timeout)
+// Returns 0 or less when there is no time left
+func healthCheckBudget(ctx context.Context, requested time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return requested
+	}
+	if remaining := time.Until(deadline) - healthCheckReserve; remaining < requested {
+		return remaining
+	}
+	return requested
+}
+
+// isServiceAllowed checks a service against the allowed list (".service" suffix optional)
+func isServiceAllowed(service string, allowed []string) bool {
+	name := strings.TrimSuffix(service, ".service")
+	for _, entry := range allowed {
+		if strings.TrimSuffix(entry, ".service") == name {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForServiceActive polls 'systemctl is-active' until the service is active
+// Returns how long it took since the call, or an error after 30 seconds
+func waitForServiceActive(ctx context.Context, service string) (time.Duration, error) {
+	start := time.Now()
+	deadline := start.Add(serviceActiveTimeout)
+
+	for {
+		if exec.CommandContext(ctx, "systemctl", "is-active", "--quiet", service).Run() == nil {
+			return time.Since(start), nil
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("service %s did not become active within %v", service, serviceActiveTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(serviceActivePollInterval):
+		}
+	}
+}
+
+// checkServiceHealth performs an HTTP GET and passes on any 2xx response
+func checkServiceHealth(ctx context.Context, url string, timeout time.Duration) (bool, error) {
+	client := &http.Client{Timeout: timeout}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, fmt.Errorf("invalid health check URL: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return true, nil
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHealthCheckBudget(t *testing.T) {
+	requested := 60 * time.Second
+
+	if got := healthCheckBudget(context.Background(), requested); got != requested {
+		t.Errorf("healthCheckBudget() without deadline = %v, want %v", got, requested)
+	}
+
+	// The default 60s command timeout with 30s already spent on the restart
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	got := healthCheckBudget(ctx, requested)
+	if got > 30*time.Second-healthCheckReserve || got < 25*time.Second {
+		t.Errorf("healthCheckBudget() with 30s left = %v, want just under %v", got, 30*time.Second-healthCheckReserve)
+	}
+	if got := healthCheckBudget(ctx, 5*time.Second); got != 5*time.Second {
+		t.Errorf("healthCheckBudget() for a short check = %v, want 5s", got)
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), time.Second)
+	defer cancelShort()
+	if got := healthCheckBudget(short, requested); got > 0 {
+		t.Errorf("healthCheckBudget() with 1s left = %v, want no time", got)
+	}
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"vpsentinel-agent/config"
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+// handleShowConfig handles the show_config command
+// Returns the current config with credentials redacted
+func (h *Handler) handleShowConfig(ctx context.Context, cmd models.Command) (string, error) {
+	logging.Infof("Received show_config command")
+
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := json.Marshal(config.Redact(cfg))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/metrics"
+	"vpsentinel-agent/models"
+	"vpsentinel-agent/services"
+)
+
+// snapshotTimeout bounds the collection done by get_metrics_snapshot
+const snapshotTimeout = 15 * time.Second
+
+// handleGetMetricsSnapshot handles the get_metrics_snapshot command
+// Collects live system metrics and services and returns them in the response
+// instead of sending a payload to the backend
+func (h *Handler) handleGetMetricsSnapshot(ctx context.Context, cmd models.Command) (string, error) {
+	logging.Infof("Collecting metrics snapshot...")
+
+	snapshotCtx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	sysMetrics, err := metrics.CollectSystem(snapshotCtx)
+	collectionError := ""
+	if err != nil {
+		// Partial metrics are still returned
+		collectionError = err.Error()
+	}
+
+	detected := services.DetectAllServices()
+
+	result, err := json.Marshal(map[string]interface{}{
+		"timestamp": time.Now().UTC(),
+		"system":    sysMetrics,
+		"services":  detected,
+		"error":     collectionError,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"vpsentinel-agent/models"
+)
+
+// maxSysctlKeys limits how many parameters a single sysctl_get command reads
+const maxSysctlKeys = 50
+
+// sysctlKeyPattern matches dotted kernel parameter names (no options or paths)
+var sysctlKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// handleSysctlGet handles the sysctl_get command
+// Reads kernel parameters allowed by allowed_sysctl_keys; values are never written
+func (h *Handler) handleSysctlGet(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	rawKeys, _ := cmd.Payload["keys"].([]interface{})
+	if len(rawKeys) == 0 {
+		return "", fmt.Errorf("missing required field: keys")
+	}
+	if len(rawKeys) > maxSysctlKeys {
+		return "", fmt.Errorf("too many keys (max %d)", maxSysctlKeys)
+	}
+
+	// Check every key before reading any of them
+	keys := make([]string, 0, len(rawKeys))
+	for _, raw := range rawKeys {
+		key, _ := raw.(string)
+		if !sysctlKeyPattern.MatchString(key) {
+			return "", fmt.Errorf("invalid sysctl key: %q", key)
+		}
+		if !isSysctlKeyAllowed(key, cfg.AllowedSysctlKeys) {
+			return "", fmt.Errorf("sysctl key %s is not in allowed_sysctl_keys", key)
+		}
+		keys = append(keys, key)
+	}
+
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		output, err := exec.CommandContext(ctx, "sysctl", "-n", key).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		values[key] = strings.TrimSpace(string(output))
+	}
+
+	result, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// isSysctlKeyAllowed checks a key against the allowlist's glob patterns (e.g. "net.*")
+func isSysctlKeyAllowed(key string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if matched, err := filepath.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
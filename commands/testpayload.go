@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+// handleSendTestPayload handles the send_test_payload command
+// Runs a full collection and a single send attempt immediately instead of waiting
+// for the next cycle; the send is cancelled with the command (no retries)
+// A failed send is reported in the result's "status_code" and "error" fields
+// rather than as a command error
+func (h *Handler) handleSendTestPayload(ctx context.Context, cmd models.Command) (string, error) {
+	if h.collect == nil || h.client == nil {
+		return "", fmt.Errorf("metrics collection not available")
+	}
+
+	logging.Infof("Sending test payload...")
+	start := time.Now()
+
+	payload := h.collect()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	statusCode := 0
+	size, err := h.client.PayloadSize(payload)
+	if err == nil {
+		statusCode, err = h.client.SendOnce(ctx, payload)
+	}
+	if err == nil && h.history != nil {
+		h.history.Push(payload)
+	}
+
+	errMessage := ""
+	if err != nil {
+		errMessage = err.Error()
+		logging.Errorf("Test payload failed: %v", err)
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"duration_ms":        time.Since(start).Milliseconds(),
+		"payload_size_bytes": size,
+		"status_code":        statusCode,
+		"error":              errMessage,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"vpsentinel-agent/models"
+	"vpsentinel-agent/transport"
+)
+
+// sendTestPayload runs send_test_payload against a backend answering with handler
+// and returns the decoded result and the number of ingest requests received
+func sendTestPayload(t *testing.T, ctx context.Context, handler http.HandlerFunc) (map[string]interface{}, int32, error) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		handler(w, r)
+	}))
+	defer server.Close()
+
+	h := NewHandler("", transport.NewClient(server.URL, "test-key", "1.0.0"), nil)
+	h.SetCollector(func() models.Payload {
+		return models.Payload{Host: "web-01"}
+	})
+
+	out, err := h.Execute(ctx, models.Command{ID: "cmd-1", Type: "send_test_payload"})
+	if err != nil {
+		return nil, atomic.LoadInt32(&requests), err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	return result, atomic.LoadInt32(&requests), nil
+}
+
+func TestSendTestPayloadStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantError string
+	}{
+		{"accepted", http.StatusAccepted, ""},
+		{"server error", http.StatusServiceUnavailable, "HTTP 503"},
+		{"unauthorized", http.StatusUnauthorized, "HTTP 401"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			result, requests, err := sendTestPayload(t, context.Background(), func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			// A single attempt: failures are not retried with backoff
+			if requests != 1 {
+				t.Errorf("backend received %d requests, want 1", requests)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("send_test_payload took %v, want a single quick attempt", elapsed)
+			}
+			if got := result["status_code"]; got != float64(tt.status) {
+				t.Errorf("status_code = %v, want %d", got, tt.status)
+			}
+			errMessage, _ := result["error"].(string)
+			if tt.wantError == "" && errMessage != "" || !strings.Contains(errMessage, tt.wantError) {
+				t.Errorf("error = %q, want %q", errMessage, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestSendTestPayloadCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, _, err := sendTestPayload(t, ctx, func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the agent gives up (the server only notices the
+		// closed connection once the body has been read)
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("send_test_payload took %v, want it cancelled with the command", elapsed)
+	}
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := result["status_code"]; got != float64(0) {
+		t.Errorf("status_code = %v, want 0 without a response", got)
+	}
+	if errMessage, _ := result["error"].(string); !strings.Contains(errMessage, "context deadline exceeded") {
+		t.Errorf("error = %q, want the context error", errMessage)
+	}
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+	"vpsentinel-agent/network"
+)
+
+const (
+	testSSLTimeout    = 30 * time.Second
+	maxTestSSLDomains = 20
+)
+
+// handleTestSSL handles the test_ssl command
+// Runs an SSL check right away, e.g. to confirm a renewal without waiting for the next cycle
+func (h *Handler) handleTestSSL(ctx context.Context, cmd models.Command) (string, error) {
+	var domains []string
+	if rawDomains, ok := cmd.Payload["domains"].([]interface{}); ok && len(rawDomains) > 0 {
+		if len(rawDomains) > maxTestSSLDomains {
+			return "", fmt.Errorf("too many domains (max %d)", maxTestSSLDomains)
+		}
+		for _, raw := range rawDomains {
+			if domain, ok := raw.(string); ok && domain != "" {
+				domains = append(domains, domain)
+			}
+		}
+	} else {
+		cfg, err := h.loadConfig()
+		if err != nil {
+			return "", err
+		}
+		domains = cfg.SSLDomains
+	}
+	if len(domains) == 0 {
+		return "", fmt.Errorf("no domains given and no ssl_domains configured")
+	}
+
+	logging.Infof("Testing SSL certificates for %d domain(s)", len(domains))
+
+	// CheckSSL has its own per-domain timeouts, this bounds the whole run
+	ctx, cancel := context.WithTimeout(ctx, testSSLTimeout)
+	defer cancel()
+	done := make(chan []models.SSLCheckResult, 1)
+	go func() {
+		// Per-domain failures are reported in each result's Error
+		results, _ := network.CheckSSL(domains)
+		done <- results
+	}()
+
+	var results []models.SSLCheckResult
+	select {
+	case results = <-done:
+	case <-ctx.Done():
+		return "", fmt.Errorf("SSL check did not finish within %v", testSSLTimeout)
+	}
+
+	result, err := json.Marshal(results)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+// ActiveCommandTracker keeps track of commands running in the background
+// so shutdown can wait for them
+type ActiveCommandTracker struct {
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	active map[string]string // Command ID -> type
+}
+
+// NewActiveCommandTracker creates an empty tracker
+func NewActiveCommandTracker() *ActiveCommandTracker {
+	return &ActiveCommandTracker{active: make(map[string]string)}
+}
+
+// Add marks a command as running
+func (t *ActiveCommandTracker) Add(cmd models.Command) {
+	t.mu.Lock()
+	t.active[cmd.ID] = cmd.Type
+	t.mu.Unlock()
+	t.wg.Add(1)
+}
+
+// Done marks a command as finished
+func (t *ActiveCommandTracker) Done(cmd models.Command) {
+	t.mu.Lock()
+	delete(t.active, cmd.ID)
+	t.mu.Unlock()
+	t.wg.Done()
+}
+
+// WaitWithTimeout waits for all running commands to finish
+// Returns false if some were still running after d
+func (t *ActiveCommandTracker) WaitWithTimeout(d time.Duration) bool {
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+// Running lists the commands still running as "type (ID: id)"
+func (t *ActiveCommandTracker) Running() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	running := make([]string, 0, len(t.active))
+	for id, cmdType := range t.active {
+		running = append(running, fmt.Sprintf("%s (ID: %s)", cmdType, id))
+	}
+	sort.Strings(running)
+	return running
+}
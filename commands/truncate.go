@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+const (
+	// maxTruncateKeepLines caps the lines truncate_log can keep
+	maxTruncateKeepLines = 10000
+	// tailChunkSize is how much is read per step when looking for the last lines
+	tailChunkSize = 64 * 1024
+)
+
+// handleTruncateLog handles the truncate_log command
+// Truncates a log file in place (keeping its inode) and optionally keeps the last lines
+func (h *Handler) handleTruncateLog(ctx context.Context, cmd models.Command) (string, error) {
+	cfg, err := h.loadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := requireString(cmd.Payload, "path")
+	if err != nil {
+		return "", err
+	}
+	path = filepath.Clean(path)
+
+	// Resolve symlinks so a link inside an allowed directory can't truncate other files
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if !isPathAllowed(resolved, cfg.AllowedWritePaths) {
+		return "", fmt.Errorf("path %s is not in allowed_write_paths", path)
+	}
+
+	keepLines, _ := payloadInt(cmd.Payload, "keep_lines")
+	if keepLines < 0 || keepLines > maxTruncateKeepLines {
+		return "", fmt.Errorf("keep_lines must be between 0 and %d", maxTruncateKeepLines)
+	}
+
+	f, err := os.OpenFile(resolved, os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+	previousSize := info.Size()
+
+	var tail []byte
+	if keepLines > 0 {
+		tail, err = readTailLines(f, previousSize, keepLines)
+		if err != nil {
+			return "", fmt.Errorf("failed to read last lines: %w", err)
+		}
+	}
+
+	logging.Infof("Truncating %s (%d bytes, keeping %d lines)", resolved, previousSize, keepLines)
+
+	if err := f.Truncate(0); err != nil {
+		return "", fmt.Errorf("failed to truncate file: %w", err)
+	}
+	if len(tail) > 0 {
+		if _, err := f.WriteAt(tail, 0); err != nil {
+			return "", fmt.Errorf("failed to restore last lines: %w", err)
+		}
+	}
+
+	// Writers may already have appended again, so report what is on disk
+	newSize := int64(len(tail))
+	if info, err := f.Stat(); err == nil {
+		newSize = info.Size()
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"previous_size_bytes": previousSize,
+		"new_size_bytes":      newSize,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// readTailLines returns the last n lines of a file, reading backwards in chunks
+func readTailLines(f *os.File, size int64, n int) ([]byte, error) {
+	var tail []byte
+	offset := size
+
+	for offset > 0 {
+		chunk := int64(tailChunkSize)
+		if offset < chunk {
+			chunk = offset
+		}
+		offset -= chunk
+
+		buf := make([]byte, chunk)
+		if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+
+		// A trailing newline ends the last line rather than starting a new one
+		if bytes.Count(bytes.TrimSuffix(tail, []byte("\n")), []byte("\n")) >= n {
+			break
+		}
+	}
+
+	// Drop everything before the start of the n-th line from the end
+	trimmed := bytes.TrimSuffix(tail, []byte("\n"))
+	for i := 0; i < n; i++ {
+		idx := bytes.LastIndexByte(trimmed, '\n')
+		if idx < 0 {
+			return tail, nil // Fewer than n lines in the file
+		}
+		trimmed = trimmed[:idx]
+	}
+	return tail[len(trimmed)+1:], nil
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/metrics"
+	"vpsentinel-agent/models"
+)
+
+// securityUpdate is a pending security update reported by check_updates
+type securityUpdate struct {
+	Name string `json:"name"`
+	From string `json:"from,omitempty"` // Not reported by yum
+	To   string `json:"to"`
+}
+
+// handleCheckUpdates handles the check_updates command
+// Lists all pending security updates, without the per-cycle limit
+func (h *Handler) handleCheckUpdates(ctx context.Context, cmd models.Command) (string, error) {
+	logging.Infof("Checking for pending security updates")
+
+	updates, err := metrics.CollectAllPackageUpdates()
+	if err != nil {
+		return "", fmt.Errorf("failed to check package updates: %w", err)
+	}
+
+	security := []securityUpdate{}
+	for _, update := range updates {
+		if update.IsSecurityUpdate {
+			security = append(security, securityUpdate{
+				Name: update.Name,
+				From: update.CurrentVersion,
+				To:   update.AvailableVersion,
+			})
+		}
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"security_update_count": len(security),
+		"updates":               security,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxConfigBackups is how many previous versions of the config are kept
+const maxConfigBackups = 3
+
+// backupPath returns the path of the nth backup: <path>.bak, <path>.bak2, ...
+func backupPath(path string, n int) string {
+	if n == 1 {
+		return path + ".bak"
+	}
+	return fmt.Sprintf("%s.bak%d", path, n)
+}
+
+// SaveWithBackup writes the configuration like Save, after copying the current
+// file to <path>.bak (older backups rotate to .bak2 and .bak3)
+// If the write fails, the previous config is restored from the backup
+func SaveWithBackup(path string, cfg *Config) error {
+	return withBackup(path, func() error {
+		return Save(path, cfg)
+	})
+}
+
+// writeWithBackup replaces the config file with data, backing it up like SaveWithBackup
+func writeWithBackup(path string, data []byte) error {
+	return withBackup(path, func() error {
+		// Keep the file's permissions (the config holds the API key)
+		perm := os.FileMode(0600)
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+		if err := os.WriteFile(path, data, perm); err != nil {
+			return fmt.Errorf("failed to write config file: %w", err)
+		}
+		return nil
+	})
+}
+
+// withBackup backs up the config file, runs write, and restores the backup if write fails
+func withBackup(path string, write func() error) error {
+	backedUp, err := backupConfig(path)
+	if err != nil {
+		return fmt.Errorf("failed to back up config file: %w", err)
+	}
+
+	if err := write(); err != nil {
+		if backedUp {
+			if restoreErr := copyFile(backupPath(path, 1), path); restoreErr != nil {
+				return fmt.Errorf("%w (restoring backup also failed: %v)", err, restoreErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// backupConfig rotates existing backups and copies the current config to <path>.bak
+// Returns false if there was no config file to back up
+func backupConfig(path string) (bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	// Drop the oldest backup and shift the others up by one
+	for n := maxConfigBackups - 1; n >= 1; n-- {
+		if err := os.Rename(backupPath(path, n), backupPath(path, n+1)); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+
+	if err := copyFile(path, backupPath(path, 1)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// copyFile copies src to dst, keeping src's permissions (configs hold credentials)
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
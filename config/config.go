@@ -1,36 +1,108 @@
 package config
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
 )
 
 // Config represents the agent configuration structure
 type Config struct {
+	SchemaVersion int `json:"schema_version,omitempty"` // Config schema version (managed by MigrateConfig)
+
 	// Required fields
-	APIKey          string `json:"api_key"`
-	BackendURL      string `json:"backend_url"`
-	IntervalSeconds int    `json:"interval_seconds"`
+	APIKey          string   `json:"api_key"`
+	APIKeys         []string `json:"api_keys,omitempty"` // Next keys tried when the backend deprecates api_key (rotation)
+	BackendURL      string   `json:"backend_url"`
+	IntervalSeconds int      `json:"interval_seconds"`
 
 	// Optional fields
-	Hostname      string   `json:"hostname,omitempty"`       // Override system hostname
-	LogPaths      []string `json:"log_paths,omitempty"`      // Paths to log files to monitor
-	LogMaxLines   int      `json:"log_max_lines,omitempty"`  // Maximum lines to read from each log (default: 100)
-	SSLDomains    []string `json:"ssl_domains,omitempty"`    // Domains to check SSL certificates for
-	PortsToMonitor []int   `json:"ports_to_monitor,omitempty"` // Specific ports to monitor (empty = all)
+	AdaptiveInterval           bool                        `json:"adaptive_interval,omitempty"`              // Collect less often while the server is idle
+	MaxAdaptiveIntervalSeconds int                         `json:"max_adaptive_interval_seconds,omitempty"`  // Longest idle interval (default: 5x interval_seconds)
+	Hostname                   string                      `json:"hostname,omitempty"`                       // Override system hostname
+	LogPaths                   []string                    `json:"log_paths,omitempty"`                      // Paths to log files to monitor
+	LogMaxLines                int                         `json:"log_max_lines,omitempty"`                  // Maximum lines to read from each log (default: 100)
+	LogRateLimitBytesPerCycle  int                         `json:"log_rate_limit_bytes_per_cycle,omitempty"` // Max log bytes sent per cycle (0 = unlimited)
+	DisableSanitizeRules       []string                    `json:"disable_sanitize_rules,omitempty"`         // Built-in log sanitization rules to skip (e.g. "password_keyword")
+	EnableOOMDetection         bool                        `json:"enable_oom_detection,omitempty"`           // Report OOM killer events from the kernel log
+	KernLogPath                string                      `json:"kern_log_path,omitempty"`                  // Kernel log scanned for OOM events (default: /var/log/kern.log)
+	SSLDomains                 []string                    `json:"ssl_domains,omitempty"`                    // Domains to check SSL certificates for
+	EnableCTLogCheck           bool                        `json:"enable_ct_log_check,omitempty"`            // Look up recently issued certificates for SSL domains
+	PortsToMonitor             []int                       `json:"ports_to_monitor,omitempty"`               // Specific ports to monitor (empty = all)
+	ExcludeMountPoints         []string                    `json:"exclude_mount_points"`                     // Mount point globs left out of disk metrics (default: /boot/efi, /snap/*)
+	ExcludeFSTypes             []string                    `json:"exclude_fs_types"`                         // Filesystem types left out of disk usage (default: proc, sysfs, devtmpfs, tmpfs, squashfs)
+	ExcludeNetworkInterfaces   []string                    `json:"exclude_network_interfaces"`               // Interface globs left out of network stats (default: lo, lo0, docker0, br-*, veth*)
+	CollectionTimeouts         map[string]int              `json:"collection_timeouts,omitempty"`            // Per-subsystem collection timeouts in seconds (e.g. "metrics")
+	MetricsHistorySize         int                         `json:"metrics_history_size,omitempty"`           // Payloads kept in memory for get_metrics_history (default: 10)
+	HTTPEndpoints              []models.HTTPEndpointConfig `json:"http_endpoints,omitempty"`                 // HTTP endpoints to check for uptime
+	EnableLVMMetrics           bool                        `json:"enable_lvm_metrics,omitempty"`             // Report LVM logical volumes and thin pool usage
+	HealthPort                 int                         `json:"health_port,omitempty"`                    // Port serving the agent state on /healthz (0 = disabled)
+	HealthAllowedIPs           []string                    `json:"health_allowed_ips"`                       // IPs or CIDRs allowed to query /healthz (default: localhost, empty = all)
+	StatsDListenAddr           string                      `json:"statsd_listen_addr,omitempty"`             // UDP address to receive StatsD metrics on (e.g. "127.0.0.1:8125", empty = disabled)
+	EnableGeoIP                bool                        `json:"enable_geoip,omitempty"`                   // Report the outbound IP and its location
+	GeoIPURL                   string                      `json:"geoip_url,omitempty"`                      // IP-info API (default: https://ipinfo.io/json)
+
+	// Transport settings
+	CircuitBreakerOpenSeconds int               `json:"circuit_breaker_open_seconds,omitempty"` // Pause after repeated send failures (default: 60)
+	MaxRequestsPerSecond      float64           `json:"max_requests_per_second,omitempty"`      // Limit on backend requests across ingest, commands and pings (default: 10)
+	SigningSecret             string            `json:"signing_secret,omitempty"`               // HMAC secret for signing ingest requests
+	BackendTLSPins            []string          `json:"backend_tls_pins,omitempty"`             // SHA-256 fingerprints of the backend leaf certificate
+	SerializationFormat       string            `json:"serialization_format,omitempty"`         // Ingest payload encoding: "json" (default) or "msgpack"
+	CustomHeaders             map[string]string `json:"custom_headers,omitempty"`               // Extra headers sent with every backend request
+
+	// Security audits (disabled by default)
+	EnableCronAudit     bool     `json:"enable_cron_audit,omitempty"`     // Report system and user cron jobs
+	EnableSSHAudit      bool     `json:"enable_ssh_audit,omitempty"`      // Report sshd hardening settings
+	EnableFileAudit     bool     `json:"enable_file_audit,omitempty"`     // Report SUID and world-writable files
+	FileAuditRoots      []string `json:"file_audit_roots,omitempty"`      // Directories scanned by the file audit (default: /usr, /bin, /sbin)
+	EnableEtcAudit      bool     `json:"enable_etc_audit,omitempty"`      // Report recently modified files under /etc
+	EtcAuditHours       int      `json:"etc_audit_hours,omitempty"`       // How far back the /etc audit looks (default: 24)
+	EnablePackageAudit  bool     `json:"enable_package_audit,omitempty"`  // Report pending package updates
+	EnableSecurityAudit bool     `json:"enable_security_audit,omitempty"` // Report services listening on unusual ports
+
+	// Remote commands
+	CommandTimeoutSeconds int `json:"command_timeout_seconds,omitempty"` // Maximum run time of a backend command (default: 60)
+
+	// Remote inspection commands (disabled by default)
+	EnableEnvInspection     bool     `json:"enable_env_inspection,omitempty"`     // Allow the get_environment command
+	EnvVarDenylist          []string `json:"env_var_denylist"`                    // Glob patterns of variables never returned
+	EnableProcessInspection bool     `json:"enable_process_inspection,omitempty"` // Allow the get_open_files command
+	EnableProcessControl    bool     `json:"enable_process_control,omitempty"`    // Allow the kill_process command
+	AllowedWritePaths       []string `json:"allowed_write_paths,omitempty"`       // Directories remote commands may write to (empty = none)
+	AllowedReadPaths        []string `json:"allowed_read_paths,omitempty"`        // Directories remote commands may read from (empty = none)
+	AllowedServiceActions   []string `json:"allowed_service_actions,omitempty"`   // systemd services remote commands may restart (empty = none)
+	AllowedSysctlKeys       []string `json:"allowed_sysctl_keys"`                 // Kernel parameter globs sysctl_get may read (default: net.*, vm.*, kernel.hostname)
+	EnableBenchmarkCommand  bool     `json:"enable_benchmark_command,omitempty"`  // Allow the benchmark command
+	EnablePacketCapture     bool     `json:"enable_packet_capture,omitempty"`     // Allow the tcpdump_capture command (requires root)
 }
 
 // Load reads and parses the configuration file
+// Older config files are migrated in memory; saving the migrated file is best
+// effort, so a read-only config still loads
 func Load(path string) (*Config, error) {
-	f, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
-	defer f.Close()
+
+	// Upgrade older config files before strict parsing
+	data, migrated, err := migrateData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate config file: %w", err)
+	}
 
 	var cfg Config
-	decoder := json.NewDecoder(f)
+	decoder := json.NewDecoder(bytes.NewReader(data))
 	decoder.DisallowUnknownFields() // Strict parsing
 	if err := decoder.Decode(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
@@ -41,12 +113,57 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
+	if migrated {
+		if err := writeWithBackup(path, data); err != nil {
+			logging.Warnf("Config file %s migrated to schema version %d in memory, but saving it failed: %v", path, CurrentSchemaVersion, err)
+		} else {
+			logging.Infof("Config file %s migrated to schema version %d (previous version saved to %s)", path, CurrentSchemaVersion, backupPath(path, 1))
+		}
+	}
+
 	// Set defaults for optional fields
 	cfg.SetDefaults()
 
 	return &cfg, nil
 }
 
+// LoadWithDefaults loads the configuration file, falling back to environment
+// variables (see LoadFromEnv) when the file doesn't exist
+// fileFound reports whether the configuration came from the file
+func LoadWithDefaults(path string) (cfg *Config, fileFound bool, err error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		cfg, err := LoadFromEnv()
+		return cfg, false, err
+	}
+
+	cfg, err = Load(path)
+	return cfg, true, err
+}
+
+// ErrNoConfigFile is returned by LoadForUpdate when the agent runs from environment variables only
+var ErrNoConfigFile = errors.New("no config file (the agent is configured from environment variables)")
+
+// LoadForUpdate loads the configuration file for a change that is saved back to it
+// Returns ErrNoConfigFile when the file doesn't exist, since the change couldn't be persisted
+func LoadForUpdate(path string) (*Config, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s not found", ErrNoConfigFile, path)
+	}
+	return Load(path)
+}
+
+// PromoteAPIKey makes key the primary API key after a rotation
+// Keys listed before it in api_keys are deprecated and dropped
+func (c *Config) PromoteAPIKey(key string) {
+	for i, candidate := range c.APIKeys {
+		if candidate == key {
+			c.APIKeys = c.APIKeys[i+1:]
+			break
+		}
+	}
+	c.APIKey = key
+}
+
 // Validate checks that all required configuration fields are present
 func (c *Config) Validate() error {
 	if c.APIKey == "" {
@@ -61,15 +178,87 @@ func (c *Config) Validate() error {
 	if c.IntervalSeconds < 10 {
 		return fmt.Errorf("interval_seconds must be at least 10 seconds (got %d)", c.IntervalSeconds)
 	}
+	if c.MaxAdaptiveIntervalSeconds != 0 && c.MaxAdaptiveIntervalSeconds < c.IntervalSeconds {
+		return fmt.Errorf("max_adaptive_interval_seconds must be at least interval_seconds (got %d)", c.MaxAdaptiveIntervalSeconds)
+	}
 
 	// Validate backend URL is HTTPS
 	if len(c.BackendURL) < 8 || c.BackendURL[:8] != "https://" {
 		return fmt.Errorf("backend_url must use HTTPS (got %s)", c.BackendURL)
 	}
 
+	// Validate serialization format
+	if c.SerializationFormat != "" && c.SerializationFormat != "json" && c.SerializationFormat != "msgpack" {
+		return fmt.Errorf("serialization_format must be \"json\" or \"msgpack\" (got %s)", c.SerializationFormat)
+	}
+
+	// Validate TLS pins are SHA-256 fingerprints (hex, colons optional)
+	for _, pin := range c.BackendTLSPins {
+		normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(pin), ":", ""))
+		if _, err := hex.DecodeString(normalized); err != nil || len(normalized) != 64 {
+			return fmt.Errorf("backend_tls_pins entry %q is not a SHA-256 fingerprint", pin)
+		}
+	}
+
+	// Validate the StatsD listen address
+	if c.StatsDListenAddr != "" {
+		if _, _, err := net.SplitHostPort(c.StatsDListenAddr); err != nil {
+			return fmt.Errorf("statsd_listen_addr must be host:port (got %s)", c.StatsDListenAddr)
+		}
+	}
+
+	// Validate the health endpoint port
+	if c.HealthPort < 0 || c.HealthPort > 65535 {
+		return fmt.Errorf("health_port must be between 0 and 65535 (got %d)", c.HealthPort)
+	}
+	for _, entry := range c.HealthAllowedIPs {
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return fmt.Errorf("health_allowed_ips: invalid IP or CIDR %q", entry)
+		}
+	}
+
+	// Validate custom header names (values are opaque)
+	for name := range c.CustomHeaders {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("custom_headers: invalid header name %q", name)
+		}
+	}
+
+	// Expensive collectors need a longer interval
+	for _, rule := range intervalRules {
+		if !rule.applies(c) || c.IntervalSeconds >= rule.minSeconds {
+			continue
+		}
+		if rule.warnOnly {
+			logging.Warnf("%s: interval_seconds should be at least %d (got %d)", rule.name, rule.minSeconds, c.IntervalSeconds)
+			continue
+		}
+		return fmt.Errorf("%s: interval_seconds must be at least %d (got %d)", rule.name, rule.minSeconds, c.IntervalSeconds)
+	}
+
 	return nil
 }
 
+// headerNamePattern matches the header names allowed in custom_headers
+var headerNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*$`)
+
+// intervalRule raises the minimum interval when a costly feature is enabled
+type intervalRule struct {
+	name       string
+	applies    func(c *Config) bool
+	minSeconds int
+	warnOnly   bool // Log a warning instead of failing validation
+}
+
+// intervalRules lists the minimum intervals for resource-heavy collection
+var intervalRules = []intervalRule{
+	{
+		name:       "ssl_domains_over_10",
+		applies:    func(c *Config) bool { return len(c.SSLDomains) > 10 },
+		minSeconds: 60,
+	},
+}
+
 // SetDefaults applies default values to optional configuration fields
 func (c *Config) SetDefaults() {
 	if c.LogMaxLines <= 0 {
@@ -84,9 +273,86 @@ func (c *Config) SetDefaults() {
 	if c.PortsToMonitor == nil {
 		c.PortsToMonitor = []int{} // Empty slice = monitor all ports
 	}
+	if c.HTTPEndpoints == nil {
+		c.HTTPEndpoints = []models.HTTPEndpointConfig{} // Empty slice instead of nil
+	}
+	if c.AllowedWritePaths == nil {
+		c.AllowedWritePaths = []string{} // Empty slice = no writes allowed
+	}
+	if c.AllowedReadPaths == nil {
+		c.AllowedReadPaths = []string{} // Empty slice = no reads allowed
+	}
+	if c.ExcludeMountPoints == nil {
+		c.ExcludeMountPoints = []string{"/boot/efi", "/snap/*"}
+	}
+	if c.ExcludeFSTypes == nil {
+		c.ExcludeFSTypes = []string{"proc", "sysfs", "devtmpfs", "tmpfs", "squashfs"}
+	}
+	if c.ExcludeNetworkInterfaces == nil {
+		c.ExcludeNetworkInterfaces = []string{"lo", "lo0", "docker0", "br-*", "veth*"}
+	}
+	if c.HealthAllowedIPs == nil {
+		c.HealthAllowedIPs = []string{"127.0.0.1/8", "::1/128"} // Empty slice = allow all
+	}
+	if c.AllowedSysctlKeys == nil {
+		c.AllowedSysctlKeys = []string{"net.*", "vm.*", "kernel.hostname"}
+	}
+	if c.EnvVarDenylist == nil {
+		c.EnvVarDenylist = []string{"*PASSWORD*", "*SECRET*", "*KEY*", "*TOKEN*"}
+	}
+	if c.MetricsHistorySize <= 0 {
+		c.MetricsHistorySize = 10 // Default to the last 10 cycles
+	}
+	if c.KernLogPath == "" {
+		c.KernLogPath = "/var/log/kern.log"
+	}
+	if c.EtcAuditHours <= 0 {
+		c.EtcAuditHours = 24 // Default to changes in the last day
+	}
+	if c.SerializationFormat == "" {
+		c.SerializationFormat = "json"
+	}
+	if c.CommandTimeoutSeconds <= 0 {
+		c.CommandTimeoutSeconds = 60 // Default to 1 minute per command
+	}
+	if c.MaxRequestsPerSecond <= 0 {
+		c.MaxRequestsPerSecond = 10
+	}
+	if c.CircuitBreakerOpenSeconds <= 0 {
+		c.CircuitBreakerOpenSeconds = 60 // Default to a 1 minute pause
+	}
+}
+
+// defaultCollectionTimeouts are used for subsystems without a configured timeout
+var defaultCollectionTimeouts = map[string]int{
+	"metrics": 15, // CPU sampling alone takes 2 seconds
+	"logs":    20,
+}
+
+// CollectionTimeout returns the collection timeout for a subsystem
+// Falls back to the subsystem default, then to 30 seconds
+func (c *Config) CollectionTimeout(subsystem string) time.Duration {
+	if seconds := c.CollectionTimeouts[subsystem]; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if seconds, ok := defaultCollectionTimeouts[subsystem]; ok {
+		return time.Duration(seconds) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// MaxAdaptiveInterval returns the longest interval adaptive mode may stretch to
+// Defaults to 5 times interval_seconds so it follows interval changes
+func (c *Config) MaxAdaptiveInterval() int {
+	if c.MaxAdaptiveIntervalSeconds > 0 {
+		return c.MaxAdaptiveIntervalSeconds
+	}
+	return 5 * c.IntervalSeconds
 }
 
 // Save writes the configuration to a file
+// Lists with a non-empty default are not tagged omitempty, so an explicit []
+// survives a save instead of reverting to the default on the next Load
 func Save(path string, cfg *Config) error {
 	f, err := os.Create(path)
 	if err != nil {
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"vpsentinel-agent/models"
+)
+
+// fullConfig returns a valid Config with every field set to a non-default value
+func fullConfig() *Config {
+	return &Config{
+		SchemaVersion:              CurrentSchemaVersion,
+		APIKey:                     "key-current",
+		APIKeys:                    []string{"key-next", "key-after"},
+		BackendURL:                 "https://api.example.com/",
+		IntervalSeconds:            120,
+		AdaptiveInterval:           true,
+		MaxAdaptiveIntervalSeconds: 600,
+		Hostname:                   "web-01",
+		LogPaths:                   []string{"/var/log/syslog", "journald://nginx"},
+		LogMaxLines:                250,
+		LogRateLimitBytesPerCycle:  65536,
+		DisableSanitizeRules:       []string{"password_keyword"},
+		EnableOOMDetection:         true,
+		KernLogPath:                "/var/log/messages",
+		SSLDomains:                 []string{"example.com", "api.example.com:8443"},
+		EnableCTLogCheck:           true,
+		PortsToMonitor:             []int{22, 80, 443},
+		ExcludeMountPoints:         []string{"/mnt/backup/*"},
+		ExcludeFSTypes:             []string{"overlay"},
+		ExcludeNetworkInterfaces:   []string{"tun*"},
+		CollectionTimeouts:         map[string]int{"metrics": 20, "logs": 30},
+		MetricsHistorySize:         50,
+		HTTPEndpoints: []models.HTTPEndpointConfig{
+			{URL: "https://example.com/health", ExpectedStatusCode: 204, TimeoutSeconds: 5, Headers: map[string]string{"X-Probe": "1"}},
+			{URL: "https://example.com/"},
+		},
+		EnableLVMMetrics:          true,
+		HealthPort:                9110,
+		HealthAllowedIPs:          []string{"10.0.0.0/8"},
+		StatsDListenAddr:          "127.0.0.1:8125",
+		EnableGeoIP:               true,
+		GeoIPURL:                  "https://geo.example.com/json",
+		CircuitBreakerOpenSeconds: 90,
+		MaxRequestsPerSecond:      2.5,
+		SigningSecret:             "hmac-secret",
+		BackendTLSPins:            []string{strings.Repeat("ab", 32)},
+		SerializationFormat:       "msgpack",
+		CustomHeaders:             map[string]string{"X-Tenant-ID": "acme"},
+		EnableCronAudit:           true,
+		EnableSSHAudit:            true,
+		EnableFileAudit:           true,
+		FileAuditRoots:            []string{"/usr", "/opt"},
+		EnableEtcAudit:            true,
+		EtcAuditHours:             48,
+		EnablePackageAudit:        true,
+		EnableSecurityAudit:       true,
+		CommandTimeoutSeconds:     30,
+		EnableEnvInspection:       true,
+		EnvVarDenylist:            []string{"*PASS*"},
+		EnableProcessInspection:   true,
+		EnableProcessControl:      true,
+		AllowedWritePaths:         []string{"/etc/app"},
+		AllowedReadPaths:          []string{"/var/www"},
+		AllowedServiceActions:     []string{"nginx"},
+		AllowedSysctlKeys:         []string{"net.ipv4.*"},
+		EnableBenchmarkCommand:    true,
+		EnablePacketCapture:       true,
+	}
+}
+
+// saveAndLoad saves cfg to a temporary file and loads it back
+func saveAndLoad(t *testing.T, cfg *Config) (*Config, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return loaded, path
+}
+
+func TestFullConfigSetsEveryField(t *testing.T) {
+	// Keeps the round-trip test honest when fields are added
+	value := reflect.ValueOf(*fullConfig())
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).IsZero() {
+			t.Errorf("fullConfig() leaves %s unset", value.Type().Field(i).Name)
+		}
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	cfg := fullConfig()
+	loaded, _ := saveAndLoad(t, cfg)
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("Load(Save(cfg)) differs from cfg\ngot:  %+v\nwant: %+v", loaded, cfg)
+	}
+}
+
+func TestSaveLoadExplicitEmptyLists(t *testing.T) {
+	// An explicit [] disables a default and must not revert to it after a save
+	cfg := fullConfig()
+	cfg.ExcludeMountPoints = []string{}
+	cfg.ExcludeFSTypes = []string{}
+	cfg.ExcludeNetworkInterfaces = []string{}
+	cfg.HealthAllowedIPs = []string{}
+	cfg.AllowedSysctlKeys = []string{}
+	cfg.EnvVarDenylist = []string{}
+	cfg.AllowedWritePaths = []string{}
+	cfg.AllowedReadPaths = []string{}
+
+	loaded, _ := saveAndLoad(t, cfg)
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("Load(Save(cfg)) differs from cfg\ngot:  %+v\nwant: %+v", loaded, cfg)
+	}
+}
+
+func TestSaveLoadDefaults(t *testing.T) {
+	// A minimal config picks up the same defaults before and after a save
+	cfg := &Config{SchemaVersion: CurrentSchemaVersion, APIKey: "key", BackendURL: "https://api.example.com", IntervalSeconds: 60}
+	cfg.SetDefaults()
+
+	loaded, _ := saveAndLoad(t, cfg)
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("Load(Save(cfg)) differs from cfg\ngot:  %+v\nwant: %+v", loaded, cfg)
+	}
+}
+
+func TestSavedConfigIsStrict(t *testing.T) {
+	_, path := saveAndLoad(t, fullConfig())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every saved key is a Config field, so strict parsing still applies
+	known := make(map[string]bool)
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		key, _, _ := strings.Cut(configType.Field(i).Tag.Get("json"), ",")
+		known[key] = true
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("saved config is not a JSON object: %v", err)
+	}
+	for key := range raw {
+		if !known[key] {
+			t.Errorf("saved config has unknown field %q", key)
+		}
+	}
+
+	// An unknown field added to a saved file is still rejected
+	tampered := bytes.Replace(data, []byte("{"), []byte(`{"unexpected_field": true,`), 1)
+	if err := os.WriteFile(path, tampered, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("Load() error = %v, want unknown field rejected", err)
+	}
+}
+
+func FuzzLoad(f *testing.F) {
+	full, err := json.Marshal(fullConfig())
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(full)
+	f.Add([]byte(v0Config))
+	f.Add([]byte(`{"api_key":"k","backend_url":"https://a.example","interval_seconds":60}`))
+	f.Add([]byte(`{"api_key":"k","backend_url":"https://a.example","interval_seconds":60,"health_allowed_ips":[],"collection_timeouts":{}}`))
+	f.Add([]byte(`{"schema_version":7}`))
+	f.Add([]byte(`{"api_key":"k","backend_url":"http://a.example","interval_seconds":5}`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`{"api_key":`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.json")
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		// Arbitrary input must never panic
+		cfg, err := Load(path)
+		if err != nil {
+			return
+		}
+
+		// Anything that loads must survive a save: it reloads, and saving
+		// again produces the same file
+		first := filepath.Join(dir, "first.json")
+		if err := Save(first, cfg); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		reloaded, err := Load(first)
+		if err != nil {
+			t.Fatalf("Load() of a saved config failed: %v", err)
+		}
+		second := filepath.Join(dir, "second.json")
+		if err := Save(second, reloaded); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		a, _ := os.ReadFile(first)
+		b, _ := os.ReadFile(second)
+		if !bytes.Equal(a, b) {
+			t.Errorf("saving a reloaded config changed it\nfirst:  %s\nsecond: %s", a, b)
+		}
+	})
+}
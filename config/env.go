@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envPrefix is prepended to every environment variable read by LoadFromEnv
+const envPrefix = "VPSENTINEL_"
+
+// LoadFromEnv builds a configuration from VPSENTINEL_* environment variables
+// Only the core settings are supported; everything else uses its default
+func LoadFromEnv() (*Config, error) {
+	cfg := Config{
+		SchemaVersion: CurrentSchemaVersion,
+		APIKey:        envString(envPrefix + "API_KEY"),
+		BackendURL:    envString(envPrefix + "BACKEND_URL"),
+		Hostname:      envString(envPrefix + "HOSTNAME"),
+		LogPaths:      envList(envPrefix + "LOG_PATHS"),
+		SSLDomains:    envList(envPrefix + "SSL_DOMAINS"),
+	}
+
+	var err error
+	if cfg.IntervalSeconds, err = envInt(envPrefix + "INTERVAL_SECONDS"); err != nil {
+		return nil, err
+	}
+	if cfg.LogMaxLines, err = envInt(envPrefix + "LOG_MAX_LINES"); err != nil {
+		return nil, err
+	}
+	for _, value := range envList(envPrefix + "PORTS_TO_MONITOR") {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%sPORTS_TO_MONITOR: invalid port %q", envPrefix, value)
+		}
+		cfg.PortsToMonitor = append(cfg.PortsToMonitor, port)
+	}
+
+	// Validate required fields
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	// Set defaults for optional fields
+	cfg.SetDefaults()
+
+	return &cfg, nil
+}
+
+// envString returns an environment variable with surrounding whitespace trimmed
+// A key exported from a file often carries a trailing newline
+func envString(name string) string {
+	return strings.TrimSpace(os.Getenv(name))
+}
+
+// envInt parses an integer environment variable (0 if unset)
+func envInt(name string) (int, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid integer %q", name, value)
+	}
+	return n, nil
+}
+
+// envList parses a comma-separated environment variable (nil if unset)
+func envList(name string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,35 @@
+package config
+
+import "testing"
+
+func TestLoadFromEnvTrimsValues(t *testing.T) {
+	t.Setenv(envPrefix+"API_KEY", " secret-key\n")
+	t.Setenv(envPrefix+"BACKEND_URL", "https://backend.example.com\n")
+	t.Setenv(envPrefix+"HOSTNAME", "\tweb-01 ")
+	t.Setenv(envPrefix+"INTERVAL_SECONDS", " 30 ")
+	t.Setenv(envPrefix+"LOG_PATHS", " /var/log/syslog , ,/var/log/auth.log")
+
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.APIKey != "secret-key" || cfg.BackendURL != "https://backend.example.com" || cfg.Hostname != "web-01" {
+		t.Errorf("LoadFromEnv() = api_key %q, backend_url %q, hostname %q; want trimmed values", cfg.APIKey, cfg.BackendURL, cfg.Hostname)
+	}
+	if cfg.IntervalSeconds != 30 {
+		t.Errorf("IntervalSeconds = %d, want 30", cfg.IntervalSeconds)
+	}
+	if len(cfg.LogPaths) != 2 || cfg.LogPaths[0] != "/var/log/syslog" || cfg.LogPaths[1] != "/var/log/auth.log" {
+		t.Errorf("LogPaths = %q, want [/var/log/syslog /var/log/auth.log]", cfg.LogPaths)
+	}
+}
+
+func TestLoadFromEnvBlankAPIKey(t *testing.T) {
+	// A whitespace-only key is as good as none
+	t.Setenv(envPrefix+"API_KEY", " \n")
+	t.Setenv(envPrefix+"BACKEND_URL", "https://backend.example.com")
+
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("LoadFromEnv() succeeded with a blank API key, want a validation error")
+	}
+}
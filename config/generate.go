@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// defaultValues are the example values written by GenerateDefault
+// Fields not listed get an empty value of their type
+var defaultValues = map[string]interface{}{
+	"schema_version":               CurrentSchemaVersion,
+	"api_key":                      "<YOUR_API_KEY>",
+	"backend_url":                  "https://api.vpsentinel.example.com",
+	"interval_seconds":             60,
+	"log_paths":                    []string{"/var/log/syslog"},
+	"log_max_lines":                100,
+	"kern_log_path":                "/var/log/kern.log",
+	"exclude_mount_points":         []string{"/boot/efi", "/snap/*"},
+	"exclude_fs_types":             []string{"proc", "sysfs", "devtmpfs", "tmpfs", "squashfs"},
+	"exclude_network_interfaces":   []string{"lo", "lo0", "docker0", "br-*", "veth*"},
+	"collection_timeouts":          map[string]int{"metrics": 15, "logs": 20},
+	"metrics_history_size":         10,
+	"geoip_url":                    "https://ipinfo.io/json",
+	"circuit_breaker_open_seconds": 60,
+	"max_requests_per_second":      10,
+	"serialization_format":         "json",
+	"file_audit_roots":             []string{"/usr", "/bin", "/sbin"},
+	"etc_audit_hours":              24,
+	"command_timeout_seconds":      60,
+	"env_var_denylist":             []string{"*PASSWORD*", "*SECRET*", "*KEY*", "*TOKEN*"},
+	"allowed_sysctl_keys":          []string{"net.*", "vm.*", "kernel.hostname"},
+	"health_allowed_ips":           []string{"127.0.0.1/8", "::1/128"},
+}
+
+// fieldComments describe each field in generated YAML configs
+var fieldComments = map[string]string{
+	"schema_version":                 "Config schema version (managed by the agent)",
+	"api_key":                        "Your VPSentinel agent key (get it from the dashboard)",
+	"api_keys":                       "Keys to switch to, in order, when the backend deprecates api_key",
+	"backend_url":                    "VPSentinel backend URL (must be HTTPS)",
+	"interval_seconds":               "Collection interval in seconds (minimum: 10)",
+	"adaptive_interval":              "Double the interval after 3 idle cycles (CPU < 5%, no errors or anomalies)",
+	"max_adaptive_interval_seconds":  "Longest interval in adaptive mode (0 = 5x interval_seconds)",
+	"hostname":                       "Override the system hostname (empty = system hostname)",
+	"log_paths":                      "Log files to monitor (use journald://<unit> for the systemd journal)",
+	"log_max_lines":                  "Maximum lines read from each log file",
+	"log_rate_limit_bytes_per_cycle": "Maximum log bytes sent per cycle (0 = unlimited)",
+	"disable_sanitize_rules":         "Built-in log sanitization rules to skip, e.g. [\"password_keyword\"]",
+	"enable_oom_detection":           "Report processes killed by the OOM killer",
+	"kern_log_path":                  "Kernel log scanned for OOM events",
+	"ssl_domains":                    "Domains to check SSL certificates for, e.g. [\"example.com\", \"example.com:8443\"]",
+	"enable_ct_log_check":            "Report recently logged certificates for SSL domains",
+	"ports_to_monitor":               "Specific ports to monitor (empty = all ports)",
+	"exclude_mount_points":           "Mount point globs left out of disk metrics (a trailing /* matches everything below)",
+	"exclude_fs_types":               "Filesystem types left out of disk usage",
+	"exclude_network_interfaces":     "Interface globs left out of network stats (loopback and container interfaces)",
+	"collection_timeouts":            "Per-subsystem collection timeouts in seconds",
+	"metrics_history_size":           "Payloads kept in memory for get_metrics_history",
+	"http_endpoints":                 "HTTP endpoints to check, e.g. [{\"url\": \"https://example.com/health\"}]",
+	"enable_lvm_metrics":             "Report LVM logical volumes and thin pool usage",
+	"health_port":                    "Port serving the agent state on /healthz (0 = disabled)",
+	"health_allowed_ips":             "IPs or CIDRs allowed to query /healthz (empty = all)",
+	"statsd_listen_addr":             "UDP address for StatsD metrics, e.g. \"127.0.0.1:8125\" (empty = disabled)",
+	"enable_geoip":                   "Report the outbound IP and its location",
+	"geoip_url":                      "IP-info API used for the GeoIP lookup",
+	"circuit_breaker_open_seconds":   "Pause after 5 consecutive send failures",
+	"max_requests_per_second":        "Limit on requests to the backend (ingest, commands, pings)",
+	"signing_secret":                 "Shared secret for HMAC-signing ingest requests",
+	"backend_tls_pins":               "SHA-256 fingerprints of the backend's leaf certificate",
+	"serialization_format":           "Ingest payload encoding: \"json\" or \"msgpack\"",
+	"custom_headers":                 "Extra headers sent with every backend request, e.g. {\"X-Tenant-ID\": \"acme\"}",
+	"enable_cron_audit":              "Report system and user cron jobs",
+	"enable_ssh_audit":               "Report sshd hardening settings",
+	"enable_file_audit":              "Report SUID and world-writable files",
+	"file_audit_roots":               "Directories scanned by the file audit",
+	"enable_etc_audit":               "Report recently modified files under /etc",
+	"etc_audit_hours":                "How far back the /etc audit looks, in hours",
+	"enable_package_audit":           "Report pending package updates",
+	"enable_security_audit":          "Report services listening on unusual ports",
+	"command_timeout_seconds":        "Maximum run time of a backend command in seconds",
+	"enable_env_inspection":          "Allow the get_environment command",
+	"env_var_denylist":               "Glob patterns of environment variables never returned",
+	"enable_process_inspection":      "Allow the get_open_files command",
+	"enable_process_control":         "Allow the kill_process command",
+	"allowed_write_paths":            "Directories remote commands may write to (empty = none)",
+	"allowed_read_paths":             "Directories remote commands may read from (empty = none)",
+	"allowed_service_actions":        "systemd services remote commands may restart (empty = none)",
+	"allowed_sysctl_keys":            "Kernel parameter globs the sysctl_get command may read",
+	"enable_benchmark_command":       "Allow the benchmark command",
+	"enable_packet_capture":          "Allow the tcpdump_capture command (requires root)",
+}
+
+// defaultField is one field of a generated config
+type defaultField struct {
+	key     string
+	value   []byte // JSON-encoded value (also valid YAML flow syntax)
+	comment string
+}
+
+// GenerateDefault writes a ready-to-edit config file listing every field
+// Paths ending in .yml or .yaml get YAML with a comment per field, anything
+// else gets JSON (which can't hold comments). Existing files are never overwritten.
+func GenerateDefault(path string) error {
+	fields, err := defaultFields()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if strings.HasSuffix(path, ".yml") || strings.HasSuffix(path, ".yaml") {
+		buf.WriteString("# VPSentinel Agent configuration\n# Replace api_key and backend_url, then enable the features you need\n")
+		for _, field := range fields {
+			buf.WriteString("\n")
+			if field.comment != "" {
+				fmt.Fprintf(&buf, "# %s\n", field.comment)
+			}
+			fmt.Fprintf(&buf, "%s: %s\n", field.key, field.value)
+		}
+	} else {
+		buf.WriteString("{\n")
+		for i, field := range fields {
+			fmt.Fprintf(&buf, "  %q: %s", field.key, field.value)
+			if i < len(fields)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString("}\n")
+	}
+
+	// The file will hold the API key, so keep it private
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create config file: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return f.Close()
+}
+
+// defaultFields lists every Config field in declaration order with its example value
+func defaultFields() ([]defaultField, error) {
+	configType := reflect.TypeOf(Config{})
+	fields := make([]defaultField, 0, configType.NumField())
+
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		value, ok := defaultValues[key]
+		if !ok {
+			value = emptyValue(field.Type)
+		}
+		// Keep placeholders like <YOUR_API_KEY> readable
+		var encoded bytes.Buffer
+		encoder := json.NewEncoder(&encoded)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(value); err != nil {
+			return nil, fmt.Errorf("failed to encode default for %s: %w", key, err)
+		}
+
+		fields = append(fields, defaultField{key: key, value: bytes.TrimSpace(encoded.Bytes()), comment: fieldComments[key]})
+	}
+
+	return fields, nil
+}
+
+// emptyValue returns the zero value of t, using empty slices and maps instead of null
+func emptyValue(t reflect.Type) interface{} {
+	switch t.Kind() {
+	case reflect.Slice:
+		return reflect.MakeSlice(t, 0, 0).Interface()
+	case reflect.Map:
+		return reflect.MakeMap(t).Interface()
+	default:
+		return reflect.Zero(t).Interface()
+	}
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// CurrentSchemaVersion is the config schema version this agent understands
+const CurrentSchemaVersion = 1
+
+// migration upgrades a raw config document by one schema version
+type migration func(raw map[string]interface{}) error
+
+// migrations[i] upgrades a config from version i to version i+1
+var migrations = []migration{
+	migrateV0toV1,
+}
+
+// MigrateConfig upgrades the config file at path to the current schema version
+// A missing "schema_version" field means version 0. The file is only rewritten
+// (after a backup to <path>.bak) when at least one migration was applied.
+func MigrateConfig(path string) (migrated bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	out, migrated, err := migrateData(data)
+	if err != nil || !migrated {
+		return false, err
+	}
+
+	if err := writeWithBackup(path, out); err != nil {
+		return false, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+	return true, nil
+}
+
+// migrateData upgrades a config document to the current schema version in memory
+// Returns data unchanged when no migration was needed
+// Top-level keys keep their original order so a rewritten file stays familiar
+func migrateData(data []byte) ([]byte, bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep numbers exactly as written
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, false, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	version, err := schemaVersion(raw)
+	if err != nil {
+		return nil, false, err
+	}
+	if version > CurrentSchemaVersion {
+		return nil, false, fmt.Errorf("config schema_version %d is newer than supported version %d", version, CurrentSchemaVersion)
+	}
+	if version == CurrentSchemaVersion {
+		return data, false, nil
+	}
+
+	// Apply migrations in order
+	for v := version; v < CurrentSchemaVersion; v++ {
+		if err := migrations[v](raw); err != nil {
+			return nil, false, fmt.Errorf("migration from schema version %d failed: %w", v, err)
+		}
+		raw["schema_version"] = v + 1
+	}
+
+	out, err := encodeOrdered(raw, topLevelKeys(data))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	return out, true, nil
+}
+
+// topLevelKeys returns the keys of a JSON object in document order
+func topLevelKeys(data []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return keys
+		}
+		key, ok := token.(string)
+		if !ok {
+			return keys
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return keys
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// encodeOrdered encodes raw as an indented JSON object
+// schema_version comes first, then the keys in order, then any keys added by migrations
+func encodeOrdered(raw map[string]interface{}, order []string) ([]byte, error) {
+	keys := []string{"schema_version"}
+	seen := map[string]bool{"schema_version": true}
+	for _, key := range order {
+		if _, ok := raw[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	var added []string
+	for key := range raw {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	keys = append(keys, added...)
+
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, key := range keys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.MarshalIndent(raw[key], "  ", "  ")
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("  ")
+		buf.Write(name)
+		buf.WriteString(": ")
+		buf.Write(value)
+		if i < len(keys)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// schemaVersion reads the schema_version field (absent = 0)
+func schemaVersion(raw map[string]interface{}) (int, error) {
+	value, ok := raw["schema_version"]
+	if !ok {
+		return 0, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("schema_version must be a number")
+	}
+	version, err := number.Int64()
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("schema_version must be a non-negative integer (got %s)", number)
+	}
+	return int(version), nil
+}
+
+// migrateV0toV1 introduces schema versioning and trims api_key and backend_url
+// Version 0 agents used both values verbatim, so a key pasted with a trailing
+// newline failed authentication; version 1 stores them trimmed, like LoadFromEnv
+func migrateV0toV1(raw map[string]interface{}) error {
+	for _, key := range []string{"api_key", "backend_url"} {
+		value, ok := raw[key]
+		if !ok {
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", key)
+		}
+		raw[key] = strings.TrimSpace(s)
+	}
+	return nil
+}
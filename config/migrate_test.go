@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// v0Config is a config written before schema versioning, with a pasted API key
+const v0Config = `{
+  "api_key": "  test-key-123\n",
+  "backend_url": "https://api.example.com ",
+  "interval_seconds": 30,
+  "log_max_lines": 100,
+  "allowed_write_paths": [],
+  "ssl_domains": ["example.com"]
+}
+`
+
+func TestMigrateDataV0toV1(t *testing.T) {
+	out, migrated, err := migrateData([]byte(v0Config))
+	if err != nil {
+		t.Fatalf("migrateData() error = %v", err)
+	}
+	if !migrated {
+		t.Fatal("migrateData() migrated = false, want true for a version 0 config")
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		t.Fatalf("migrated config is not valid JSON: %v\n%s", err, out)
+	}
+	if raw["schema_version"] != float64(1) {
+		t.Errorf("schema_version = %v, want 1", raw["schema_version"])
+	}
+	if raw["api_key"] != "test-key-123" {
+		t.Errorf("api_key = %q, want it trimmed", raw["api_key"])
+	}
+	if raw["backend_url"] != "https://api.example.com" {
+		t.Errorf("backend_url = %q, want it trimmed", raw["backend_url"])
+	}
+	// An explicit empty list must survive (it disables a default)
+	if paths, ok := raw["allowed_write_paths"].([]interface{}); !ok || len(paths) != 0 {
+		t.Errorf("allowed_write_paths = %v, want []", raw["allowed_write_paths"])
+	}
+
+	wantOrder := []string{"schema_version", "api_key", "backend_url", "interval_seconds", "log_max_lines", "allowed_write_paths", "ssl_domains"}
+	if got := topLevelKeys(out); !reflect.DeepEqual(got, wantOrder) {
+		t.Errorf("key order = %v, want %v", got, wantOrder)
+	}
+}
+
+func TestMigrateDataCurrentVersion(t *testing.T) {
+	data := []byte(`{"schema_version": 1, "api_key": " kept as is "}`)
+	out, migrated, err := migrateData(data)
+	if err != nil {
+		t.Fatalf("migrateData() error = %v", err)
+	}
+	if migrated || string(out) != string(data) {
+		t.Errorf("migrateData() = %q, %v, want the input unchanged", out, migrated)
+	}
+}
+
+func TestMigrateDataErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"newer version", `{"schema_version": 2}`, "newer than supported"},
+		{"negative version", `{"schema_version": -1}`, "non-negative"},
+		{"string version", `{"schema_version": "1"}`, "must be a number"},
+		{"invalid JSON", `{"api_key": `, "failed to parse"},
+		{"api_key not a string", `{"api_key": 123}`, "api_key must be a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := migrateData([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("migrateData() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigratesV0Config(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(v0Config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.SchemaVersion != CurrentSchemaVersion || cfg.APIKey != "test-key-123" {
+		t.Errorf("Load() = schema %d, key %q, want migrated values", cfg.SchemaVersion, cfg.APIKey)
+	}
+	if cfg.AllowedWritePaths == nil || len(cfg.AllowedWritePaths) != 0 {
+		t.Errorf("allowed_write_paths = %v, want explicit empty list kept", cfg.AllowedWritePaths)
+	}
+
+	// The file is upgraded, the original kept as a backup, permissions unchanged
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(saved), `"schema_version": 1`) {
+		t.Errorf("config file was not rewritten:\n%s", saved)
+	}
+	backup, err := os.ReadFile(backupPath(path, 1))
+	if err != nil || string(backup) != v0Config {
+		t.Errorf("backup = %q, %v, want the original file", backup, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("config file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A second load has nothing to migrate and doesn't rotate backups again
+	if _, err := Load(path); err != nil {
+		t.Fatalf("second Load() error = %v", err)
+	}
+	if _, err := os.Stat(backupPath(path, 2)); !os.IsNotExist(err) {
+		t.Errorf("second Load() created %s, want no further backup", backupPath(path, 2))
+	}
+}
+
+func TestLoadMigrationWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(v0Config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Make the backup rotation fail: .bak2 can't be renamed onto a non-empty directory
+	// (permissions can't be used to block writes when tests run as root)
+	if err := os.WriteFile(backupPath(path, 2), []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(backupPath(path, 3), "busy"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v, want the config loaded despite the failed save", err)
+	}
+	if cfg.SchemaVersion != CurrentSchemaVersion || cfg.APIKey != "test-key-123" {
+		t.Errorf("Load() = schema %d, key %q, want migrated values", cfg.SchemaVersion, cfg.APIKey)
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil || string(saved) != v0Config {
+		t.Errorf("config file = %q, %v, want it left untouched", saved, err)
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// redactedValue replaces secrets in redacted configs
+const redactedValue = "***REDACTED***"
+
+// redactedFields are the JSON keys of config fields holding credentials
+var redactedFields = []string{"api_key", "api_keys", "signing_secret"}
+
+// sensitiveHeaderWords mark header names whose values are credentials
+// (Authorization, Proxy-Authorization, Cookie, X-Api-Key, X-Auth-Token, ...)
+var sensitiveHeaderWords = []string{"auth", "cookie", "token", "key", "secret", "pass", "session"}
+
+// isSensitiveHeader reports whether a header value should be redacted
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeaders replaces the values of sensitive headers in a decoded header map
+func redactHeaders(headers interface{}) {
+	values, ok := headers.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for name := range values {
+		if isSensitiveHeader(name) {
+			values[name] = redactedValue
+		}
+	}
+}
+
+// Redact returns the config as a JSON-style map with credentials replaced,
+// including the values of credential-like headers (see isSensitiveHeader)
+// Safe to send to the backend for remote debugging
+func Redact(cfg *Config) map[string]interface{} {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+
+	var redacted map[string]interface{}
+	if err := json.Unmarshal(data, &redacted); err != nil {
+		return map[string]interface{}{}
+	}
+
+	for _, field := range redactedFields {
+		switch value := redacted[field].(type) {
+		case string:
+			if value != "" {
+				redacted[field] = redactedValue
+			}
+		case []interface{}:
+			for i := range value {
+				value[i] = redactedValue
+			}
+		}
+	}
+
+	// Headers often carry bearer tokens or session cookies
+	redactHeaders(redacted["custom_headers"])
+	if endpoints, ok := redacted["http_endpoints"].([]interface{}); ok {
+		for _, endpoint := range endpoints {
+			if fields, ok := endpoint.(map[string]interface{}); ok {
+				redactHeaders(fields["headers"])
+			}
+		}
+	}
+	return redacted
+}
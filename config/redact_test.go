@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"vpsentinel-agent/models"
+)
+
+func TestRedact(t *testing.T) {
+	cfg := fullConfig()
+	cfg.CustomHeaders = map[string]string{
+		"X-Tenant-ID":   "acme",
+		"Authorization": "Bearer custom-secret-1",
+		"Cookie":        "session=custom-secret-2",
+	}
+	cfg.HTTPEndpoints = []models.HTTPEndpointConfig{
+		{URL: "https://example.com/health", Headers: map[string]string{
+			"Proxy-Authorization": "Basic custom-secret-3",
+			"X-Api-Key":           "custom-secret-4",
+			"X-Auth-Token":        "custom-secret-5",
+			"Accept":              "application/json",
+		}},
+		{URL: "https://example.com/"},
+	}
+
+	redacted := Redact(cfg)
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	text := string(data)
+
+	for _, secret := range []string{"key-current", "key-next", "key-after", "hmac-secret", "custom-secret"} {
+		if strings.Contains(text, secret) {
+			t.Errorf("redacted config contains %q: %s", secret, text)
+		}
+	}
+
+	// Non-credential values stay visible for debugging
+	headers := redacted["custom_headers"].(map[string]interface{})
+	if headers["X-Tenant-ID"] != "acme" || headers["Authorization"] != redactedValue {
+		t.Errorf("custom_headers = %v", headers)
+	}
+	endpoint := redacted["http_endpoints"].([]interface{})[0].(map[string]interface{})
+	if accept := endpoint["headers"].(map[string]interface{})["Accept"]; accept != "application/json" {
+		t.Errorf("http_endpoints[0].headers.Accept = %v, want application/json", accept)
+	}
+	if redacted["backend_url"] != cfg.BackendURL {
+		t.Errorf("backend_url = %v, want %s", redacted["backend_url"], cfg.BackendURL)
+	}
+
+	// The config itself is not modified
+	if cfg.CustomHeaders["Authorization"] != "Bearer custom-secret-1" || cfg.APIKey != "key-current" {
+		t.Error("Redact() modified the config")
+	}
+}
+
+func TestIsSensitiveHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Authorization", true},
+		{"proxy-authorization", true},
+		{"Cookie", true},
+		{"X-API-Key", true},
+		{"X-Auth-Token", true},
+		{"X-Client-Secret", true},
+		{"X-Session-ID", true},
+		{"X-Tenant-ID", false},
+		{"Accept", false},
+		{"User-Agent", false},
+	}
+
+	for _, tt := range tests {
+		if got := isSensitiveHeader(tt.name); got != tt.want {
+			t.Errorf("isSensitiveHeader(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package executor
+
+import (
+	"os/exec"
+)
+
+// Executor runs external commands
+// Packages that shell out use this interface so tests can substitute fixture output
+type Executor interface {
+	// Output runs the command and returns its standard output
+	Output(name string, args ...string) ([]byte, error)
+	// Run runs the command and returns an error if it fails or exits non-zero
+	Run(name string, args ...string) error
+}
+
+// System runs commands on the host using os/exec
+type System struct{}
+
+// Output runs the command and returns its standard output
+func (System) Output(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// Run runs the command and waits for it to complete
+func (System) Run(name string, args ...string) error {
+	return exec.Command(name, args...).Run()
+}
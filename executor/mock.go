@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// MockResult is the canned outcome of a mocked command
+type MockResult struct {
+	Output []byte
+	Err    error
+}
+
+// Mock returns canned results for commands, keyed by the full command line
+// (e.g. "ss -tulpn"). Commands without a result fail like a missing binary,
+// so errors.Is(err, exec.ErrNotFound) holds for them
+type Mock struct {
+	Results map[string]MockResult
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// NewMock creates a Mock with the given results
+func NewMock(results map[string]MockResult) *Mock {
+	return &Mock{Results: results}
+}
+
+// Output returns the canned output of the command
+func (m *Mock) Output(name string, args ...string) ([]byte, error) {
+	commandLine := strings.Join(append([]string{name}, args...), " ")
+
+	m.mu.Lock()
+	m.calls = append(m.calls, commandLine)
+	m.mu.Unlock()
+
+	result, ok := m.Results[commandLine]
+	if !ok {
+		return nil, &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	return result.Output, result.Err
+}
+
+// Run returns the canned error of the command
+func (m *Mock) Run(name string, args ...string) error {
+	_, err := m.Output(name, args...)
+	return err
+}
+
+// Calls returns the command lines run so far, in order
+func (m *Mock) Calls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.calls...)
+}
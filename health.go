@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/transport"
+)
+
+const (
+	// backendStatusMaxAge is how long /healthz reuses a backend ping result
+	backendStatusMaxAge = 30 * time.Second
+
+	// startupPingAttempts is how often the backend is pinged at startup before giving up
+	startupPingAttempts = 3
+	startupPingDelay    = 2 * time.Second
+)
+
+// healthResponse is the body served on /healthz
+type healthResponse struct {
+	State         AgentState     `json:"state"`
+	Version       string         `json:"version"`
+	UptimeSeconds int64          `json:"uptime_seconds"`
+	Backend       *backendStatus `json:"backend,omitempty"`
+}
+
+// backendStatus is the result of the latest backend ping
+type backendStatus struct {
+	Reachable bool      `json:"reachable"`
+	LatencyMs int       `json:"latency_ms,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// lastBackendStatus caches the latest ping so health checks don't flood the backend
+var lastBackendStatus struct {
+	sync.Mutex
+	status *backendStatus
+}
+
+// pingBackend pings the backend and caches the result
+func pingBackend(client *transport.Client) backendStatus {
+	latencyMs, _, err := client.Ping()
+	status := backendStatus{Reachable: err == nil, CheckedAt: time.Now().UTC()}
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.LatencyMs = latencyMs
+	}
+
+	lastBackendStatus.Lock()
+	lastBackendStatus.status = &status
+	lastBackendStatus.Unlock()
+	return status
+}
+
+// backendConnectivity returns the cached ping result, pinging again once it is stale
+func backendConnectivity(client *transport.Client) backendStatus {
+	lastBackendStatus.Lock()
+	cached := lastBackendStatus.status
+	lastBackendStatus.Unlock()
+
+	if cached != nil && time.Since(cached.CheckedAt) < backendStatusMaxAge {
+		return *cached
+	}
+	return pingBackend(client)
+}
+
+// checkBackendAtStartup pings the backend a few times and logs the outcome
+// An unreachable backend is not fatal, sends are retried every cycle
+func checkBackendAtStartup(client *transport.Client) {
+	var status backendStatus
+	for attempt := 1; attempt <= startupPingAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(startupPingDelay)
+		}
+		status = pingBackend(client)
+		if status.Reachable {
+			logging.Infof("Backend reachable (latency %dms)", status.LatencyMs)
+			return
+		}
+	}
+	logging.Warnf("Backend unreachable after %d attempts: %s", startupPingAttempts, status.Error)
+}
+
+// parseAllowedIPs converts IPs and CIDRs into networks (exact IPs become /32 or /128)
+func parseAllowedIPs(entries []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP or CIDR %q", entry)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks, nil
+}
+
+// isRemoteAllowed checks the request's remote IP against the allowed networks
+// An empty allowlist allows everyone
+func isRemoteAllowed(remoteAddr string, allowed []*net.IPNet) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// startHealthServer serves the agent state on /healthz in the background
+// Only clients in allowedIPs may query it (all clients if the list is empty)
+func startHealthServer(port int, allowedIPs []string, client *transport.Client) (*http.Server, error) {
+	allowed, err := parseAllowedIPs(allowedIPs)
+	if err != nil {
+		return nil, fmt.Errorf("health_allowed_ips: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !isRemoteAllowed(r.RemoteAddr, allowed) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		handleHealthz(w, r, client)
+	})
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logging.Warnf("Health endpoint stopped: %v", err)
+		}
+	}()
+
+	logging.Infof("Health endpoint listening on :%d/healthz", port)
+	return server, nil
+}
+
+// handleHealthz reports the agent state and backend connectivity
+// Returns 503 while shutting down so load balancers and supervisors stop routing to the agent
+// An unreachable backend doesn't change the status code, the agent itself is still healthy
+func handleHealthz(w http.ResponseWriter, r *http.Request, client *transport.Client) {
+	state := currentState()
+	status := http.StatusOK
+	if state == StateShuttingDown {
+		status = http.StatusServiceUnavailable
+	}
+
+	backend := backendConnectivity(client)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(healthResponse{
+		State:         state,
+		Version:       Version,
+		UptimeSeconds: int64(time.Since(startTime).Seconds()),
+		Backend:       &backend,
+	})
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the severity of a log message
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// current is the minimum level that gets logged (default: info)
+var current atomic.Int32
+
+func init() {
+	current.Store(int32(LevelInfo))
+}
+
+// String returns the level name
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+}
+
+// ParseLevel converts a level name ("debug", "info", "warn" or "error") to a Level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level: %s", name)
+	}
+}
+
+// SetLevel changes the minimum level that gets logged; safe to call at any time
+func SetLevel(level Level) {
+	current.Store(int32(level))
+}
+
+// GetLevel returns the minimum level that gets logged
+func GetLevel() Level {
+	return Level(current.Load())
+}
+
+// Enabled reports whether messages at level are currently logged
+// Use it to skip expensive work that only feeds debug output
+func Enabled(level Level) bool {
+	return level >= GetLevel()
+}
+
+// Debugf logs a message at debug level
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, "Debug: ", format, args...)
+}
+
+// Infof logs a message at info level
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, "", format, args...)
+}
+
+// Warnf logs a message at warn level
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, "Warning: ", format, args...)
+}
+
+// Errorf logs a message at error level
+func Errorf(format string, args ...interface{}) {
+	logf(LevelError, "Error: ", format, args...)
+}
+
+// logf writes the message through the standard logger if level is enabled
+func logf(level Level, prefix, format string, args ...interface{}) {
+	if !Enabled(level) {
+		return
+	}
+	log.Output(3, prefix+fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+// captureOutput redirects the standard logger and restores the level afterwards
+func captureOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, flags, previousLevel := log.Writer(), log.Flags(), GetLevel()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(writer)
+		log.SetFlags(flags)
+		SetLevel(previousLevel)
+	})
+	return &buf
+}
+
+func TestLevelFiltering(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  []string
+	}{
+		{LevelDebug, []string{"Debug: d", "i", "Warning: w", "Error: e"}},
+		{LevelInfo, []string{"i", "Warning: w", "Error: e"}},
+		{LevelWarn, []string{"Warning: w", "Error: e"}},
+		{LevelError, []string{"Error: e"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			buf := captureOutput(t)
+			SetLevel(tt.level)
+
+			Debugf("d")
+			Infof("i")
+			Warnf("w")
+			Errorf("e")
+
+			got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("logged %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{" INFO ", LevelInfo, false},
+		{"warning", LevelWarn, false},
+		{"error", LevelError, false},
+		{"verbose", LevelInfo, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v (error: %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
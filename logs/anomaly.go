@@ -0,0 +1,103 @@
+package logs
+
+import (
+	"sync"
+
+	"vpsentinel-agent/models"
+)
+
+const (
+	// defaultAnomalyWindow is the number of past cycles used for the baseline
+	defaultAnomalyWindow = 10
+	// defaultAnomalyMultiplier is how far above the baseline a count must be to be anomalous
+	defaultAnomalyMultiplier = 3.0
+	// minAnomalySamples avoids flagging anomalies before a baseline exists
+	minAnomalySamples = 3
+)
+
+// AnomalyDetector tracks per-file error counts across collection cycles
+// and flags sudden spikes relative to a rolling baseline
+type AnomalyDetector struct {
+	mu         sync.Mutex
+	window     int
+	multiplier float64
+	history    map[string][]int
+}
+
+// NewAnomalyDetector creates a detector with a 10-cycle window and a 3x threshold
+func NewAnomalyDetector() *AnomalyDetector {
+	return &AnomalyDetector{
+		window:     defaultAnomalyWindow,
+		multiplier: defaultAnomalyMultiplier,
+		history:    make(map[string][]int),
+	}
+}
+
+// Check records the error count for a file and reports whether it's a spike
+// The baseline is the average of the previous cycles (the current one is excluded)
+// A baseline below one error is treated as one so quiet logs don't alert on a single error
+func (d *AnomalyDetector) Check(path string, errorCount int) (anomaly bool, baseline float64, currentCount int) {
+	return d.check(path, errorCount, true)
+}
+
+// Compare reports whether an error count is a spike like Check, without recording it
+// Used for on-demand collections so they don't skew the per-cycle baseline
+func (d *AnomalyDetector) Compare(path string, errorCount int) (anomaly bool, baseline float64, currentCount int) {
+	return d.check(path, errorCount, false)
+}
+
+func (d *AnomalyDetector) check(path string, errorCount int, record bool) (anomaly bool, baseline float64, currentCount int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	past := d.history[path]
+	if len(past) > 0 {
+		total := 0
+		for _, count := range past {
+			total += count
+		}
+		baseline = float64(total) / float64(len(past))
+	}
+
+	threshold := baseline
+	if threshold < 1 {
+		threshold = 1
+	}
+	anomaly = len(past) >= minAnomalySamples && float64(errorCount) > d.multiplier*threshold
+
+	if record {
+		// Append and keep only the last window entries
+		past = append(past, errorCount)
+		if len(past) > d.window {
+			past = past[len(past)-d.window:]
+		}
+		d.history[path] = past
+	}
+
+	return anomaly, baseline, errorCount
+}
+
+// CheckEntries runs Check for every log entry and returns the detected anomalies
+func (d *AnomalyDetector) CheckEntries(entries []models.LogEntry) []models.LogAnomaly {
+	return d.checkEntries(entries, d.Check)
+}
+
+// CompareEntries runs Compare for every log entry and returns the detected anomalies
+func (d *AnomalyDetector) CompareEntries(entries []models.LogEntry) []models.LogAnomaly {
+	return d.checkEntries(entries, d.Compare)
+}
+
+func (d *AnomalyDetector) checkEntries(entries []models.LogEntry, check func(path string, errorCount int) (bool, float64, int)) []models.LogAnomaly {
+	var anomalies []models.LogAnomaly
+	for _, entry := range entries {
+		anomaly, baseline, current := check(entry.Path, entry.ErrorCount)
+		if anomaly {
+			anomalies = append(anomalies, models.LogAnomaly{
+				Path:         entry.Path,
+				Baseline:     baseline,
+				CurrentCount: current,
+			})
+		}
+	}
+	return anomalies
+}
@@ -0,0 +1,60 @@
+package logs
+
+import (
+	"testing"
+
+	"vpsentinel-agent/models"
+)
+
+func TestAnomalyDetectorCheck(t *testing.T) {
+	d := NewAnomalyDetector()
+
+	// No alert until a baseline of minAnomalySamples cycles exists
+	for i := 0; i < minAnomalySamples; i++ {
+		if anomaly, _, _ := d.Check("/var/log/app.log", 2); anomaly {
+			t.Fatalf("cycle %d flagged as anomaly before a baseline existed", i)
+		}
+	}
+
+	anomaly, baseline, current := d.Check("/var/log/app.log", 7)
+	if !anomaly || baseline != 2 || current != 7 {
+		t.Errorf("Check() = %v, %v, %d, want true, 2, 7", anomaly, baseline, current)
+	}
+
+	// A quiet log doesn't alert on a couple of errors (baseline floor of one)
+	for i := 0; i < minAnomalySamples; i++ {
+		d.Check("/var/log/quiet.log", 0)
+	}
+	if anomaly, _, _ := d.Check("/var/log/quiet.log", 3); anomaly {
+		t.Error("3 errors on a quiet log flagged as anomaly, want the 1-error floor applied")
+	}
+}
+
+func TestAnomalyDetectorCompareDoesNotRecord(t *testing.T) {
+	d := NewAnomalyDetector()
+	entries := []models.LogEntry{{Path: "/var/log/app.log", ErrorCount: 2}}
+	for i := 0; i < minAnomalySamples; i++ {
+		d.CheckEntries(entries)
+	}
+
+	// On-demand collections see the spike...
+	spike := []models.LogEntry{{Path: "/var/log/app.log", ErrorCount: 50}}
+	for i := 0; i < 5; i++ {
+		anomalies := d.CompareEntries(spike)
+		if len(anomalies) != 1 || anomalies[0].Baseline != 2 || anomalies[0].CurrentCount != 50 {
+			t.Fatalf("CompareEntries() = %+v, want one anomaly against baseline 2", anomalies)
+		}
+	}
+
+	// ...but don't raise the baseline of the scheduled cycle
+	anomalies := d.CheckEntries(spike)
+	if len(anomalies) != 1 || anomalies[0].Baseline != 2 {
+		t.Errorf("CheckEntries() = %+v, want baseline 2 unaffected by CompareEntries", anomalies)
+	}
+
+	// Without any scheduled history, Compare never alerts nor creates one
+	d.CompareEntries([]models.LogEntry{{Path: "/var/log/new.log", ErrorCount: 100}})
+	if _, ok := d.history["/var/log/new.log"]; ok {
+		t.Error("CompareEntries() recorded history for a new file")
+	}
+}
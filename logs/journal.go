@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"vpsentinel-agent/models"
+)
+
+// journalPrefix marks log paths that are read from the systemd journal
+const journalPrefix = "journald://"
+
+// ReadFromJournal reads the last lines logged by a systemd unit
+func ReadFromJournal(unit string, lines int) (*models.LogEntry, error) {
+	return readFromJournal(context.Background(), unit, lines)
+}
+
+// readFromJournal runs journalctl for a unit and sanitizes the output
+func readFromJournal(ctx context.Context, unit string, lines int) (*models.LogEntry, error) {
+	// Don't let a unit name be parsed as a journalctl option
+	if unit == "" || strings.HasPrefix(unit, "-") {
+		return nil, fmt.Errorf("invalid journald unit: %q", unit)
+	}
+	if lines <= 0 {
+		lines = 100
+	}
+
+	output, err := exec.CommandContext(ctx, "journalctl", "-u", unit, "-n", strconv.Itoa(lines), "--no-pager", "--output=short-iso").Output()
+	if err != nil {
+		return nil, fmt.Errorf("journalctl failed for %s: %w", unit, err)
+	}
+
+	var logLines []string
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		// Skip journalctl markers such as "-- No entries --" and "-- Boot ... --"
+		if line == "" || strings.HasPrefix(line, "-- ") {
+			continue
+		}
+		logLines = append(logLines, line)
+	}
+
+	if len(logLines) == 0 {
+		return nil, nil // No entries for the unit
+	}
+
+	content := strings.Join(logLines, "\n")
+	return &models.LogEntry{
+		Path:       journalPrefix + unit,
+		Message:    sanitize(content),
+		Lines:      len(logLines),
+		Level:      detectLogLevel(content),
+		ErrorCount: countErrorLines(logLines),
+	}, nil
+}
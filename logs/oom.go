@@ -0,0 +1,133 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+const (
+	// DefaultKernLogPath is the kernel log scanned when none is configured
+	DefaultKernLogPath = "/var/log/kern.log"
+
+	// oomScanBytes limits how much of the end of the kernel log is scanned
+	oomScanBytes = 1024 * 1024
+	// maxOOMEvents caps the number of events reported per cycle
+	maxOOMEvents = 50
+)
+
+var (
+	// Old format: "Out of memory: Kill process 1234 (mysqld) score 512 or sacrifice child"
+	// New format: "Out of memory: Killed process 1234 (mysqld) total-vm:...kB, ... oom_score_adj:0"
+	// Cgroup limit: "Memory cgroup out of memory: Killed process 1234 (java) total-vm:...kB, ..."
+	oomPattern      = regexp.MustCompile(`(?i)(?:memory cgroup )?out of memory: kill(?:ed)? process (\d+) \(([^)]+)\)`)
+	oomScorePattern = regexp.MustCompile(`\bscore (-?\d+)|oom_score_adj:(-?\d+)`)
+
+	// ISO timestamps written by rsyslog's high-precision template
+	isoTimestampPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2}))`)
+	// Traditional syslog timestamps without a year, e.g. "Jan  2 15:04:05"
+	syslogTimestampPattern = regexp.MustCompile(`^([A-Z][a-z]{2}\s+\d{1,2} \d{2}:\d{2}:\d{2})`)
+)
+
+// DetectOOMEvents scans the end of the kernel log for OOM killer events
+// Both the old "Kill process ... score N" and the newer "Killed process ...
+// oom_score_adj:N" formats are recognised, for system-wide and cgroup
+// (container or systemd unit memory limit) OOM kills
+func DetectOOMEvents(kernLogPath string) ([]models.OOMEvent, error) {
+	if kernLogPath == "" {
+		kernLogPath = DefaultKernLogPath
+	}
+
+	f, err := os.Open(kernLogPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open kernel log: %w", err)
+	}
+	defer f.Close()
+
+	// Only scan the tail, kernel logs can be large
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat kernel log: %w", err)
+	}
+	offset := info.Size() - oomScanBytes
+	if offset < 0 {
+		offset = 0
+	}
+	data := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read kernel log: %w", err)
+	}
+	if offset > 0 {
+		// Drop the partial first line
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+
+	events := []models.OOMEvent{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		matches := oomPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		pid, _ := strconv.Atoi(matches[1])
+		event := models.OOMEvent{
+			PID:         pid,
+			ProcessName: matches[2],
+			Timestamp:   parseSyslogTimestamp(line),
+		}
+		if scoreMatches := oomScorePattern.FindStringSubmatch(line); scoreMatches != nil {
+			score := scoreMatches[1]
+			if score == "" {
+				score = scoreMatches[2]
+			}
+			event.Score, _ = strconv.Atoi(score)
+		}
+
+		events = append(events, event)
+	}
+
+	// Keep the most recent events
+	if len(events) > maxOOMEvents {
+		events = events[len(events)-maxOOMEvents:]
+	}
+
+	return events, nil
+}
+
+// parseSyslogTimestamp extracts the timestamp at the start of a syslog line
+// Returns the zero time if the line has no recognisable timestamp
+func parseSyslogTimestamp(line string) time.Time {
+	if matches := isoTimestampPattern.FindStringSubmatch(line); matches != nil {
+		if t, err := time.Parse(time.RFC3339Nano, matches[1]); err == nil {
+			return t.UTC()
+		}
+	}
+
+	if matches := syslogTimestampPattern.FindStringSubmatch(line); matches != nil {
+		// Syslog omits the year; assume the current one unless that's in the future
+		now := time.Now()
+		stamp := strings.Join(strings.Fields(matches[1]), " ")
+		t, err := time.ParseInLocation("Jan 2 15:04:05 2006", stamp+" "+strconv.Itoa(now.Year()), time.Local)
+		if err != nil {
+			return time.Time{}
+		}
+		if t.After(now.Add(24 * time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t.UTC()
+	}
+
+	return time.Time{}
+}
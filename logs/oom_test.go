@@ -0,0 +1,43 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+func TestDetectOOMEvents(t *testing.T) {
+	log := `2026-03-01T10:00:00+00:00 web kernel: [100.1] Out of memory: Kill process 1234 (mysqld) score 512 or sacrifice child
+2026-03-01T10:05:00+00:00 web kernel: [400.2] Out of memory: Killed process 2345 (php-fpm) total-vm:812344kB, anon-rss:402112kB, file-rss:0kB, shmem-rss:0kB, UID:33 pgtables:1024kB oom_score_adj:0
+2026-03-01T10:10:00+00:00 web kernel: [700.3] Memory cgroup out of memory: Killed process 3456 (java) total-vm:4194304kB, anon-rss:2097152kB, file-rss:0kB, shmem-rss:0kB, UID:1000 pgtables:8192kB oom_score_adj:-500
+2026-03-01T10:11:00+00:00 web kernel: [760.4] oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=/,mems_allowed=0,task=java,pid=3456,uid=1000
+2026-03-01T10:12:00+00:00 web kernel: [800.5] eth0: link up
+`
+	path := filepath.Join(t.TempDir(), "kern.log")
+	if err := os.WriteFile(path, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := DetectOOMEvents(path)
+	if err != nil {
+		t.Fatalf("DetectOOMEvents() error = %v", err)
+	}
+	want := []models.OOMEvent{
+		{PID: 1234, ProcessName: "mysqld", Score: 512, Timestamp: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{PID: 2345, ProcessName: "php-fpm", Score: 0, Timestamp: time.Date(2026, 3, 1, 10, 5, 0, 0, time.UTC)},
+		{PID: 3456, ProcessName: "java", Score: -500, Timestamp: time.Date(2026, 3, 1, 10, 10, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("DetectOOMEvents() = %+v, want %+v", events, want)
+	}
+}
+
+func TestDetectOOMEventsMissingLog(t *testing.T) {
+	if _, err := DetectOOMEvents(filepath.Join(t.TempDir(), "missing.log")); err == nil {
+		t.Error("DetectOOMEvents() on a missing file succeeded")
+	}
+}
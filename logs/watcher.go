@@ -2,16 +2,28 @@ package logs
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 
+	"vpsentinel-agent/logging"
 	"vpsentinel-agent/models"
 )
 
 // ReadAndSanitize reads log files and sanitizes their content
 // Only reads the last maxLines from each file to avoid huge payloads
-func ReadAndSanitize(paths []string, maxLines int) ([]models.LogEntry, error) {
+// If maxBytes > 0, files are read in order until their combined message size
+// reaches maxBytes; the entry crossing the limit is truncated and later files are skipped
+// Paths of the form "journald://<unit>" are read from the systemd journal
+// If ctx is cancelled, the entries read so far are returned with ctx's error
+func ReadAndSanitize(ctx context.Context, paths []string, maxLines int, maxBytes int) ([]models.LogEntry, error) {
 	if len(paths) == 0 {
 		return []models.LogEntry{}, nil
 	}
@@ -21,19 +33,43 @@ func ReadAndSanitize(paths []string, maxLines int) ([]models.LogEntry, error) {
 	}
 
 	var entries []models.LogEntry
+	totalBytes := 0
 
-	for _, path := range paths {
+	for i, path := range paths {
 		if path == "" {
 			continue
 		}
 
-		logEntry, err := readLogFile(path, maxLines)
+		// Stop once the byte budget is used up (earlier paths have priority)
+		if maxBytes > 0 && totalBytes >= maxBytes {
+			logging.Warnf("Log rate limit of %d bytes reached, skipped: %s", maxBytes, strings.Join(paths[i:], ", "))
+			break
+		}
+
+		if err := ctx.Err(); err != nil {
+			return entries, err
+		}
+
+		var logEntry *models.LogEntry
+		var err error
+		if unit, ok := strings.CutPrefix(path, journalPrefix); ok {
+			logEntry, err = readFromJournal(ctx, unit, maxLines)
+		} else {
+			logEntry, err = readLogFile(ctx, path, maxLines)
+		}
 		if err != nil {
+			if ctx.Err() != nil {
+				return entries, ctx.Err()
+			}
 			// Log error but continue with other files
 			continue
 		}
 
 		if logEntry != nil {
+			if maxBytes > 0 && totalBytes+len(logEntry.Message) > maxBytes {
+				logEntry.Message = truncateUTF8(logEntry.Message, maxBytes-totalBytes)
+			}
+			totalBytes += len(logEntry.Message)
 			entries = append(entries, *logEntry)
 		}
 	}
@@ -41,8 +77,19 @@ func ReadAndSanitize(paths []string, maxLines int) ([]models.LogEntry, error) {
 	return entries, nil
 }
 
+// truncateUTF8 shortens s to at most n bytes without splitting a multi-byte character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
 // readLogFile reads the last N lines from a log file and sanitizes the content
-func readLogFile(path string, maxLines int) (*models.LogEntry, error) {
+func readLogFile(ctx context.Context, path string, maxLines int) (*models.LogEntry, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -57,24 +104,32 @@ func readLogFile(path string, maxLines int) (*models.LogEntry, error) {
 
 	// For small files, read all lines
 	// For large files, we'll read backwards from the end
+	// Compressed (rotated) logs are always streamed through gzip
 	var lines []string
-	if stat.Size() < 1024*1024 { // If less than 1MB, read all
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip file %s: %w", path, err)
 		}
-		if err := scanner.Err(); err != nil {
-			return nil, err
+		defer gz.Close()
+
+		lines, err = readLastLinesSimple(ctx, gz, maxLines)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
 		}
-		// Keep only last maxLines
-		if len(lines) > maxLines {
-			lines = lines[len(lines)-maxLines:]
+	} else if stat.Size() < 1024*1024 { // If less than 1MB, read all
+		lines, err = readLastLinesSimple(ctx, file, maxLines)
+		if err != nil {
+			return nil, err
 		}
 	} else {
 		// For large files, use a simple approach: read last chunk
 		// This is a simplified version; for production, consider using a library
 		// that can efficiently tail files
-		lines = readLastLinesSimple(file, maxLines)
+		lines, err = readLastLinesSimple(ctx, file, maxLines)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if len(lines) == 0 {
@@ -89,79 +144,156 @@ func readLogFile(path string, maxLines int) (*models.LogEntry, error) {
 	level := detectLogLevel(content)
 
 	return &models.LogEntry{
-		Path:    path,
-		Message: sanitized,
-		Lines:   len(lines),
-		Level:   level,
+		Path:       path,
+		Message:    sanitized,
+		Lines:      len(lines),
+		Level:      level,
+		ErrorCount: countErrorLines(lines),
 	}, nil
 }
 
+// maxLogLineBytes is the longest line read from a log file (e.g. a minified JSON log record)
+const maxLogLineBytes = 1024 * 1024
+
 // readLastLinesSimple reads the last N lines from a file (simple implementation)
 // For very large files, this could be optimized further
-func readLastLinesSimple(file *os.File, maxLines int) []string {
-	scanner := bufio.NewScanner(file)
+// Reading stops at a line longer than maxLogLineBytes; the lines before it are returned
+func readLastLinesSimple(ctx context.Context, r io.Reader, maxLines int) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineBytes)
 	var allLines []string
 	for scanner.Scan() {
 		allLines = append(allLines, scanner.Text())
+		if err := checkCancelled(ctx, len(allLines)); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return nil, err // e.g. corrupt gzip data
 	}
 
 	// Return last maxLines
 	if len(allLines) <= maxLines {
-		return allLines
+		return allLines, nil
 	}
-	return allLines[len(allLines)-maxLines:]
+	return allLines[len(allLines)-maxLines:], nil
 }
 
-// sanitize removes or masks sensitive information from log content
-func sanitize(content string) string {
-	s := content
+// checkCancelled returns ctx's error every 1000 lines once ctx is cancelled
+func checkCancelled(ctx context.Context, lineCount int) error {
+	if lineCount%1000 != 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// sanitizeRule is a named pattern masked by sanitize
+type sanitizeRule struct {
+	name    string
+	pattern *regexp.Regexp
+	replace string
+}
+
+// sanitizeRules are the built-in masking rules, applied in order
+var sanitizeRules = []sanitizeRule{
+	// Passwords (password=value or "password": "value")
+	{"password_assignment", regexp.MustCompile(`(?i)(password\s*[=:]\s*)([^\s"']+)`), `${1}***REDACTED***`},
+	{"password_json", regexp.MustCompile(`(?i)("password"\s*:\s*")[^"]+`), `${1}***REDACTED***`},
 
-	// Patterns to mask (case-insensitive)
-	patterns := []struct {
-		pattern *regexp.Regexp
-		replace string
-	}{
-		// Passwords (password=value or "password": "value")
-		{regexp.MustCompile(`(?i)(password\s*[=:]\s*)([^\s"']+)`), `${1}***REDACTED***`},
-		{regexp.MustCompile(`(?i)("password"\s*:\s*")[^"]+`), `${1}***REDACTED***`},
+	// API keys (api[_-]?key, apikey)
+	{"api_key_assignment", regexp.MustCompile(`(?i)(api[_-]?key\s*[=:]\s*)([^\s"']+)`), `${1}***REDACTED***`},
+	{"api_key_json", regexp.MustCompile(`(?i)("api[_-]?key"\s*:\s*")[^"]+`), `${1}***REDACTED***`},
 
-		// API keys (api[_-]?key, apikey)
-		{regexp.MustCompile(`(?i)(api[_-]?key\s*[=:]\s*)([^\s"']+)`), `${1}***REDACTED***`},
-		{regexp.MustCompile(`(?i)("api[_-]?key"\s*:\s*")[^"]+`), `${1}***REDACTED***`},
+	// Secrets (secret=value)
+	{"secret_assignment", regexp.MustCompile(`(?i)(secret\s*[=:]\s*)([^\s"']+)`), `${1}***REDACTED***`},
+	{"secret_json", regexp.MustCompile(`(?i)("secret"\s*:\s*")[^"]+`), `${1}***REDACTED***`},
 
-		// Secrets (secret=value)
-		{regexp.MustCompile(`(?i)(secret\s*[=:]\s*)([^\s"']+)`), `${1}***REDACTED***`},
-		{regexp.MustCompile(`(?i)("secret"\s*:\s*")[^"]+`), `${1}***REDACTED***`},
+	// Tokens (token=value, bearer token)
+	{"token_assignment", regexp.MustCompile(`(?i)(token\s*[=:]\s*)([^\s"']+)`), `${1}***REDACTED***`},
+	{"bearer_token", regexp.MustCompile(`(?i)(bearer\s+)([A-Za-z0-9\-._~+/]+)`), `${1}***REDACTED***`},
 
-		// Tokens (token=value, bearer token)
-		{regexp.MustCompile(`(?i)(token\s*[=:]\s*)([^\s"']+)`), `${1}***REDACTED***`},
-		{regexp.MustCompile(`(?i)(bearer\s+)([A-Za-z0-9\-._~+/]+)`), `${1}***REDACTED***`},
+	// JWT tokens (eyJ... pattern)
+	{"jwt_token", regexp.MustCompile(`(eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+)`), `***JWT_TOKEN_REDACTED***`},
 
-		// JWT tokens (eyJ... pattern)
-		{regexp.MustCompile(`(eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+)`), `***JWT_TOKEN_REDACTED***`},
+	// Private keys (BEGIN PRIVATE KEY blocks)
+	{"private_key", regexp.MustCompile(`(?s)-----BEGIN[^\n]+\n[^-]+\n-----END[^\n]+-----`), `***PRIVATE_KEY_REDACTED***`},
 
-		// Private keys (BEGIN PRIVATE KEY blocks)
-		{regexp.MustCompile(`(?s)-----BEGIN[^\n]+\n[^-]+\n-----END[^\n]+-----`), `***PRIVATE_KEY_REDACTED***`},
+	// AWS keys (AKIA... pattern)
+	{"aws_key", regexp.MustCompile(`AKIA[0-9A-Z]{16}`), `***AWS_KEY_REDACTED***`},
 
-		// AWS keys (AKIA... pattern)
-		{regexp.MustCompile(`AKIA[0-9A-Z]{16}`), `***AWS_KEY_REDACTED***`},
+	// Email addresses (basic pattern, be careful not to over-sanitize)
+	// Only sanitize if they look like sensitive data
+	// {"email", regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`), `***EMAIL_REDACTED***`},
+
+	// Additional simple replacements for common terms
+	{"password_keyword", regexp.MustCompile(`password`), `***`},
+	{"secret_keyword", regexp.MustCompile(`secret`), `***`},
+}
 
-		// Email addresses (basic pattern, be careful not to over-sanitize)
-		// Only sanitize if they look like sensitive data
-		// {regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`), `***EMAIL_REDACTED***`},
+// disabledSanitizeRules holds the names of rules sanitize skips (map[string]bool)
+var disabledSanitizeRules atomic.Value
+
+// SetDisabledSanitizeRules disables built-in sanitization rules by name
+func SetDisabledSanitizeRules(names []string) error {
+	disabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !isSanitizeRule(name) {
+			return fmt.Errorf("unknown sanitize rule: %s", name)
+		}
+		disabled[name] = true
 	}
+	disabledSanitizeRules.Store(disabled)
+	return nil
+}
 
-	for _, p := range patterns {
-		s = p.pattern.ReplaceAllString(s, p.replace)
+// isSanitizeRule checks whether name is a built-in rule
+func isSanitizeRule(name string) bool {
+	for _, rule := range sanitizeRules {
+		if rule.name == name {
+			return true
+		}
 	}
+	return false
+}
 
-	// Additional simple replacements for common terms
-	s = strings.ReplaceAll(s, "password", "***")
-	s = strings.ReplaceAll(s, "secret", "***")
+// Sanitize masks sensitive information in text read from other sources (e.g. dmesg)
+// using the same rules as log files
+func Sanitize(content string) string {
+	return sanitize(content)
+}
+
+// sanitize removes or masks sensitive information from log content
+func sanitize(content string) string {
+	s := content
+	disabled, _ := disabledSanitizeRules.Load().(map[string]bool)
+
+	for _, rule := range sanitizeRules {
+		if disabled[rule.name] {
+			continue
+		}
+		s = rule.pattern.ReplaceAllString(s, rule.replace)
+	}
 
 	return s
 }
 
+// countErrorLines counts lines classified as error or critical
+func countErrorLines(lines []string) int {
+	count := 0
+	for _, line := range lines {
+		level := detectLogLevel(line)
+		if level == "error" || level == "critical" {
+			count++
+		}
+	}
+	return count
+}
+
 // detectLogLevel attempts to detect the log level from the content
 func detectLogLevel(content string) string {
 	contentLower := strings.ToLower(content)
@@ -0,0 +1,183 @@
+package logs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// writeLog writes content to a file in a temporary directory and returns its path
+func writeLog(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"shorter than limit", "hello", 10, "hello"},
+		{"exact length", "hello", 5, "hello"},
+		{"ascii cut", "hello world", 5, "hello"},
+		{"zero", "hello", 0, ""},
+		{"cut inside 2-byte rune", "café latte", 4, "caf"}, // é is 2 bytes at offset 3
+		{"cut after 2-byte rune", "café latte", 5, "café"},
+		{"cut inside 3-byte rune", "日本語", 4, "日"},    // 3 bytes per rune
+		{"cut inside 4-byte rune", "ok🚀go", 4, "ok"}, // 🚀 is 4 bytes at offset 2
+		{"cut after 4-byte rune", "ok🚀go", 6, "ok🚀"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateUTF8(tt.s, tt.n)
+			if got != tt.want {
+				t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateUTF8(%q, %d) = %q is not valid UTF-8", tt.s, tt.n, got)
+			}
+		})
+	}
+}
+
+func TestReadAndSanitizeByteLimit(t *testing.T) {
+	first := writeLog(t, "first.log", "alpha line one\nalpha line two\n") // Message is 29 bytes
+	second := writeLog(t, "second.log", "beta line one\nbeta line two\n") // Message is 27 bytes
+	third := writeLog(t, "third.log", "gamma line one\n")
+	paths := []string{first, second, third}
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		want     []string // Expected messages, in order
+	}{
+		{"unlimited", 0, []string{"alpha line one\nalpha line two", "beta line one\nbeta line two", "gamma line one"}},
+		{"limit inside first file", 10, []string{"alpha line"}},
+		{"limit at end of first file", 29, []string{"alpha line one\nalpha line two"}},
+		{"limit one byte into second file", 30, []string{"alpha line one\nalpha line two", "b"}},
+		{"limit on a line break of second file", 43, []string{"alpha line one\nalpha line two", "beta line one\n"}},
+		{"limit at end of second file", 56, []string{"alpha line one\nalpha line two", "beta line one\nbeta line two"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ReadAndSanitize(context.Background(), paths, 100, tt.maxBytes)
+			if err != nil {
+				t.Fatalf("ReadAndSanitize() error = %v", err)
+			}
+			if len(entries) != len(tt.want) {
+				t.Fatalf("ReadAndSanitize() returned %d entries, want %d: %+v", len(entries), len(tt.want), entries)
+			}
+
+			total := 0
+			for i, entry := range entries {
+				if entry.Path != paths[i] {
+					t.Errorf("entry %d path = %s, want %s (files must keep their priority order)", i, entry.Path, paths[i])
+				}
+				if entry.Message != tt.want[i] {
+					t.Errorf("entry %d message = %q, want %q", i, entry.Message, tt.want[i])
+				}
+				total += len(entry.Message)
+			}
+			if tt.maxBytes > 0 && total > tt.maxBytes {
+				t.Errorf("total message size = %d bytes, over the %d byte limit", total, tt.maxBytes)
+			}
+		})
+	}
+}
+
+func TestReadAndSanitizeByteLimitMultiByte(t *testing.T) {
+	// "état: ok" starts with a 2-byte rune; the budget left for the second
+	// file ends in the middle of it
+	first := writeLog(t, "first.log", "0123456789\n")
+	second := writeLog(t, "second.log", "état: ok\n")
+
+	entries, err := ReadAndSanitize(context.Background(), []string{first, second}, 100, 11)
+	if err != nil {
+		t.Fatalf("ReadAndSanitize() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ReadAndSanitize() returned %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[1].Message != "" {
+		t.Errorf("second message = %q, want %q (the split rune must be dropped)", entries[1].Message, "")
+	}
+
+	entries, err = ReadAndSanitize(context.Background(), []string{first, second}, 100, 12)
+	if err != nil {
+		t.Fatalf("ReadAndSanitize() error = %v", err)
+	}
+	if got := entries[1].Message; got != "é" || !utf8.ValidString(got) {
+		t.Errorf("second message = %q, want %q", got, "é")
+	}
+}
+
+func TestReadLogFileLineAcrossReadChunks(t *testing.T) {
+	// Lines longer than bufio's initial 4KB buffer span several reads
+	long := strings.Repeat("x", 10000) + "é"
+
+	tests := []struct {
+		name   string
+		prefix string
+	}{
+		{"small file", ""},
+		// Files over 1MB take the streaming path
+		{"large file", strings.Repeat(strings.Repeat("y", 99)+"\n", 11000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLog(t, "app.log", tt.prefix+"first\n"+long+"\nlast\n")
+
+			entry, err := readLogFile(context.Background(), path, 3)
+			if err != nil {
+				t.Fatalf("readLogFile() error = %v", err)
+			}
+			want := "first\n" + long + "\nlast"
+			if entry.Message != want {
+				t.Errorf("readLogFile() message has %d bytes, want %d (line split across reads was not rejoined)", len(entry.Message), len(want))
+			}
+			if entry.Lines != 3 {
+				t.Errorf("readLogFile() lines = %d, want 3", entry.Lines)
+			}
+		})
+	}
+}
+
+func TestReadLogFileLongLines(t *testing.T) {
+	// Lines over bufio.Scanner's default 64KB limit are read whole
+	long := strings.Repeat("x", 100*1024)
+	path := writeLog(t, "app.log", "first\n"+long+"\nlast\n")
+
+	entry, err := readLogFile(context.Background(), path, 3)
+	if err != nil {
+		t.Fatalf("readLogFile() error = %v", err)
+	}
+	if entry.Lines != 3 || len(entry.Message) != len("first\n"+long+"\nlast") {
+		t.Errorf("readLogFile() = %d lines, %d bytes; want 3 lines with the long line intact", entry.Lines, len(entry.Message))
+	}
+}
+
+func TestReadLogFileOverlongLine(t *testing.T) {
+	// A line over maxLogLineBytes ends the read, but the lines before it are kept
+	overlong := strings.Repeat("x", maxLogLineBytes+1)
+	path := writeLog(t, "app.log", "first\nsecond\n"+overlong+"\nlast\n")
+
+	entry, err := readLogFile(context.Background(), path, 10)
+	if err != nil {
+		t.Fatalf("readLogFile() error = %v", err)
+	}
+	if entry == nil || entry.Message != "first\nsecond" {
+		t.Errorf("readLogFile() = %+v, want the lines before the overlong line", entry)
+	}
+}
@@ -2,18 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"vpsentinel-agent/commands"
 	"vpsentinel-agent/config"
+	"vpsentinel-agent/logging"
 	"vpsentinel-agent/logs"
 	"vpsentinel-agent/metrics"
 	"vpsentinel-agent/models"
 	"vpsentinel-agent/network"
+	"vpsentinel-agent/security"
 	"vpsentinel-agent/services"
 	"vpsentinel-agent/transport"
 )
@@ -21,28 +25,135 @@ import (
 // Version is set during build via ldflags
 var Version = "dev"
 
+var (
+	// startTime is used to report agent uptime
+	startTime = time.Now()
+
+	// latestVersion and updateAvailable are set by the startup version check
+	latestVersion   string
+	updateAvailable bool
+
+	// activeCommands tracks backend commands running in the background
+	activeCommands = commands.NewActiveCommandTracker()
+
+	// diskTrends compares disk usage between scheduled collection cycles
+	diskTrends = metrics.NewDiskTrendTracker()
+)
+
+// shutdownTimeout is how long shutdown waits for running commands to finish
+const shutdownTimeout = 15 * time.Second
+
 func main() {
-	log.Printf("VPSentinel Agent v%s starting...", Version)
+	logging.Infof("VPSentinel Agent v%s starting...", Version)
 
 	// Load configuration
-	cfg, err := config.Load("config.json")
+	cfg, fileFound, err := config.LoadWithDefaults("config.json")
 	if err != nil {
+		// First run without a config file or environment: write a template to edit
+		if !fileFound {
+			if genErr := config.GenerateDefault("config.json"); genErr == nil {
+				log.Fatalf("No configuration found (%v). A default config.json has been created: set api_key and backend_url, then start the agent again", err)
+			}
+		}
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if !fileFound {
+		logging.Warnf("config.json not found, using configuration from environment variables")
+	}
+
+	logging.Infof("Configuration loaded: backend=%s, interval=%ds", cfg.BackendURL, cfg.IntervalSeconds)
 
-	log.Printf("Configuration loaded: backend=%s, interval=%ds", cfg.BackendURL, cfg.IntervalSeconds)
+	if err := applyRuntimeConfig(cfg); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// Initialize transport client
-	client := transport.NewClient(cfg.BackendURL, cfg.APIKey)
+	client := transport.NewClient(cfg.BackendURL, cfg.APIKey, Version)
+	client.SetCircuitBreakerOpenDuration(time.Duration(cfg.CircuitBreakerOpenSeconds) * time.Second)
+	client.SetRateLimit(cfg.MaxRequestsPerSecond)
+	if cfg.SigningSecret != "" {
+		client.SetSigningSecret(cfg.SigningSecret)
+	}
+	serializer, err := transport.NewSerializer(cfg.SerializationFormat)
+	if err != nil {
+		log.Fatalf("Invalid serialization format: %v", err)
+	}
+	client.SetSerializer(serializer)
+	if len(cfg.BackendTLSPins) > 0 {
+		client.SetTLSPins(cfg.BackendTLSPins)
+	}
+	if len(cfg.CustomHeaders) > 0 {
+		client.SetCustomHeaders(cfg.CustomHeaders)
+	}
+	if len(cfg.APIKeys) > 0 {
+		client.SetRotationKeys(cfg.APIKeys, func(apiKey string) {
+			persistRotatedAPIKey("config.json", apiKey)
+		})
+	}
+
+	// Check connectivity early so misconfigured URLs and keys show up in the log
+	checkBackendAtStartup(client)
+
+	// Let operators know when a newer release is available
+	latestVersion, updateAvailable, err = client.CheckLatestVersion()
+	if err != nil {
+		logging.Warnf("Failed to check for agent updates: %v", err)
+	} else if updateAvailable {
+		logging.Warnf("A newer agent version is available (running %s, latest %s)", Version, latestVersion)
+	}
 
 	// Set up graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	shutdownFunc := func() {
+		setState(StateShuttingDown)
 		cancel()
 	}
 
+	// Recent payloads are kept for the get_metrics_history command
+	history := metrics.NewRingBuffer(cfg.MetricsHistorySize)
+
+	// Error-rate baselines persist across collection cycles
+	anomalyDetector := logs.NewAnomalyDetector()
+
+	// Port scans are cached briefly and shared with the scan_ports command
+	portScanner := network.NewPortScanner(network.DefaultPortCacheMaxAge)
+
+	// Applications can push StatsD metrics to the agent
+	var statsd *metrics.StatsDCollector
+	if cfg.StatsDListenAddr != "" {
+		// StatsD is optional, so a port that stays busy doesn't stop the agent
+		statsd, err = metrics.NewStatsDCollector(cfg.StatsDListenAddr)
+		if err != nil {
+			logging.Errorf("Failed to start StatsD listener, continuing without StatsD: %v", err)
+			statsd = nil
+		} else {
+			defer statsd.Close()
+			logging.Infof("Listening for StatsD metrics on %s", cfg.StatsDListenAddr)
+		}
+	}
+
 	// Initialize command handler
-	cmdHandler := commands.NewHandler("config.json", shutdownFunc)
+	cmdHandler := commands.NewHandler("config.json", client, shutdownFunc)
+	cmdHandler.SetMetricsHistory(history)
+	cmdHandler.SetPortScanner(portScanner)
+	cmdHandler.SetCollector(func() models.Payload {
+		return collectPayload(liveConfig.Load(), anomalyDetector, portScanner, false)
+	})
+	cmdHandler.SetConfigReloader(applyRuntimeConfig)
+
+	// Report the agent state for supervisors and debugging
+	if cfg.HealthPort > 0 {
+		healthServer, err := startHealthServer(cfg.HealthPort, cfg.HealthAllowedIPs, client)
+		if err != nil {
+			log.Fatalf("Failed to start health endpoint: %v", err)
+		}
+		defer healthServer.Close()
+	}
+
+	// Show retries in the agent state
+	client.SetRetryCallback(func(attempt int) {
+		setState(StateRetrying)
+	})
 
 	// Handle signals for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -50,117 +161,354 @@ func main() {
 
 	// Start collection loop in goroutine
 	done := make(chan bool)
-	go collectionLoop(ctx, cfg, client, cmdHandler, done)
+	go collectionLoop(ctx, client, cmdHandler, anomalyDetector, portScanner, history, statsd, done)
 
 	// Wait for signal or completion
 	select {
 	case sig := <-sigChan:
-		log.Printf("Received signal: %v, shutting down gracefully...", sig)
+		logging.Infof("Received signal: %v, shutting down gracefully...", sig)
+		setState(StateShuttingDown)
 		cancel()
 		<-done
 	case <-done:
-		log.Println("Collection loop stopped")
+		logging.Infof("Collection loop stopped")
+	}
+
+	// Give running commands (e.g. the stop command's response) a chance to finish
+	if !activeCommands.WaitWithTimeout(shutdownTimeout) {
+		logging.Warnf("Commands still running after %v: %s", shutdownTimeout, strings.Join(activeCommands.Running(), ", "))
 	}
 
-	log.Println("VPSentinel Agent stopped")
+	logging.Infof("VPSentinel Agent stopped")
 }
 
 // collectionLoop runs the main collection and transmission loop
-func collectionLoop(ctx context.Context, cfg *config.Config, client *transport.Client, cmdHandler *commands.Handler, done chan bool) {
+// Each cycle uses the latest config from liveConfig
+func collectionLoop(ctx context.Context, client *transport.Client, cmdHandler *commands.Handler, anomalyDetector *logs.AnomalyDetector, portScanner *network.PortScanner, history *metrics.RingBuffer, statsd *metrics.StatsDCollector, done chan bool) {
 	defer close(done)
 
 	// Immediate first collection
-	if err := collectAndSend(cfg, client, cmdHandler); err != nil {
-		log.Printf("Initial collection failed: %v", err)
+	var adaptive adaptiveInterval
+	cfg := liveConfig.Load()
+	payload, err := collectAndSend(ctx, cfg, client, cmdHandler, anomalyDetector, portScanner, history, statsd)
+	if err != nil {
+		logging.Errorf("Initial collection failed: %v", err)
 	}
 
 	// Set up ticker for periodic collection
-	ticker := time.NewTicker(time.Duration(cfg.IntervalSeconds) * time.Second)
+	interval := adaptive.next(cfg, payload, err)
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Context cancelled, stopping collection loop")
+			logging.Infof("Context cancelled, stopping collection loop")
+			setState(StateShuttingDown)
 			return
 		case <-ticker.C:
-			if err := collectAndSend(cfg, client, cmdHandler); err != nil {
-				log.Printf("Collection cycle failed: %v", err)
+			cfg := liveConfig.Load()
+			payload, err := collectAndSend(ctx, cfg, client, cmdHandler, anomalyDetector, portScanner, history, statsd)
+			if err != nil {
+				logging.Errorf("Collection cycle failed: %v", err)
 				// Continue running even on errors
 			}
+
+			// Pick up an interval changed by soft_reload or adaptive mode
+			if next := adaptive.next(cfg, payload, err); next != interval {
+				interval = next
+				ticker.Reset(time.Duration(interval) * time.Second)
+			}
 		}
 	}
 }
 
 // collectAndSend collects all metrics and sends them to the backend
-func collectAndSend(cfg *config.Config, client *transport.Client, cmdHandler *commands.Handler) error {
-	startTime := time.Now()
-	log.Println("Starting collection cycle...")
+// The collected payload is returned even if sending failed
+// Commands received in this cycle run under ctx and are cancelled on shutdown
+func collectAndSend(ctx context.Context, cfg *config.Config, client *transport.Client, cmdHandler *commands.Handler, anomalyDetector *logs.AnomalyDetector, portScanner *network.PortScanner, history *metrics.RingBuffer, statsd *metrics.StatsDCollector) (models.Payload, error) {
+	cycleStart := time.Now()
+	setState(StateCollecting)
+	logging.Infof("Starting collection cycle...")
 
 	// Check for commands from backend before collecting
+	commandsStart := time.Now()
 	if cmdHandler != nil {
 		cmds, err := client.CheckCommands()
 		if err == nil && len(cmds) > 0 {
-			log.Printf("Received %d command(s) from backend", len(cmds))
+			logging.Infof("Received %d command(s) from backend", len(cmds))
 			for _, cmd := range cmds {
+				activeCommands.Add(cmd)
 				go func(c models.Command) {
-					result, err := cmdHandler.Execute(context.Background(), c)
+					defer activeCommands.Done(c)
+					result, err := cmdHandler.ExecuteWithTimeout(ctx, c, time.Duration(cfg.CommandTimeoutSeconds)*time.Second)
 					status := "success"
 					message := result
 					if err != nil {
 						status = "error"
+						if errors.Is(err, commands.ErrCommandTimeout) {
+							status = "timeout"
+						}
 						message = err.Error()
-						log.Printf("Command execution failed: %v", err)
+						logging.Errorf("Command execution failed: %v", err)
 					}
 					if err := client.SendCommandResponse(c.ID, status, message); err != nil {
-						log.Printf("Failed to send command response: %v", err)
+						logging.Errorf("Failed to send command response: %v", err)
 					}
 				}(cmd)
 			}
 		} else if err != nil {
-			log.Printf("Warning: Failed to check commands: %v", err)
+			logging.Warnf("Failed to check commands: %v", err)
 		}
 	}
 
+	commandsDuration := time.Since(commandsStart)
+
+	payload := collectPayload(cfg, anomalyDetector, portScanner, true)
+	payload.Agent.SubsystemTimings["commands"] = commandsDuration.Milliseconds()
+
+	// StatsD metrics cover the interval since the previous cycle
+	if statsd != nil {
+		payload.StatsD = statsd.Flush()
+	}
+
+	collectionDuration := time.Since(cycleStart)
+	logging.Infof("Collection completed in %v", collectionDuration)
+
+	// Send payload with retry logic (handled in transport)
+	if err := client.Send(payload); err != nil {
+		setState(StateRetrying) // Retried on the next cycle
+		return payload, err
+	}
+	history.Push(payload)
+	setState(StateIdle)
+
+	logging.Infof("Payload sent successfully (total cycle time: %v)", time.Since(cycleStart))
+	return payload, nil
+}
+
+// collectPayload collects all metrics and assembles the payload
+// scheduled is false for on-demand collections (commands); those compare against
+// the previous scheduled cycle without moving its baselines
+func collectPayload(cfg *config.Config, anomalyDetector *logs.AnomalyDetector, portScanner *network.PortScanner, scheduled bool) models.Payload {
+	// Per-subsystem durations in milliseconds, reported in AgentStats
+	timings := make(map[string]int64)
+
 	// Collect system metrics
-	sysMetrics, err := metrics.CollectSystem()
+	stepStart := time.Now()
+	metricsCtx, cancelMetrics := context.WithTimeout(context.Background(), cfg.CollectionTimeout("metrics"))
+	sysMetrics, err := metrics.CollectSystem(metricsCtx)
+	cancelMetrics()
 	if err != nil {
-		log.Printf("Warning: Failed to collect system metrics: %v", err)
+		logging.Warnf("Failed to collect system metrics: %v", err)
 		// Continue with partial data
 	}
+	if scheduled {
+		diskTrends.Update(sysMetrics.Disks)
+	} else {
+		diskTrends.Compare(sysMetrics.Disks)
+	}
+	logTiming(timings, "metrics", stepStart)
+
+	// Identify the hardware so the backend can detect migrations and resizes
+	fingerprintCtx, cancelFingerprint := context.WithTimeout(context.Background(), cfg.CollectionTimeout("metrics"))
+	fingerprint, err := metrics.CollectFingerprint(fingerprintCtx)
+	cancelFingerprint()
+	if err != nil {
+		logging.Warnf("Failed to collect system fingerprint: %v", err)
+	}
 
 	// Collect open ports (this can take longer)
-	ports, err := network.GetOpenPorts(cfg.PortsToMonitor)
+	stepStart = time.Now()
+	ports, err := portScanner.Scan(cfg.PortsToMonitor)
 	if err != nil {
-		log.Printf("Warning: Failed to collect ports: %v", err)
+		logging.Warnf("Failed to collect ports: %v", err)
 		ports = []models.PortInfo{} // Empty slice on error
 	}
+	logTiming(timings, "ports", stepStart)
 
 	// Detect running services
+	stepStart = time.Now()
 	detectedServices := services.DetectAllServices()
+	logTiming(timings, "services", stepStart)
 	servicesList := make([]models.ServiceInfo, len(detectedServices))
 	for i, svc := range detectedServices {
 		servicesList[i] = models.ServiceInfo{
-			Type:      string(svc.Type),
-			Name:      svc.Name,
-			Version:   svc.Version,
-			IsRunning: svc.IsRunning,
-			Port:      svc.Port,
+			Type:         string(svc.Type),
+			Name:         svc.Name,
+			Version:      svc.Version,
+			IsRunning:    svc.IsRunning,
+			Port:         svc.Port,
+			IsSealed:     svc.IsSealed,
+			MemoryMB:     svc.MemoryMB,
+			CPUPercent:   svc.CPUPercent,
+			RouterCount:  svc.RouterCount,
+			ServiceCount: svc.ServiceCount,
+			Mode:         svc.Mode,
+			Description:  svc.Description,
+			ConfigFiles:  svc.ConfigFiles,
+		}
+	}
+
+	// Infer service topology from detected services and services found on open ports
+	graphInput := detectedServices
+	for _, port := range ports {
+		if port.ServiceType != "" {
+			graphInput = append(graphInput, services.ServiceInfo{
+				Type: services.ServiceType(port.ServiceType),
+				Name: port.ServiceName,
+				Port: port.Port,
+			})
 		}
 	}
+	serviceGraph := services.BuildDependencyGraph(graphInput)
 
-	// Check SSL certificates (can be slow, run in parallel if needed)
+	// Flag well-known ports held by unexpected processes
+	portConflicts := services.DetectPortConflicts(detectedServices, ports)
+	for _, c := range portConflicts {
+		logging.Warnf("Port %d is held by %s, expected %s", c.Port, c.ActualProcess, c.ExpectedService)
+	}
+
+	// Check SSL certificates (in parallel, failures are reported per domain)
+	stepStart = time.Now()
 	sslInfo, err := network.CheckSSL(cfg.SSLDomains)
 	if err != nil {
-		log.Printf("Warning: Failed to check SSL certificates: %v", err)
-		sslInfo = []models.SSLInfo{} // Empty slice on error
+		logging.Warnf("Failed to check SSL certificates: %v", err)
+	}
+	for _, result := range sslInfo {
+		if result.Error != "" {
+			logging.Warnf("SSL check failed for %s: %s", result.Domain, result.Error)
+		}
+	}
+
+	// Look for certificates issued for monitored domains
+	if cfg.EnableCTLogCheck {
+		for i := range sslInfo {
+			if sslInfo[i].Error != "" {
+				continue
+			}
+			entries, err := network.CheckCTLogs(sslInfo[i].Domain)
+			if err != nil {
+				logging.Warnf("Failed to check CT logs for %s: %v", sslInfo[i].Domain, err)
+				continue
+			}
+			sslInfo[i].CTLogEntries = entries
+		}
+	}
+	logTiming(timings, "ssl", stepStart)
+
+	// Check HTTP endpoints for uptime
+	var httpEndpoints []models.HTTPEndpointResult
+	if len(cfg.HTTPEndpoints) > 0 {
+		stepStart = time.Now()
+		httpEndpoints = network.CheckHTTPEndpoints(cfg.HTTPEndpoints)
+		logTiming(timings, "http_endpoints", stepStart)
+	}
+
+	// Collect LVM volumes (thin pools silently corrupt data when full)
+	var lvmVolumes []models.LVInfo
+	if cfg.EnableLVMMetrics {
+		lvmVolumes, err = metrics.CollectLVM()
+		if err != nil {
+			logging.Warnf("Failed to collect LVM volumes: %v", err)
+		}
 	}
 
 	// Read and sanitize logs
-	logsData, err := logs.ReadAndSanitize(cfg.LogPaths, cfg.LogMaxLines)
+	stepStart = time.Now()
+	logsCtx, cancelLogs := context.WithTimeout(context.Background(), cfg.CollectionTimeout("logs"))
+	logsData, err := logs.ReadAndSanitize(logsCtx, cfg.LogPaths, cfg.LogMaxLines, cfg.LogRateLimitBytesPerCycle)
+	cancelLogs()
 	if err != nil {
-		log.Printf("Warning: Failed to read logs: %v", err)
-		logsData = []models.LogEntry{} // Empty slice on error
+		logging.Warnf("Failed to read logs: %v", err)
+	}
+	if logsData == nil {
+		logsData = []models.LogEntry{} // Empty slice instead of nil (keeps partial results on timeout)
+	}
+	logTiming(timings, "logs", stepStart)
+
+	// Compare error counts against recent cycles
+	var anomalies []models.LogAnomaly
+	if scheduled {
+		anomalies = anomalyDetector.CheckEntries(logsData)
+	} else {
+		anomalies = anomalyDetector.CompareEntries(logsData)
+	}
+	for _, a := range anomalies {
+		logging.Warnf("Error spike in %s (%d errors, baseline %.1f)", a.Path, a.CurrentCount, a.Baseline)
+	}
+
+	// Look for processes killed by the OOM killer
+	var oomEvents []models.OOMEvent
+	if cfg.EnableOOMDetection {
+		oomEvents, err = logs.DetectOOMEvents(cfg.KernLogPath)
+		if err != nil {
+			logging.Warnf("Failed to scan kernel log for OOM events: %v", err)
+		}
+	}
+
+	// Enumerate cron jobs (helps detect persistence mechanisms)
+	var cronJobs []models.CronJob
+	if cfg.EnableCronAudit {
+		cronJobs, err = metrics.CollectCronJobs()
+		if err != nil {
+			logging.Warnf("Failed to collect cron jobs: %v", err)
+		}
+	}
+
+	// Audit SSH daemon configuration
+	var sshConfig *models.SSHConfigAudit
+	if cfg.EnableSSHAudit {
+		sshConfig, err = network.CheckSSHConfig()
+		if err != nil {
+			logging.Warnf("Failed to audit SSH config: %v", err)
+		}
+	}
+
+	// Audit SUID and world-writable files
+	var fileAudit []models.FileAuditEntry
+	if cfg.EnableFileAudit {
+		if scheduled {
+			fileAudit, err = security.CheckSUIDFiles(cfg.FileAuditRoots)
+		} else {
+			fileAudit, err = security.CompareSUIDFiles(cfg.FileAuditRoots)
+		}
+		if err != nil {
+			logging.Warnf("File audit incomplete: %v", err)
+		}
+	}
+
+	// Find recently modified /etc files
+	var etcChanges []models.ModifiedFile
+	if cfg.EnableEtcAudit {
+		etcChanges, err = security.CheckEtcChanges(time.Duration(cfg.EtcAuditHours) * time.Hour)
+		if err != nil {
+			logging.Warnf("/etc audit incomplete: %v", err)
+		}
+	}
+
+	// Flag listeners that don't belong to any known service
+	var securityFindings []models.SecurityFinding
+	if cfg.EnableSecurityAudit {
+		securityFindings = security.DetectUnusualPorts(ports)
+	}
+
+	// Check for pending package updates
+	var pendingUpdates []models.PackageUpdate
+	if cfg.EnablePackageAudit {
+		pendingUpdates, err = metrics.CollectPackageUpdates()
+		if err != nil {
+			logging.Warnf("Failed to check package updates: %v", err)
+		}
+	}
+
+	// Look up the outbound IP (cached for an hour)
+	var publicIP, ipCountry, ipASN string
+	if cfg.EnableGeoIP {
+		publicIP, ipCountry, ipASN, err = network.GetPublicIP(cfg.GeoIPURL)
+		if err != nil {
+			logging.Warnf("Failed to look up public IP: %v", err)
+		}
 	}
 
 	// Get hostname (from config or system)
@@ -175,22 +523,43 @@ func collectAndSend(cfg *config.Config, client *transport.Client, cmdHandler *co
 	// Assemble payload
 	payload := models.Payload{
 		Host:      hostname,
+		PublicIP:  publicIP,
+		IPCountry: ipCountry,
+		IPASN:     ipASN,
 		Timestamp: time.Now().UTC(),
-		System:    sysMetrics,
-		Ports:     ports,
-		Services:  servicesList,
-		SSL:       sslInfo,
-		Logs:      logsData,
+		Agent: &models.AgentStats{
+			Version:          Version,
+			UptimeSeconds:    int64(time.Since(startTime).Seconds()),
+			UpdateAvailable:  updateAvailable,
+			LatestVersion:    latestVersion,
+			SubsystemTimings: timings,
+		},
+		System:           sysMetrics,
+		Fingerprint:      fingerprint,
+		Ports:            ports,
+		Services:         servicesList,
+		ServiceGraph:     &serviceGraph,
+		PortConflicts:    portConflicts,
+		SSL:              sslInfo,
+		HTTPEndpoints:    httpEndpoints,
+		Logs:             logsData,
+		Anomalies:        anomalies,
+		OOMEvents:        oomEvents,
+		CronJobs:         cronJobs,
+		SSHConfig:        sshConfig,
+		FileAudit:        fileAudit,
+		RecentEtcChanges: etcChanges,
+		SecurityFindings: securityFindings,
+		PendingUpdates:   pendingUpdates,
+		LVMVolumes:       lvmVolumes,
 	}
 
-	collectionDuration := time.Since(startTime)
-	log.Printf("Collection completed in %v", collectionDuration)
-
-	// Send payload with retry logic (handled in transport)
-	if err := client.Send(payload); err != nil {
-		return err
-	}
+	return payload
+}
 
-	log.Printf("Payload sent successfully (total cycle time: %v)", time.Since(startTime))
-	return nil
+// logTiming records how long a collection step took and logs it (debug level only)
+func logTiming(timings map[string]int64, subsystem string, start time.Time) {
+	elapsed := time.Since(start)
+	timings[subsystem] = elapsed.Milliseconds()
+	logging.Debugf("Collected %s in %v", subsystem, elapsed)
 }
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// SystemCollector reads the raw system statistics used by CollectSystem
+// CollectSystem uses this interface so tests can substitute fixed values or failures
+type SystemCollector interface {
+	// CPUPercent returns usage over interval, per core or as a single aggregate
+	CPUPercent(ctx context.Context, interval time.Duration, perCPU bool) ([]float64, error)
+	// VirtualMemory returns physical memory statistics
+	VirtualMemory(ctx context.Context) (*mem.VirtualMemoryStat, error)
+	// SwapMemory returns swap statistics
+	SwapMemory(ctx context.Context) (*mem.SwapMemoryStat, error)
+	// Partitions returns mounted filesystems (all includes virtual filesystems)
+	Partitions(ctx context.Context, all bool) ([]disk.PartitionStat, error)
+	// DiskUsage returns usage of the filesystem mounted at path
+	DiskUsage(ctx context.Context, path string) (*disk.UsageStat, error)
+	// NetIOCounters returns network I/O counters, per interface if perNIC is set
+	NetIOCounters(ctx context.Context, perNIC bool) ([]net.IOCountersStat, error)
+}
+
+// GopsutilCollector reads system statistics from the host using gopsutil
+type GopsutilCollector struct{}
+
+// CPUPercent returns usage over interval, per core or as a single aggregate
+func (GopsutilCollector) CPUPercent(ctx context.Context, interval time.Duration, perCPU bool) ([]float64, error) {
+	return cpu.PercentWithContext(ctx, interval, perCPU)
+}
+
+// VirtualMemory returns physical memory statistics
+func (GopsutilCollector) VirtualMemory(ctx context.Context) (*mem.VirtualMemoryStat, error) {
+	return mem.VirtualMemoryWithContext(ctx)
+}
+
+// SwapMemory returns swap statistics
+func (GopsutilCollector) SwapMemory(ctx context.Context) (*mem.SwapMemoryStat, error) {
+	return mem.SwapMemoryWithContext(ctx)
+}
+
+// Partitions returns mounted filesystems (all includes virtual filesystems)
+func (GopsutilCollector) Partitions(ctx context.Context, all bool) ([]disk.PartitionStat, error) {
+	return disk.PartitionsWithContext(ctx, all)
+}
+
+// DiskUsage returns usage of the filesystem mounted at path
+func (GopsutilCollector) DiskUsage(ctx context.Context, path string) (*disk.UsageStat, error) {
+	return disk.UsageWithContext(ctx, path)
+}
+
+// NetIOCounters returns network I/O counters, per interface if perNIC is set
+func (GopsutilCollector) NetIOCounters(ctx context.Context, perNIC bool) ([]net.IOCountersStat, error) {
+	return net.IOCountersWithContext(ctx, perNIC)
+}
+
+// systemCollector provides the statistics read by CollectSystem
+var systemCollector SystemCollector = GopsutilCollector{}
+
+// SetSystemCollector replaces the system statistics source (used to inject fixed values in tests)
+func SetSystemCollector(c SystemCollector) {
+	systemCollector = c
+}
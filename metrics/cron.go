@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"vpsentinel-agent/models"
+)
+
+const (
+	// maxCronJobs caps the number of cron entries reported per cycle
+	maxCronJobs = 200
+
+	systemCrontab   = "/etc/crontab"
+	cronDDir        = "/etc/cron.d"
+	userCrontabsDir = "/var/spool/cron/crontabs"
+)
+
+// CollectCronJobs enumerates system and per-user cron jobs
+// Sources: /etc/crontab, /etc/cron.d/*, /var/spool/cron/crontabs/* and `crontab -l` per user
+func CollectCronJobs() ([]models.CronJob, error) {
+	var jobs []models.CronJob
+	seen := make(map[string]bool)
+
+	add := func(newJobs []models.CronJob) {
+		for _, job := range newJobs {
+			if len(jobs) >= maxCronJobs {
+				return
+			}
+			// The spool files and `crontab -l` usually report the same entries
+			key := job.User + "\x00" + job.Schedule + "\x00" + job.Command
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			jobs = append(jobs, job)
+		}
+	}
+
+	// System crontab (includes a user column)
+	if systemJobs, err := readCronFile(systemCrontab, "", true); err == nil {
+		add(systemJobs)
+	}
+
+	// Package-installed cron fragments (same format as /etc/crontab)
+	if files, err := filepath.Glob(filepath.Join(cronDDir, "*")); err == nil {
+		for _, file := range files {
+			if fileJobs, err := readCronFile(file, "", true); err == nil {
+				add(fileJobs)
+			}
+		}
+	}
+
+	// Per-user crontabs (file name is the user, no user column)
+	if files, err := filepath.Glob(filepath.Join(userCrontabsDir, "*")); err == nil {
+		for _, file := range files {
+			if fileJobs, err := readCronFile(file, filepath.Base(file), false); err == nil {
+				add(fileJobs)
+			}
+		}
+	}
+
+	// Ask crontab directly for every local user (covers non-Debian spool layouts)
+	for _, user := range listUsers() {
+		if len(jobs) >= maxCronJobs {
+			break
+		}
+		output, err := exec.Command("crontab", "-l", "-u", user).Output()
+		if err != nil {
+			// No crontab for this user or insufficient permissions
+			continue
+		}
+		add(parseCrontab(string(output), user, "crontab -l -u "+user, false))
+	}
+
+	if jobs == nil {
+		jobs = []models.CronJob{}
+	}
+
+	return jobs, nil
+}
+
+// readCronFile reads and parses a single crontab file
+func readCronFile(path, user string, hasUserField bool) ([]models.CronJob, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCrontab(string(content), user, path, hasUserField), nil
+}
+
+// parseCrontab parses crontab content into cron jobs
+// System crontabs carry a user column after the schedule, user crontabs do not
+func parseCrontab(content, user, source string, hasUserField bool) []models.CronJob {
+	var jobs []models.CronJob
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip blank lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		// Skip environment assignments (SHELL=/bin/sh, MAILTO=root, ...)
+		if strings.Contains(fields[0], "=") {
+			continue
+		}
+
+		// Special schedules (@reboot, @daily, ...) take one field, standard ones take five
+		scheduleFields := 5
+		if strings.HasPrefix(fields[0], "@") {
+			scheduleFields = 1
+		}
+
+		minFields := scheduleFields + 1
+		if hasUserField {
+			minFields++
+		}
+		if len(fields) < minFields {
+			continue
+		}
+
+		jobUser := user
+		commandStart := scheduleFields
+		if hasUserField {
+			jobUser = fields[scheduleFields]
+			commandStart++
+		}
+
+		jobs = append(jobs, models.CronJob{
+			User:     jobUser,
+			Schedule: strings.Join(fields[:scheduleFields], " "),
+			Command:  strings.Join(fields[commandStart:], " "),
+			Source:   source,
+		})
+	}
+
+	return jobs
+}
+
+// listUsers returns local user names from /etc/passwd
+func listUsers() []string {
+	file, err := os.Open("/etc/passwd")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var users []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, _, found := strings.Cut(line, ":"); found && name != "" {
+			users = append(users, name)
+		}
+	}
+
+	return users
+}
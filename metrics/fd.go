@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const fileNrPath = "/proc/sys/fs/file-nr"
+
+// collectSystemFileDescriptors returns the system-wide number of allocated file
+// descriptors and their maximum from /proc/sys/fs/file-nr (Linux only)
+func collectSystemFileDescriptors() (uint64, uint64, error) {
+	data, err := os.ReadFile(fileNrPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseFileNr(string(data))
+}
+
+// collectAgentFileDescriptors returns the number of file descriptors the agent
+// process holds open and its soft limit (0 where the platform has none)
+func collectAgentFileDescriptors() (uint64, uint64, error) {
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return 0, 0, err
+	}
+	numFDs, err := proc.NumFDs()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Soft limit is best effort (not available on all platforms)
+	limit, _ := fdSoftLimit()
+
+	return uint64(numFDs), limit, nil
+}
+
+// parseFileNr parses the contents of /proc/sys/fs/file-nr
+// Format: <allocated> <unused (always 0 on modern kernels)> <max>
+func parseFileNr(data string) (uint64, uint64, error) {
+	fields := strings.Fields(data)
+	if len(fields) < 3 {
+		return 0, 0, fmt.Errorf("unexpected file-nr format: %q", data)
+	}
+
+	allocated, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	max, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return allocated, max, nil
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseFileNr(t *testing.T) {
+	tests := []struct {
+		data      string
+		wantOpen  uint64
+		wantMax   uint64
+		wantError bool
+	}{
+		{"1984\t0\t9223372036854775807\n", 1984, 9223372036854775807, false},
+		{"3264 0 398472", 3264, 398472, false},
+		{"3264 0", 0, 0, true},
+		{"x 0 398472", 0, 0, true},
+		{"", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		open, max, err := parseFileNr(tt.data)
+		if (err != nil) != tt.wantError || open != tt.wantOpen || max != tt.wantMax {
+			t.Errorf("parseFileNr(%q) = %d, %d, %v; want %d, %d (error: %v)", tt.data, open, max, err, tt.wantOpen, tt.wantMax, tt.wantError)
+		}
+	}
+}
+
+func TestCollectAgentFileDescriptors(t *testing.T) {
+	// Hold one more descriptor open and check the count follows
+	before, _, err := collectAgentFileDescriptors()
+	if err != nil {
+		t.Skipf("agent file descriptors not available: %v", err)
+	}
+	file, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	after, _, err := collectAgentFileDescriptors()
+	if err != nil {
+		t.Fatalf("collectAgentFileDescriptors() error = %v", err)
+	}
+	if after <= before {
+		t.Errorf("open descriptors = %d after opening a file, want more than %d", after, before)
+	}
+}
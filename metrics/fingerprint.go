@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/net"
+
+	"vpsentinel-agent/models"
+)
+
+// virtualInterfacePrefixes are interfaces created and removed at runtime
+// (containers, bridges, VPNs) whose MAC addresses would make the fingerprint unstable
+var virtualInterfacePrefixes = []string{"lo", "veth", "docker", "br-", "virbr", "cni", "flannel", "cali", "vxlan", "tun", "tap", "wg"}
+
+// CollectFingerprint collects hardware identity used to detect migrations and resizing
+// Returns partial data with the first error if some sources fail
+func CollectFingerprint(ctx context.Context) (models.SystemFingerprint, error) {
+	var fp models.SystemFingerprint
+	var errs []error
+
+	if infos, err := cpu.InfoWithContext(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("CPU info failed: %w", err))
+	} else if len(infos) > 0 {
+		fp.CPUModel = strings.TrimSpace(infos[0].ModelName)
+	}
+	if cores, err := cpu.CountsWithContext(ctx, true); err != nil {
+		errs = append(errs, fmt.Errorf("CPU count failed: %w", err))
+	} else {
+		fp.CPUCores = cores
+	}
+
+	if memStats, err := systemCollector.VirtualMemory(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("memory info failed: %w", err))
+	} else {
+		fp.MemoryTotalMB = memStats.Total / (1024 * 1024)
+	}
+
+	// Physical devices only, sorted so the hash doesn't depend on mount order
+	fp.DiskDevices = []string{}
+	if partitions, err := systemCollector.Partitions(ctx, false); err != nil {
+		errs = append(errs, fmt.Errorf("disk info failed: %w", err))
+	} else {
+		seen := make(map[string]bool)
+		for _, partition := range partitions {
+			if partition.Device == "" || seen[partition.Device] {
+				continue
+			}
+			seen[partition.Device] = true
+			fp.DiskDevices = append(fp.DiskDevices, partition.Device)
+		}
+		sort.Strings(fp.DiskDevices)
+	}
+
+	fp.MacAddresses = []string{}
+	if interfaces, err := net.InterfacesWithContext(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("interface info failed: %w", err))
+	} else {
+		seen := make(map[string]bool)
+		for _, iface := range interfaces {
+			mac := strings.ToLower(iface.HardwareAddr)
+			if mac == "" || seen[mac] || isVirtualInterface(iface.Name) {
+				continue
+			}
+			seen[mac] = true
+			fp.MacAddresses = append(fp.MacAddresses, mac)
+		}
+		sort.Strings(fp.MacAddresses)
+	}
+
+	fp.FingerprintHash = fingerprintHash(fp)
+
+	if len(errs) > 0 {
+		return fp, errs[0]
+	}
+	return fp, nil
+}
+
+// isVirtualInterface checks whether an interface name belongs to a runtime-created interface
+func isVirtualInterface(name string) bool {
+	for _, prefix := range virtualInterfacePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprintHash returns the hex SHA-256 of all fingerprint fields except the hash itself
+func fingerprintHash(fp models.SystemFingerprint) string {
+	fp.FingerprintHash = ""
+	data, err := json.Marshal(fp)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
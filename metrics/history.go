@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"sync"
+
+	"vpsentinel-agent/models"
+)
+
+// RingBuffer keeps the most recent payloads in memory
+type RingBuffer struct {
+	mu    sync.Mutex
+	items []models.Payload
+	next  int  // Index the next push writes to
+	full  bool // Whether the buffer has wrapped
+}
+
+// NewRingBuffer creates a ring buffer holding up to capacity payloads
+func NewRingBuffer(capacity int) *RingBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RingBuffer{items: make([]models.Payload, capacity)}
+}
+
+// Push adds a payload, overwriting the oldest one when the buffer is full
+func (r *RingBuffer) Push(payload models.Payload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items[r.next] = payload
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Items returns the buffered payloads, oldest first
+func (r *RingBuffer) Items() []models.Payload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]models.Payload{}, r.items[:r.next]...)
+	}
+	return append(append([]models.Payload{}, r.items[r.next:]...), r.items[:r.next]...)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"vpsentinel-agent/models"
+)
+
+// CollectLVM lists LVM logical volumes with their size and thin pool usage
+// DataPercent is only reported for thin pools and thin volumes
+func CollectLVM() ([]models.LVInfo, error) {
+	// Sizes in bytes without suffix, '|' separated so empty columns survive
+	output, err := exec.Command("lvs", "--noheadings", "--units", "b", "--nosuffix", "--separator", "|",
+		"-o", "lv_name,vg_name,lv_size,data_percent,lv_attr").Output()
+	if err != nil {
+		return nil, fmt.Errorf("lvs failed: %w", err)
+	}
+
+	return parseLVSOutput(string(output)), nil
+}
+
+// parseLVSOutput parses lines like "  pool|vg0|107374182400|87.50|twi-aotz--"
+func parseLVSOutput(output string) []models.LVInfo {
+	volumes := []models.LVInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) < 5 || fields[0] == "" {
+			continue
+		}
+
+		size, _ := strconv.ParseUint(strings.TrimSpace(fields[2]), 10, 64)
+		dataPercent, _ := strconv.ParseFloat(strings.TrimSpace(fields[3]), 64)
+
+		// The fifth lv_attr character is the state, 'a' = active
+		attr := strings.TrimSpace(fields[4])
+		active := len(attr) >= 5 && attr[4] == 'a'
+
+		volumes = append(volumes, models.LVInfo{
+			LVName:      strings.TrimSpace(fields[0]),
+			VGName:      strings.TrimSpace(fields[1]),
+			SizeBytes:   size,
+			DataPercent: dataPercent,
+			IsActive:    active,
+		})
+	}
+	return volumes
+}
@@ -0,0 +1,216 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+const (
+	// maxPackageUpdates caps the number of pending updates reported per cycle
+	maxPackageUpdates = 100
+
+	packageCheckTimeout = 60 * time.Second
+)
+
+// CollectPackageUpdates lists packages with pending updates using the
+// system package manager (apt, yum or apk, whichever is installed)
+// At most maxPackageUpdates are returned to keep payloads small
+func CollectPackageUpdates() ([]models.PackageUpdate, error) {
+	updates, err := CollectAllPackageUpdates()
+	if len(updates) > maxPackageUpdates {
+		updates = updates[:maxPackageUpdates]
+	}
+	return updates, err
+}
+
+// CollectAllPackageUpdates lists every package with a pending update
+// apk doesn't mark security updates, so IsSecurityUpdate is only set for apt and yum
+func CollectAllPackageUpdates() ([]models.PackageUpdate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), packageCheckTimeout)
+	defer cancel()
+
+	var updates []models.PackageUpdate
+	switch {
+	case commandExists("apt"):
+		output, err := exec.CommandContext(ctx, "apt", "list", "--upgradable").Output()
+		if err != nil {
+			return nil, fmt.Errorf("apt list failed: %w", err)
+		}
+		updates = parseAptUpgradable(string(output))
+	case commandExists("yum"):
+		// yum exits with 100 when updates are available
+		output, err := exec.CommandContext(ctx, "yum", "-q", "check-update").Output()
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 100) {
+			return nil, fmt.Errorf("yum check-update failed: %w", err)
+		}
+		updates = parseYumCheckUpdate(string(output))
+
+		// check-update only names the repository, so security updates come from updateinfo
+		// Best effort: without updateinfo metadata the updates are reported unclassified
+		security, err := exec.CommandContext(ctx, "yum", "-q", "updateinfo", "list", "security").Output()
+		if err != nil {
+			logging.Warnf("yum updateinfo failed, security updates not classified: %v", err)
+		} else {
+			markSecurityUpdates(updates, parseYumSecurityPackages(string(security)))
+		}
+	case commandExists("apk"):
+		output, err := exec.CommandContext(ctx, "apk", "list", "-u").Output()
+		if err != nil {
+			return nil, fmt.Errorf("apk list failed: %w", err)
+		}
+		updates = parseApkUpgradable(string(output))
+	default:
+		return nil, fmt.Errorf("no supported package manager found")
+	}
+
+	return updates, nil
+}
+
+// commandExists checks whether a binary is on PATH
+func commandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// parseAptUpgradable parses `apt list --upgradable` output, e.g.
+// nginx/jammy-updates,jammy-security 1.18.0-6ubuntu14.4 amd64 [upgradable from: 1.18.0-6ubuntu14.3]
+func parseAptUpgradable(output string) []models.PackageUpdate {
+	var updates []models.PackageUpdate
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.Contains(fields[0], "/") {
+			continue // Skip "Listing..." header
+		}
+
+		nameAndSources := strings.SplitN(fields[0], "/", 2)
+		update := models.PackageUpdate{
+			Name:             nameAndSources[0],
+			AvailableVersion: fields[1],
+			IsSecurityUpdate: strings.Contains(nameAndSources[1], "-security"),
+		}
+		if idx := strings.Index(line, "upgradable from: "); idx != -1 {
+			update.CurrentVersion = strings.TrimSuffix(line[idx+len("upgradable from: "):], "]")
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// parseYumCheckUpdate parses `yum check-update` output, e.g.
+// openssl.x86_64    1:1.0.2k-26.el7_9    updates
+// yum doesn't report the installed version here
+func parseYumCheckUpdate(output string) []models.PackageUpdate {
+	var updates []models.PackageUpdate
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && (strings.HasPrefix(fields[0], "Obsoleting") || strings.HasPrefix(fields[0], "Security:")) {
+			break // Obsoletes section follows the update list
+		}
+		if len(fields) != 3 {
+			continue
+		}
+
+		name := fields[0]
+		if idx := strings.LastIndex(name, "."); idx > 0 {
+			name = name[:idx] // Strip architecture
+		}
+		updates = append(updates, models.PackageUpdate{
+			Name:             name,
+			AvailableVersion: fields[1],
+		})
+	}
+	return updates
+}
+
+// parseYumSecurityPackages parses `yum updateinfo list security` output, e.g.
+// RHSA-2024:0310 Important/Sec. openssl-libs-1:3.0.7-25.el9_3.x86_64
+// Returns the names of packages with a pending security advisory
+func parseYumSecurityPackages(output string) map[string]bool {
+	packages := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		if name := packageNameFromNEVRA(fields[len(fields)-1]); name != "" {
+			packages[name] = true
+		}
+	}
+	return packages
+}
+
+// packageNameFromNEVRA extracts "openssl-libs" from "openssl-libs-1:3.0.7-25.el9_3.x86_64"
+func packageNameFromNEVRA(nevra string) string {
+	if idx := strings.LastIndex(nevra, "."); idx > 0 {
+		nevra = nevra[:idx] // Strip architecture
+	}
+	for i := 0; i < 2; i++ { // Strip release, then [epoch:]version
+		idx := strings.LastIndex(nevra, "-")
+		if idx <= 0 {
+			return ""
+		}
+		nevra = nevra[:idx]
+	}
+	return nevra
+}
+
+// markSecurityUpdates sets IsSecurityUpdate on updates whose package is in security
+func markSecurityUpdates(updates []models.PackageUpdate, security map[string]bool) {
+	for i := range updates {
+		updates[i].IsSecurityUpdate = security[updates[i].Name]
+	}
+}
+
+// parseApkUpgradable parses `apk list -u` output, e.g.
+// musl-1.2.4-r2 x86_64 {musl} (MIT) [upgradable from: musl-1.2.4-r1]
+func parseApkUpgradable(output string) []models.PackageUpdate {
+	var updates []models.PackageUpdate
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name, available := splitApkPackage(fields[0])
+		if name == "" {
+			continue
+		}
+		update := models.PackageUpdate{
+			Name:             name,
+			AvailableVersion: available,
+		}
+		if idx := strings.Index(line, "upgradable from: "); idx != -1 {
+			_, update.CurrentVersion = splitApkPackage(strings.TrimSuffix(line[idx+len("upgradable from: "):], "]"))
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// splitApkPackage splits "name-1.2.3-r0" into "name" and "1.2.3-r0"
+func splitApkPackage(pkg string) (name, version string) {
+	release := strings.LastIndex(pkg, "-")
+	if release <= 0 {
+		return "", ""
+	}
+	ver := strings.LastIndex(pkg[:release], "-")
+	if ver <= 0 {
+		return "", ""
+	}
+	return pkg[:ver], pkg[ver+1:]
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+
+	"vpsentinel-agent/models"
+)
+
+const yumCheckUpdateOutput = `
+openssl-libs.x86_64                 1:3.0.7-25.el9_3                 baseos
+curl.x86_64                         7.76.1-26.el9_3.3                baseos
+nginx.x86_64                        1:1.20.1-14.el9_2.1              appstream
+Obsoleting Packages
+grub2-tools.x86_64                  1:2.06-70.el9_3.2                baseos
+`
+
+const yumUpdateInfoOutput = `RHSA-2024:0310 Important/Sec. openssl-libs-1:3.0.7-25.el9_3.x86_64
+RHSA-2024:1234 Moderate/Sec.  curl-7.76.1-26.el9_3.3.x86_64
+RHSA-2024:1234 Moderate/Sec.  libcurl-7.76.1-26.el9_3.3.x86_64
+`
+
+func TestParseYumCheckUpdate(t *testing.T) {
+	got := parseYumCheckUpdate(yumCheckUpdateOutput)
+	want := []models.PackageUpdate{
+		{Name: "openssl-libs", AvailableVersion: "1:3.0.7-25.el9_3"},
+		{Name: "curl", AvailableVersion: "7.76.1-26.el9_3.3"},
+		{Name: "nginx", AvailableVersion: "1:1.20.1-14.el9_2.1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseYumCheckUpdate() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseYumSecurityPackages(t *testing.T) {
+	got := parseYumSecurityPackages(yumUpdateInfoOutput)
+	want := map[string]bool{"openssl-libs": true, "curl": true, "libcurl": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseYumSecurityPackages() = %v, want %v", got, want)
+	}
+}
+
+func TestMarkSecurityUpdates(t *testing.T) {
+	// The repository column (baseos, appstream) says nothing about security
+	updates := parseYumCheckUpdate(yumCheckUpdateOutput)
+	markSecurityUpdates(updates, parseYumSecurityPackages(yumUpdateInfoOutput))
+
+	want := map[string]bool{"openssl-libs": true, "curl": true, "nginx": false}
+	for _, u := range updates {
+		if u.IsSecurityUpdate != want[u.Name] {
+			t.Errorf("%s IsSecurityUpdate = %v, want %v", u.Name, u.IsSecurityUpdate, want[u.Name])
+		}
+	}
+}
+
+func TestPackageNameFromNEVRA(t *testing.T) {
+	tests := []struct {
+		nevra string
+		want  string
+	}{
+		{"openssl-libs-1:3.0.7-25.el9_3.x86_64", "openssl-libs"},
+		{"curl-7.76.1-26.el9_3.3.x86_64", "curl"},
+		{"kernel-5.14.0-362.18.1.el9_3.x86_64", "kernel"},
+		{"tzdata-2024a-1.el9.noarch", "tzdata"},
+		{"2024", ""},
+	}
+
+	for _, tt := range tests {
+		if got := packageNameFromNEVRA(tt.nevra); got != tt.want {
+			t.Errorf("packageNameFromNEVRA(%q) = %q, want %q", tt.nevra, got, tt.want)
+		}
+	}
+}
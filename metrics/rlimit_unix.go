@@ -0,0 +1,14 @@
+//go:build !windows
+
+package metrics
+
+import "syscall"
+
+// fdSoftLimit returns the agent's soft limit on open files (ulimit -n)
+func fdSoftLimit() (uint64, error) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return 0, err
+	}
+	return uint64(rlimit.Cur), nil
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package metrics
+
+import "fmt"
+
+// fdSoftLimit is not available on Windows (handles have no per-process ulimit)
+func fdSoftLimit() (uint64, error) {
+	return 0, fmt.Errorf("file descriptor limits are not supported on windows")
+}
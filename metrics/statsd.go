@@ -0,0 +1,216 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/models"
+)
+
+const (
+	// maxStatsDMetrics caps the distinct metric names kept per interval
+	maxStatsDMetrics = 1000
+	// maxStatsDPacket is the largest UDP datagram read
+	maxStatsDPacket = 65535
+)
+
+// statsdAggregate accumulates the samples of one metric during an interval
+type statsdAggregate struct {
+	metricType string
+	value      float64             // Counter sum, last gauge value or timer sum
+	count      int                 // Timer/histogram sample count
+	set        map[string]struct{} // Unique set members
+}
+
+// statsdBindRetryDelays are the waits between attempts to bind a port that is still in use
+// After a restart the previous agent instance holds the port until it has shut down
+var statsdBindRetryDelays = []time.Duration{250 * time.Millisecond, 500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second}
+
+// StatsDCollector receives StatsD metrics over UDP and aggregates them until flushed
+type StatsDCollector struct {
+	conn    net.PacketConn
+	mu      sync.Mutex
+	metrics map[string]*statsdAggregate
+	dropped int // Samples dropped because maxStatsDMetrics was reached
+}
+
+// NewStatsDCollector starts listening for StatsD packets on addr (e.g. "127.0.0.1:8125")
+// A port still in use is retried with backoff for a few seconds
+func NewStatsDCollector(addr string) (*StatsDCollector, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	for _, delay := range statsdBindRetryDelays {
+		if err == nil || !isAddrInUse(err) {
+			break
+		}
+		time.Sleep(delay)
+		conn, err = net.ListenPacket("udp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	c := &StatsDCollector{
+		conn:    conn,
+		metrics: make(map[string]*statsdAggregate),
+	}
+	go c.receive()
+	return c, nil
+}
+
+// wsaEADDRINUSE is the Windows error for an address already in use
+const wsaEADDRINUSE = syscall.Errno(10048)
+
+// isAddrInUse reports whether a bind failed because the address is in use
+func isAddrInUse(err error) bool {
+	var errno syscall.Errno
+	return errors.As(err, &errno) && (errno == syscall.EADDRINUSE || errno == wsaEADDRINUSE)
+}
+
+// receive reads packets until the collector is closed
+func (c *StatsDCollector) receive() {
+	buf := make([]byte, maxStatsDPacket)
+	for {
+		n, _, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logging.Warnf("StatsD listener stopped: %v", err)
+			}
+			return
+		}
+
+		// A packet may hold several newline-separated metrics
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				c.add(line)
+			}
+		}
+	}
+}
+
+// add parses one "name:value|type[|@rate][|#tags]" line and folds it into the aggregates
+func (c *StatsDCollector) add(line string) {
+	name, rest, ok := strings.Cut(line, ":")
+	if !ok || name == "" {
+		return
+	}
+	fields := strings.Split(rest, "|")
+	if len(fields) < 2 {
+		return
+	}
+	rawValue, metricType := fields[0], fields[1]
+
+	sampleRate := 1.0
+	for _, field := range fields[2:] {
+		if rate, ok := strings.CutPrefix(field, "@"); ok {
+			if r, err := strconv.ParseFloat(rate, 64); err == nil && r > 0 && r <= 1 {
+				sampleRate = r
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	agg, exists := c.metrics[name]
+	if exists && agg.metricType != metricType {
+		return // Conflicting type for an existing metric
+	}
+	if !exists {
+		if len(c.metrics) >= maxStatsDMetrics {
+			c.dropped++
+			return
+		}
+		agg = &statsdAggregate{metricType: metricType}
+	}
+
+	switch metricType {
+	case "c":
+		value, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			return
+		}
+		agg.value += value / sampleRate
+	case "g":
+		value, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			return
+		}
+		// A leading sign adjusts the gauge instead of setting it
+		if exists && (strings.HasPrefix(rawValue, "+") || strings.HasPrefix(rawValue, "-")) {
+			agg.value += value
+		} else {
+			agg.value = value
+		}
+	case "ms", "h", "d":
+		value, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			return
+		}
+		agg.value += value
+		agg.count++
+	case "s":
+		if agg.set == nil {
+			agg.set = make(map[string]struct{})
+		}
+		agg.set[rawValue] = struct{}{}
+	default:
+		return
+	}
+
+	c.metrics[name] = agg
+}
+
+// Flush returns the metrics aggregated since the last flush and resets them
+// Counters are summed, gauges keep their last value, timers and histograms
+// report their mean and sets report the number of unique members
+// Gauges are carried over, so a gauge not re-sent during an interval still
+// reports its last value (and +/- adjustments apply to it)
+func (c *StatsDCollector) Flush() []models.StatsDMetric {
+	c.mu.Lock()
+	aggregates := c.metrics
+	dropped := c.dropped
+	c.metrics = make(map[string]*statsdAggregate)
+	for name, agg := range aggregates {
+		if agg.metricType == "g" {
+			c.metrics[name] = &statsdAggregate{metricType: "g", value: agg.value}
+		}
+	}
+	c.dropped = 0
+	c.mu.Unlock()
+
+	if dropped > 0 {
+		logging.Warnf("Dropped %d StatsD samples (more than %d metric names)", dropped, maxStatsDMetrics)
+	}
+
+	result := make([]models.StatsDMetric, 0, len(aggregates))
+	for name, agg := range aggregates {
+		value := agg.value
+		switch agg.metricType {
+		case "ms", "h", "d":
+			value = agg.value / float64(agg.count)
+		case "s":
+			value = float64(len(agg.set))
+		}
+		result = append(result, models.StatsDMetric{
+			Name:  name,
+			Type:  agg.metricType,
+			Value: strconv.FormatFloat(value, 'f', -1, 64),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Close stops the listener
+func (c *StatsDCollector) Close() error {
+	return c.conn.Close()
+}
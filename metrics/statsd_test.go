@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+// newTestStatsD creates a collector without a socket; samples are fed with add
+func newTestStatsD() *StatsDCollector {
+	return &StatsDCollector{metrics: make(map[string]*statsdAggregate)}
+}
+
+func TestStatsDFlush(t *testing.T) {
+	c := newTestStatsD()
+	for _, line := range []string{
+		"requests:1|c", "requests:2|c|@0.5",
+		"queue:5|g", "queue:+3|g",
+		"latency:10|ms", "latency:30|ms",
+		"users:alice|s", "users:bob|s", "users:alice|s",
+		"bad", "requests:1|g", // Malformed and conflicting type
+	} {
+		c.add(line)
+	}
+
+	want := []models.StatsDMetric{
+		{Name: "latency", Type: "ms", Value: "20"},
+		{Name: "queue", Type: "g", Value: "8"},
+		{Name: "requests", Type: "c", Value: "5"},
+		{Name: "users", Type: "s", Value: "2"},
+	}
+	if got := c.Flush(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Flush() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStatsDGaugesKeepLastValue(t *testing.T) {
+	c := newTestStatsD()
+	c.add("queue:5|g")
+	c.add("requests:1|c")
+	c.Flush()
+
+	// Counters reset, gauges keep reporting their last value
+	want := []models.StatsDMetric{{Name: "queue", Type: "g", Value: "5"}}
+	if got := c.Flush(); !reflect.DeepEqual(got, want) {
+		t.Errorf("second Flush() = %+v, want %+v", got, want)
+	}
+
+	// Relative updates apply to the carried-over value
+	c.add("queue:-2|g")
+	want = []models.StatsDMetric{{Name: "queue", Type: "g", Value: "3"}}
+	if got := c.Flush(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Flush() after adjustment = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewStatsDCollectorRetriesBusyPort(t *testing.T) {
+	previous := statsdBindRetryDelays
+	statsdBindRetryDelays = []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond}
+	t.Cleanup(func() { statsdBindRetryDelays = previous })
+
+	busy, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := busy.LocalAddr().String()
+
+	// The previous owner releases the port while the new collector is retrying
+	time.AfterFunc(80*time.Millisecond, func() { busy.Close() })
+
+	c, err := NewStatsDCollector(addr)
+	if err != nil {
+		t.Fatalf("NewStatsDCollector() error = %v", err)
+	}
+	c.Close()
+}
+
+func TestNewStatsDCollectorBusyPort(t *testing.T) {
+	previous := statsdBindRetryDelays
+	statsdBindRetryDelays = []time.Duration{10 * time.Millisecond}
+	t.Cleanup(func() { statsdBindRetryDelays = previous })
+
+	busy, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	if c, err := NewStatsDCollector(busy.LocalAddr().String()); err == nil {
+		c.Close()
+		t.Fatal("NewStatsDCollector() on a busy port succeeded")
+	} else if !isAddrInUse(err) {
+		t.Errorf("NewStatsDCollector() error = %v, want address in use", err)
+	}
+}
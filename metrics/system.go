@@ -1,25 +1,30 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
-	"github.com/shirou/gopsutil/v3/mem"
-	"github.com/shirou/gopsutil/v3/net"
-
 	"vpsentinel-agent/models"
 )
 
 // CollectSystem collects comprehensive system metrics
 // Returns system metrics and any errors encountered (errors are logged but non-fatal)
-func CollectSystem() (models.SystemMetrics, error) {
+// Collection stops early with ctx's error if ctx is cancelled
+func CollectSystem(ctx context.Context) (models.SystemMetrics, error) {
 	var sysMetrics models.SystemMetrics
 	var errs []error
 
+	if err := ctx.Err(); err != nil {
+		return sysMetrics, err
+	}
+
 	// Collect CPU metrics (per-core and aggregate)
-	cpuPercent, cpuPerCore, err := collectCPU()
+	cpuPercent, cpuPerCore, err := collectCPU(ctx)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("CPU collection failed: %w", err))
 		// Continue with zero values
@@ -31,17 +36,26 @@ func CollectSystem() (models.SystemMetrics, error) {
 	}
 
 	// Collect memory metrics
-	memStats, err := mem.VirtualMemory()
+	memStats, err := systemCollector.VirtualMemory(ctx)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("memory collection failed: %w", err))
 	} else {
 		sysMetrics.MemoryUsedMB = memStats.Used / (1024 * 1024)
 		sysMetrics.MemoryTotalMB = memStats.Total / (1024 * 1024)
 		sysMetrics.MemoryPercent = memStats.UsedPercent
+
+		// Available (MemAvailable on Linux) excludes reclaimable page cache,
+		// so it reflects real memory pressure better than UsedPercent
+		sysMetrics.MemoryAvailableMB = memStats.Available / (1024 * 1024)
+		sysMetrics.MemoryCachedMB = memStats.Cached / (1024 * 1024)
+		sysMetrics.MemoryBuffersMB = memStats.Buffers / (1024 * 1024)
+		if memStats.Total > 0 {
+			sysMetrics.MemoryAvailablePercent = float64(memStats.Available) / float64(memStats.Total) * 100
+		}
 	}
 
 	// Collect swap metrics (if available)
-	swapStats, err := mem.SwapMemory()
+	swapStats, err := systemCollector.SwapMemory(ctx)
 	if err == nil {
 		sysMetrics.SwapUsedMB = swapStats.Used / (1024 * 1024)
 		sysMetrics.SwapTotalMB = swapStats.Total / (1024 * 1024)
@@ -49,16 +63,18 @@ func CollectSystem() (models.SystemMetrics, error) {
 	}
 	// Swap errors are non-fatal (system may not have swap)
 
-	// Collect disk usage per mount point
-	diskUsage, err := collectDiskUsage()
+	// Collect disk usage and mount options per mount point
+	diskUsage, mountOptions, disks, err := collectDiskUsage(ctx)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("disk collection failed: %w", err))
 		diskUsage = make(map[string]float64)
 	}
 	sysMetrics.DiskUsage = diskUsage
+	sysMetrics.MountOptions = mountOptions
+	sysMetrics.Disks = disks
 
 	// Collect network I/O statistics
-	networkRX, networkTX, err := collectNetworkIO()
+	networkRX, networkTX, err := collectNetworkIO(ctx)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("network collection failed: %w", err))
 		networkRX = 0
@@ -67,6 +83,27 @@ func CollectSystem() (models.SystemMetrics, error) {
 	sysMetrics.NetworkRXMB = networkRX
 	sysMetrics.NetworkTXMB = networkTX
 
+	// Collect file descriptor usage (non-fatal, system-wide values are Linux only)
+	openFDs, maxFDs, err := collectSystemFileDescriptors()
+	if err == nil {
+		sysMetrics.OpenFileDescriptors = openFDs
+		sysMetrics.MaxFileDescriptors = maxFDs
+	}
+	agentFDs, agentMaxFDs, err := collectAgentFileDescriptors()
+	if err == nil {
+		sysMetrics.AgentOpenFileDescriptors = agentFDs
+		sysMetrics.AgentMaxFileDescriptors = agentMaxFDs
+	}
+
+	// Collect per-user process breakdown (non-fatal)
+	userStats, err := collectUserProcessStats(ctx)
+	if err == nil {
+		sysMetrics.UserStats = userStats
+	}
+
+	// Collect CPU vulnerability mitigation status (empty outside Linux)
+	sysMetrics.CPUVulnerabilities, _ = CheckCPUVulnerabilities()
+
 	// Return first error if any occurred (but still return partial data)
 	if len(errs) > 0 {
 		return sysMetrics, errs[0]
@@ -76,15 +113,15 @@ func CollectSystem() (models.SystemMetrics, error) {
 }
 
 // collectCPU collects CPU usage percentage for all cores and aggregate
-func collectCPU() (float64, []float64, error) {
+func collectCPU(ctx context.Context) (float64, []float64, error) {
 	// Get per-core CPU usage (1 second interval for accuracy)
-	perCore, err := cpu.Percent(1*time.Second, true)
+	perCore, err := systemCollector.CPUPercent(ctx, 1*time.Second, true)
 	if err != nil {
 		return 0.0, nil, err
 	}
 
 	// Get aggregate CPU usage
-	aggregate, err := cpu.Percent(1*time.Second, false)
+	aggregate, err := systemCollector.CPUPercent(ctx, 1*time.Second, false)
 	if err != nil {
 		return 0.0, perCore, err
 	}
@@ -97,21 +134,81 @@ func collectCPU() (float64, []float64, error) {
 	return aggPercent, perCore, nil
 }
 
-// collectDiskUsage collects disk usage for all mounted filesystems
-func collectDiskUsage() (map[string]float64, error) {
-	partitions, err := disk.Partitions(false)
+// pseudoFilesystems are kernel filesystems whose mount options aren't worth reporting
+var pseudoFilesystems = map[string]bool{
+	"proc": true, "sysfs": true, "devtmpfs": true, "devpts": true, "cgroup": true,
+	"cgroup2": true, "securityfs": true, "debugfs": true, "tracefs": true, "pstore": true,
+	"bpf": true, "configfs": true, "fusectl": true, "mqueue": true, "hugetlbfs": true,
+	"binfmt_misc": true, "autofs": true, "rpc_pipefs": true, "nsfs": true, "efivarfs": true,
+}
+
+// DefaultExcludeMountPoints are mount points left out of disk metrics by default
+var DefaultExcludeMountPoints = []string{"/boot/efi", "/snap/*"}
+
+// DefaultExcludeFSTypes are filesystem types left out of disk usage by default
+var DefaultExcludeFSTypes = []string{"proc", "sysfs", "devtmpfs", "tmpfs", "squashfs"}
+
+// diskFilters holds the configured disk exclusions
+type diskFilters struct {
+	mountPoints []string
+	fsTypes     map[string]bool
+}
+
+// configuredDiskFilters holds the current diskFilters
+var configuredDiskFilters atomic.Value
+
+// SetDiskFilters sets the mount point patterns and filesystem types excluded from disk metrics
+func SetDiskFilters(mountPoints, fsTypes []string) {
+	filters := diskFilters{mountPoints: mountPoints, fsTypes: make(map[string]bool, len(fsTypes))}
+	for _, fsType := range fsTypes {
+		filters.fsTypes[fsType] = true
+	}
+	configuredDiskFilters.Store(filters)
+}
+
+// currentDiskFilters returns the configured exclusions, or the defaults if none were set
+func currentDiskFilters() diskFilters {
+	if filters, ok := configuredDiskFilters.Load().(diskFilters); ok {
+		return filters
+	}
+	SetDiskFilters(DefaultExcludeMountPoints, DefaultExcludeFSTypes)
+	return configuredDiskFilters.Load().(diskFilters)
+}
+
+// isMountExcluded matches a mount point against glob patterns
+// A trailing "/*" also matches everything nested below (e.g. /snap/core/123)
+func isMountExcluded(mountPoint string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, mountPoint); err == nil && matched {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasSuffix(prefix, "/") && strings.HasPrefix(mountPoint, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectDiskUsage collects disk usage and mount options for all mounted filesystems
+// Usage covers physical devices only; mount options also cover virtual
+// filesystems such as tmpfs (e.g. /tmp should be noexec)
+// Excluded mount points are left out of both, excluded filesystem types only out of usage
+func collectDiskUsage(ctx context.Context) (map[string]float64, map[string][]string, []models.DiskDetail, error) {
+	partitions, err := systemCollector.Partitions(ctx, false)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
+	filters := currentDiskFilters()
+
 	usage := make(map[string]float64)
 	for _, partition := range partitions {
-		// Skip virtual filesystems on Linux
-		if partition.Fstype == "proc" || partition.Fstype == "sysfs" || partition.Fstype == "devtmpfs" {
+		// Skip virtual filesystems and excluded mount points
+		if filters.fsTypes[partition.Fstype] || isMountExcluded(partition.Mountpoint, filters.mountPoints) {
 			continue
 		}
 
-		diskUsage, err := disk.Usage(partition.Mountpoint)
+		diskUsage, err := systemCollector.DiskUsage(ctx, partition.Mountpoint)
 		if err != nil {
 			// Skip mount points that can't be accessed (permissions, etc.)
 			continue
@@ -120,21 +217,141 @@ func collectDiskUsage() (map[string]float64, error) {
 		usage[partition.Mountpoint] = diskUsage.UsedPercent
 	}
 
-	return usage, nil
+	// Mount options are best effort, usage data is still useful without them
+	options := make(map[string][]string)
+	var details []models.DiskDetail
+	allPartitions, err := systemCollector.Partitions(ctx, true)
+	if err != nil {
+		return usage, options, details, nil
+	}
+	for _, partition := range allPartitions {
+		if pseudoFilesystems[partition.Fstype] || isMountExcluded(partition.Mountpoint, filters.mountPoints) {
+			continue
+		}
+
+		options[partition.Mountpoint] = partition.Opts
+		detail := models.DiskDetail{
+			MountPoint: partition.Mountpoint,
+			Fstype:     partition.Fstype,
+		}
+		for _, opt := range partition.Opts {
+			switch opt {
+			case "ro":
+				detail.ReadOnly = true
+			case "noexec":
+				detail.NoExec = true
+			case "nosuid":
+				detail.NoSuid = true
+			}
+		}
+		if partUsage, err := systemCollector.DiskUsage(ctx, partition.Mountpoint); err == nil {
+			detail.UsedBytes = partUsage.Used
+		}
+		details = append(details, detail)
+	}
+
+	return usage, options, details, nil
+}
+
+// diskTrendThreshold is the change in used bytes between cycles that counts as a trend
+const diskTrendThreshold = 10 * 1024 * 1024
+
+// DiskTrendTracker remembers used bytes per mount point between collection cycles
+// CollectSystem doesn't track trends itself, so on-demand collections (commands)
+// can't disturb the cycle-to-cycle comparison
+type DiskTrendTracker struct {
+	mu       sync.Mutex
+	previous map[string]uint64
+}
+
+// NewDiskTrendTracker creates a tracker with no previous cycle
+func NewDiskTrendTracker() *DiskTrendTracker {
+	return &DiskTrendTracker{previous: make(map[string]uint64)}
+}
+
+// Update sets the trend of each disk against the previous cycle and records
+// the current usage as the new baseline (call once per scheduled cycle)
+func (t *DiskTrendTracker) Update(disks []models.DiskDetail) {
+	t.apply(disks, true)
+}
+
+// Compare sets the trend of each disk against the previous cycle without recording anything
+func (t *DiskTrendTracker) Compare(disks []models.DiskDetail) {
+	t.apply(disks, false)
+}
+
+func (t *DiskTrendTracker) apply(disks []models.DiskDetail, record bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range disks {
+		disk := &disks[i]
+		if disk.UsedBytes == 0 {
+			continue // Usage couldn't be read
+		}
+		previous, ok := t.previous[disk.MountPoint]
+		if record {
+			t.previous[disk.MountPoint] = disk.UsedBytes
+		}
+		disk.UsedBytesDelta, disk.TrendingDirection = diskTrend(previous, ok, disk.UsedBytes)
+	}
+}
+
+// diskTrend compares used bytes with the previous cycle
+// Returns the delta and "up", "down" or "stable" (stable on the first cycle)
+func diskTrend(previous uint64, hasPrevious bool, used uint64) (int64, string) {
+	if !hasPrevious {
+		return 0, "stable"
+	}
+
+	delta := int64(used) - int64(previous)
+	switch {
+	case delta > diskTrendThreshold:
+		return delta, "up"
+	case delta < -diskTrendThreshold:
+		return delta, "down"
+	default:
+		return delta, "stable"
+	}
+}
+
+// DefaultExcludeNetworkInterfaces are interfaces left out of network stats by default
+var DefaultExcludeNetworkInterfaces = []string{"lo", "lo0", "docker0", "br-*", "veth*"}
+
+// configuredInterfaceFilters holds the interface name patterns currently excluded
+var configuredInterfaceFilters atomic.Value
+
+// SetInterfaceFilters sets the interface name globs excluded from network stats
+func SetInterfaceFilters(patterns []string) {
+	configuredInterfaceFilters.Store(patterns)
+}
+
+// IsInterfaceExcluded reports whether an interface matches a configured exclusion
+func IsInterfaceExcluded(name string) bool {
+	patterns, ok := configuredInterfaceFilters.Load().([]string)
+	if !ok {
+		patterns = DefaultExcludeNetworkInterfaces
+	}
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
 }
 
 // collectNetworkIO collects network I/O statistics
 // Returns RX and TX in MB, aggregated across all interfaces
-func collectNetworkIO() (uint64, uint64, error) {
-	netIO, err := net.IOCounters(true) // true = per interface
+func collectNetworkIO(ctx context.Context) (uint64, uint64, error) {
+	netIO, err := systemCollector.NetIOCounters(ctx, true) // true = per interface
 	if err != nil {
 		return 0, 0, err
 	}
 
 	var totalRX, totalTX uint64
 	for _, io := range netIO {
-		// Skip loopback interface
-		if io.Name == "lo" || io.Name == "lo0" {
+		// Skip loopback and excluded interfaces (docker0, veth*, ...)
+		if IsInterfaceExcluded(io.Name) {
 			continue
 		}
 		totalRX += io.BytesRecv
@@ -0,0 +1,293 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+
+	"vpsentinel-agent/models"
+)
+
+func TestCollectSystemCancelledContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"cancelled", cancelled, context.Canceled},
+		{"deadline exceeded", expired, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := CollectSystem(tt.ctx)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("CollectSystem() error = %v, want %v", err, tt.want)
+			}
+			// CPU sampling alone takes 2 seconds; a cancelled context must skip it
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("CollectSystem() took %v with a cancelled context", elapsed)
+			}
+		})
+	}
+}
+
+// MockCollector is a SystemCollector returning fixed statistics or errors
+type MockCollector struct {
+	CPU        []float64
+	CPUErr     error
+	Memory     *mem.VirtualMemoryStat
+	MemoryErr  error
+	Parts      []disk.PartitionStat
+	PartErr    error
+	Usage      map[string]*disk.UsageStat
+	UsageErr   map[string]error // Per mount point
+	Network    []net.IOCountersStat
+	NetworkErr error
+}
+
+func (m *MockCollector) CPUPercent(ctx context.Context, interval time.Duration, perCPU bool) ([]float64, error) {
+	if m.CPUErr != nil {
+		return nil, m.CPUErr
+	}
+	if perCPU {
+		return m.CPU, nil
+	}
+	total := 0.0
+	for _, p := range m.CPU {
+		total += p
+	}
+	return []float64{total / float64(len(m.CPU))}, nil
+}
+
+func (m *MockCollector) VirtualMemory(ctx context.Context) (*mem.VirtualMemoryStat, error) {
+	return m.Memory, m.MemoryErr
+}
+
+func (m *MockCollector) SwapMemory(ctx context.Context) (*mem.SwapMemoryStat, error) {
+	return &mem.SwapMemoryStat{}, nil
+}
+
+func (m *MockCollector) Partitions(ctx context.Context, all bool) ([]disk.PartitionStat, error) {
+	return m.Parts, m.PartErr
+}
+
+func (m *MockCollector) DiskUsage(ctx context.Context, path string) (*disk.UsageStat, error) {
+	if err := m.UsageErr[path]; err != nil {
+		return nil, err
+	}
+	return m.Usage[path], nil
+}
+
+func (m *MockCollector) NetIOCounters(ctx context.Context, perNIC bool) ([]net.IOCountersStat, error) {
+	return m.Network, m.NetworkErr
+}
+
+// newHealthyCollector returns a MockCollector describing a host where every read succeeds
+func newHealthyCollector() *MockCollector {
+	return &MockCollector{
+		CPU: []float64{20, 40},
+		Memory: &mem.VirtualMemoryStat{
+			Total:       4096 * 1024 * 1024,
+			Used:        1024 * 1024 * 1024,
+			Available:   3072 * 1024 * 1024,
+			UsedPercent: 25,
+		},
+		Parts: []disk.PartitionStat{
+			{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4", Opts: []string{"rw"}},
+			{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs", Opts: []string{"rw", "noexec"}},
+		},
+		Usage: map[string]*disk.UsageStat{
+			"/":     {Path: "/", UsedPercent: 50, Used: 10 * 1024 * 1024 * 1024},
+			"/data": {Path: "/data", UsedPercent: 75, Used: 30 * 1024 * 1024 * 1024},
+		},
+		Network: []net.IOCountersStat{
+			{Name: "eth0", BytesRecv: 300 * 1024 * 1024, BytesSent: 100 * 1024 * 1024},
+			{Name: "lo", BytesRecv: 900 * 1024 * 1024, BytesSent: 900 * 1024 * 1024},
+		},
+	}
+}
+
+func TestCollectSystemPartialFailures(t *testing.T) {
+	errFailed := errors.New("read failed")
+
+	tests := []struct {
+		name      string
+		breakHost func(m *MockCollector)
+		wantErr   string // Prefix of the returned error, empty for success
+		check     func(t *testing.T, got models.SystemMetrics)
+	}{
+		{
+			name: "healthy",
+			check: func(t *testing.T, got models.SystemMetrics) {
+				if got.CPUPercent != 30 || len(got.CPUPerCore) != 2 {
+					t.Errorf("CPU = %v %v, want 30 [20 40]", got.CPUPercent, got.CPUPerCore)
+				}
+				if got.MemoryUsedMB != 1024 || got.MemoryTotalMB != 4096 {
+					t.Errorf("memory = %d/%d MB, want 1024/4096", got.MemoryUsedMB, got.MemoryTotalMB)
+				}
+				if got.NetworkRXMB != 300 || got.NetworkTXMB != 100 {
+					t.Errorf("network = %d/%d MB, want 300/100 (loopback excluded)", got.NetworkRXMB, got.NetworkTXMB)
+				}
+			},
+		},
+		{
+			name:      "CPU failure",
+			breakHost: func(m *MockCollector) { m.CPUErr = errFailed },
+			wantErr:   "CPU collection failed",
+			check: func(t *testing.T, got models.SystemMetrics) {
+				if got.CPUPercent != 0 || len(got.CPUPerCore) != 0 {
+					t.Errorf("CPU = %v %v, want zero values", got.CPUPercent, got.CPUPerCore)
+				}
+				// The rest is still collected
+				if got.MemoryUsedMB != 1024 {
+					t.Errorf("memory used = %d MB, want 1024", got.MemoryUsedMB)
+				}
+				if got.DiskUsage["/"] != 50 || got.NetworkRXMB != 300 {
+					t.Errorf("disk/network = %v/%d, want partial result", got.DiskUsage, got.NetworkRXMB)
+				}
+			},
+		},
+		{
+			name:      "memory failure",
+			breakHost: func(m *MockCollector) { m.MemoryErr = errFailed },
+			wantErr:   "memory collection failed",
+			check: func(t *testing.T, got models.SystemMetrics) {
+				if got.MemoryUsedMB != 0 || got.MemoryTotalMB != 0 || got.MemoryPercent != 0 {
+					t.Errorf("memory = %d/%d MB, want zero values", got.MemoryUsedMB, got.MemoryTotalMB)
+				}
+				if got.CPUPercent != 30 || got.DiskUsage["/data"] != 75 {
+					t.Errorf("CPU/disk = %v/%v, want partial result", got.CPUPercent, got.DiskUsage)
+				}
+			},
+		},
+		{
+			name: "disk failure on one partition",
+			breakHost: func(m *MockCollector) {
+				m.UsageErr = map[string]error{"/": errFailed}
+			},
+			check: func(t *testing.T, got models.SystemMetrics) {
+				if _, ok := got.DiskUsage["/"]; ok {
+					t.Errorf("disk usage = %v, want / skipped", got.DiskUsage)
+				}
+				if got.DiskUsage["/data"] != 75 {
+					t.Errorf("disk usage = %v, want /data at 75%%", got.DiskUsage)
+				}
+				if len(got.Disks) != 2 || got.Disks[1].UsedBytes == 0 || !got.Disks[1].NoExec {
+					t.Errorf("disks = %+v, want /data details", got.Disks)
+				}
+			},
+		},
+		{
+			name:      "disk failure listing partitions",
+			breakHost: func(m *MockCollector) { m.PartErr = errFailed },
+			wantErr:   "disk collection failed",
+			check: func(t *testing.T, got models.SystemMetrics) {
+				if got.DiskUsage == nil || len(got.DiskUsage) != 0 {
+					t.Errorf("disk usage = %v, want empty map", got.DiskUsage)
+				}
+				if got.NetworkRXMB != 300 {
+					t.Errorf("network RX = %d MB, want 300", got.NetworkRXMB)
+				}
+			},
+		},
+		{
+			name:      "network failure",
+			breakHost: func(m *MockCollector) { m.NetworkErr = errFailed },
+			wantErr:   "network collection failed",
+			check: func(t *testing.T, got models.SystemMetrics) {
+				if got.NetworkRXMB != 0 || got.NetworkTXMB != 0 {
+					t.Errorf("network = %d/%d MB, want zero values", got.NetworkRXMB, got.NetworkTXMB)
+				}
+				if got.CPUPercent != 30 || got.MemoryUsedMB != 1024 {
+					t.Errorf("CPU/memory = %v/%d, want partial result", got.CPUPercent, got.MemoryUsedMB)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := newHealthyCollector()
+			if tt.breakHost != nil {
+				tt.breakHost(collector)
+			}
+			SetSystemCollector(collector)
+			t.Cleanup(func() { SetSystemCollector(GopsutilCollector{}) })
+
+			got, err := CollectSystem(context.Background())
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("CollectSystem() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)):
+				t.Errorf("CollectSystem() error = %v, want %q", err, tt.wantErr)
+			case tt.wantErr != "" && !errors.Is(err, errFailed):
+				t.Errorf("CollectSystem() error = %v, want it to wrap %v", err, errFailed)
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestDiskTrendTracker(t *testing.T) {
+	const mb = 1024 * 1024
+	disks := func(used uint64) []models.DiskDetail {
+		return []models.DiskDetail{{MountPoint: "/", UsedBytes: used}}
+	}
+
+	tracker := NewDiskTrendTracker()
+
+	// First cycle has nothing to compare against
+	first := disks(100 * mb)
+	tracker.Update(first)
+	if first[0].UsedBytesDelta != 0 || first[0].TrendingDirection != "stable" {
+		t.Errorf("first cycle = %d %q, want 0 stable", first[0].UsedBytesDelta, first[0].TrendingDirection)
+	}
+
+	// On-demand collections compare against the last scheduled cycle...
+	for i := 0; i < 3; i++ {
+		snapshot := disks(150 * mb)
+		tracker.Compare(snapshot)
+		if snapshot[0].UsedBytesDelta != 50*mb || snapshot[0].TrendingDirection != "up" {
+			t.Errorf("snapshot %d = %d %q, want +50MB up", i, snapshot[0].UsedBytesDelta, snapshot[0].TrendingDirection)
+		}
+	}
+
+	// ...without replacing it
+	second := disks(105 * mb)
+	tracker.Update(second)
+	if second[0].UsedBytesDelta != 5*mb || second[0].TrendingDirection != "stable" {
+		t.Errorf("second cycle = %d %q, want +5MB stable", second[0].UsedBytesDelta, second[0].TrendingDirection)
+	}
+
+	third := disks(80 * mb)
+	tracker.Update(third)
+	if third[0].UsedBytesDelta != -25*mb || third[0].TrendingDirection != "down" {
+		t.Errorf("third cycle = %d %q, want -25MB down", third[0].UsedBytesDelta, third[0].TrendingDirection)
+	}
+
+	// Unreadable usage is neither reported nor recorded
+	unreadable := disks(0)
+	tracker.Update(unreadable)
+	if unreadable[0].TrendingDirection != "" {
+		t.Errorf("unreadable disk trend = %q, want none", unreadable[0].TrendingDirection)
+	}
+	fourth := disks(80 * mb)
+	tracker.Update(fourth)
+	if fourth[0].UsedBytesDelta != 0 || fourth[0].TrendingDirection != "stable" {
+		t.Errorf("fourth cycle = %d %q, want 0 stable", fourth[0].UsedBytesDelta, fourth[0].TrendingDirection)
+	}
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"vpsentinel-agent/models"
+)
+
+// maxUserStats caps the number of users reported (those with the most processes)
+const maxUserStats = 20
+
+// CollectUserProcessStats groups running processes by owner
+// CPU is each process's average since it started, summed per user
+func CollectUserProcessStats() (map[string]models.UserProcessStats, error) {
+	return collectUserProcessStats(context.Background())
+}
+
+// collectUserProcessStats is CollectUserProcessStats with cancellation
+func collectUserProcessStats(ctx context.Context) (map[string]models.UserProcessStats, error) {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []userProcessSample
+	for _, p := range procs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		username, err := p.UsernameWithContext(ctx)
+		if err != nil {
+			continue // Process exited or owner can't be resolved
+		}
+
+		sample := userProcessSample{username: username}
+		if cpuPercent, err := p.CPUPercentWithContext(ctx); err == nil {
+			sample.cpuPercent = cpuPercent
+		}
+		if mem, err := p.MemoryInfoWithContext(ctx); err == nil {
+			sample.rssBytes = mem.RSS
+		}
+		samples = append(samples, sample)
+	}
+
+	return topUserStats(aggregateUserStats(samples)), nil
+}
+
+// userProcessSample is the usage of one process
+type userProcessSample struct {
+	username   string
+	cpuPercent float64
+	rssBytes   uint64
+}
+
+// aggregateUserStats sums process usage per user
+// Memory is summed in bytes and converted once, so small processes aren't rounded down to 0 MB each
+func aggregateUserStats(samples []userProcessSample) map[string]models.UserProcessStats {
+	stats := make(map[string]models.UserProcessStats)
+	rssBytes := make(map[string]uint64)
+	for _, sample := range samples {
+		userStats := stats[sample.username]
+		userStats.ProcessCount++
+		userStats.CPUPercent += sample.cpuPercent
+		stats[sample.username] = userStats
+		rssBytes[sample.username] += sample.rssBytes
+	}
+	for username, userStats := range stats {
+		userStats.MemoryMB = rssBytes[username] / (1024 * 1024)
+		stats[username] = userStats
+	}
+	return stats
+}
+
+// topUserStats keeps the maxUserStats users with the most processes
+func topUserStats(stats map[string]models.UserProcessStats) map[string]models.UserProcessStats {
+	if len(stats) <= maxUserStats {
+		return stats
+	}
+
+	// Keep the users with the most processes
+	users := make([]string, 0, len(stats))
+	for username := range stats {
+		users = append(users, username)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if stats[users[i]].ProcessCount != stats[users[j]].ProcessCount {
+			return stats[users[i]].ProcessCount > stats[users[j]].ProcessCount
+		}
+		return users[i] < users[j]
+	})
+
+	top := make(map[string]models.UserProcessStats, maxUserStats)
+	for _, username := range users[:maxUserStats] {
+		top[username] = stats[username]
+	}
+	return top
+}
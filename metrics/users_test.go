@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAggregateUserStats(t *testing.T) {
+	// 1500 processes of 700 KB each are about 1025 MB together, though each is under 1 MB
+	var samples []userProcessSample
+	for i := 0; i < 1500; i++ {
+		samples = append(samples, userProcessSample{username: "www-data", cpuPercent: 0.5, rssBytes: 700 * 1024})
+	}
+	samples = append(samples,
+		userProcessSample{username: "root", cpuPercent: 2, rssBytes: 3 * 1024 * 1024 / 2},
+		userProcessSample{username: "root", cpuPercent: 1, rssBytes: 3 * 1024 * 1024 / 2},
+	)
+
+	stats := aggregateUserStats(samples)
+
+	www := stats["www-data"]
+	if www.ProcessCount != 1500 || www.CPUPercent != 750 || www.MemoryMB != 1025 {
+		t.Errorf("www-data = %+v, want 1500 processes, 750%% CPU, 1025 MB", www)
+	}
+	root := stats["root"]
+	if root.ProcessCount != 2 || root.CPUPercent != 3 || root.MemoryMB != 3 {
+		t.Errorf("root = %+v, want 2 processes, 3%% CPU, 3 MB", root)
+	}
+}
+
+func TestTopUserStats(t *testing.T) {
+	var samples []userProcessSample
+	for user := 0; user < maxUserStats+5; user++ {
+		for i := 0; i <= user; i++ {
+			samples = append(samples, userProcessSample{username: fmt.Sprintf("user%02d", user)})
+		}
+	}
+
+	top := topUserStats(aggregateUserStats(samples))
+	if len(top) != maxUserStats {
+		t.Fatalf("topUserStats() kept %d users, want %d", len(top), maxUserStats)
+	}
+	// The five users with the fewest processes are dropped
+	for user := 0; user < 5; user++ {
+		if _, ok := top[fmt.Sprintf("user%02d", user)]; ok {
+			t.Errorf("user%02d kept, want it dropped", user)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cpuVulnerabilitiesDir is where Linux reports CPU vulnerability mitigation status
+const cpuVulnerabilitiesDir = "/sys/devices/system/cpu/vulnerabilities"
+
+// CheckCPUVulnerabilities returns the kernel's mitigation status per CPU vulnerability
+// (e.g. "spectre_v2": "Mitigation: Retpolines"). Systems without the sysfs
+// directory (non-Linux, old kernels) return an empty map
+func CheckCPUVulnerabilities() (map[string]string, error) {
+	vulnerabilities := make(map[string]string)
+
+	entries, err := os.ReadDir(cpuVulnerabilitiesDir)
+	if err != nil {
+		return vulnerabilities, nil
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(cpuVulnerabilitiesDir, entry.Name()))
+		if err != nil {
+			continue // Unreadable entries are skipped
+		}
+		vulnerabilities[entry.Name()] = strings.TrimSpace(string(data))
+	}
+
+	return vulnerabilities, nil
+}
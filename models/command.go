@@ -2,7 +2,7 @@ package models
 
 // Command represents a command sent from the backend to the agent
 type Command struct {
-	Type    string                 `json:"type"`    // "stop", "restart", "update_config", "ping"
+	Type    string                 `json:"type"`    // "stop", "restart", "update_config", "ping", "get_environment", "create_file", "rotate_api_key", "benchmark", "tcpdump_capture", "get_metrics_history", "set_log_level", "generate_report", "get_network_stats", "send_test_payload", "compare_file", "restart_service", "scan_ports", "get_open_files", "show_config", "truncate_log", "check_cert_renewal", "get_metrics_snapshot", "soft_reload", "get_dmesg", "sysctl_get", "test_ssl", "check_updates", "kill_process"
 	ID      string                 `json:"id"`      // Command ID for tracking
 	Payload map[string]interface{} `json:"payload"` // Command-specific payload
 }
@@ -10,6 +10,24 @@ type Command struct {
 // CommandResponse represents the agent's response to a command
 type CommandResponse struct {
 	CommandID string `json:"command_id"`
-	Status    string `json:"status"` // "success", "error", "processing"
+	Status    string `json:"status"` // "success", "error", "timeout", "processing"
 	Message   string `json:"message,omitempty"`
 }
+
+// OpenFileInfo represents an open file descriptor of a process
+type OpenFileInfo struct {
+	FD   string `json:"fd"`
+	Path string `json:"path"` // Link target (e.g. "/var/log/app.log" or "socket:[12345]")
+	Type string `json:"type"` // "file", "socket", "pipe" or "other"
+}
+
+// CertRenewalCheck represents the ACME renewal readiness of a domain
+type CertRenewalCheck struct {
+	Domain         string `json:"domain"`
+	DaysLeft       int    `json:"days_left"`
+	SSLError       string `json:"ssl_error,omitempty"` // Why the certificate couldn't be checked
+	HTTPReachable  bool   `json:"http_reachable"`      // The HTTP-01 challenge path answered with 404
+	HTTPStatusCode int    `json:"http_status_code,omitempty"`
+	HTTPError      string `json:"http_error,omitempty"`
+	NeedsRenewal   bool   `json:"needs_renewal"` // Expires within the threshold (or couldn't be checked)
+}
@@ -4,62 +4,275 @@ import "time"
 
 // SystemMetrics represents collected system performance metrics
 type SystemMetrics struct {
-	CPUPercent   float64            `json:"cpu_percent"`   // Overall CPU usage percentage
-	CPUPerCore   []float64          `json:"cpu_per_core"`  // CPU usage per core
-	MemoryUsedMB uint64             `json:"memory_used_mb"`
-	MemoryTotalMB uint64            `json:"memory_total_mb"`
-	MemoryPercent float64           `json:"memory_percent"`
-	SwapUsedMB   uint64             `json:"swap_used_mb,omitempty"`
-	SwapTotalMB  uint64             `json:"swap_total_mb,omitempty"`
-	SwapPercent  float64            `json:"swap_percent,omitempty"`
-	DiskUsage    map[string]float64 `json:"disk_usage"`    // Mount point -> usage percentage
-	NetworkRXMB  uint64             `json:"network_rx_mb"` // Received data in MB
-	NetworkTXMB  uint64             `json:"network_tx_mb"` // Transmitted data in MB
+	CPUPercent               float64                     `json:"cpu_percent"`  // Overall CPU usage percentage
+	CPUPerCore               []float64                   `json:"cpu_per_core"` // CPU usage per core
+	MemoryUsedMB             uint64                      `json:"memory_used_mb"`
+	MemoryTotalMB            uint64                      `json:"memory_total_mb"`
+	MemoryPercent            float64                     `json:"memory_percent"`
+	MemoryAvailableMB        uint64                      `json:"memory_available_mb"`      // Memory available without swapping (MemAvailable)
+	MemoryAvailablePercent   float64                     `json:"memory_available_percent"` // MemoryAvailableMB / MemoryTotalMB * 100
+	MemoryCachedMB           uint64                      `json:"memory_cached_mb"`         // Page cache (reclaimable)
+	MemoryBuffersMB          uint64                      `json:"memory_buffers_mb"`        // Kernel buffers
+	SwapUsedMB               uint64                      `json:"swap_used_mb,omitempty"`
+	SwapTotalMB              uint64                      `json:"swap_total_mb,omitempty"`
+	SwapPercent              float64                     `json:"swap_percent,omitempty"`
+	DiskUsage                map[string]float64          `json:"disk_usage"`                            // Mount point -> usage percentage
+	MountOptions             map[string][]string         `json:"mount_options,omitempty"`               // Mount point -> mount options
+	Disks                    []DiskDetail                `json:"disks,omitempty"`                       // Per-mount security-relevant flags
+	NetworkRXMB              uint64                      `json:"network_rx_mb"`                         // Received data in MB
+	NetworkTXMB              uint64                      `json:"network_tx_mb"`                         // Transmitted data in MB
+	OpenFileDescriptors      uint64                      `json:"open_file_descriptors,omitempty"`       // System-wide allocated file descriptors (Linux only)
+	MaxFileDescriptors       uint64                      `json:"max_file_descriptors,omitempty"`        // System-wide maximum (Linux only)
+	AgentOpenFileDescriptors uint64                      `json:"agent_open_file_descriptors,omitempty"` // Held by the agent process
+	AgentMaxFileDescriptors  uint64                      `json:"agent_max_file_descriptors,omitempty"`  // Agent soft limit (ulimit -n, not on Windows)
+	UserStats                map[string]UserProcessStats `json:"user_stats,omitempty"`                  // Username -> process usage (top 20 by process count)
+	CPUVulnerabilities       map[string]string           `json:"cpu_vulnerabilities,omitempty"`         // Vulnerability -> kernel mitigation status (Linux only)
+}
+
+// SystemFingerprint represents the hardware identity of the host
+// A changed FingerprintHash indicates a migration or resize
+type SystemFingerprint struct {
+	CPUModel        string   `json:"cpu_model"`
+	CPUCores        int      `json:"cpu_cores"` // Logical cores
+	MemoryTotalMB   uint64   `json:"memory_total_mb"`
+	DiskDevices     []string `json:"disk_devices"`     // Block devices of mounted filesystems
+	MacAddresses    []string `json:"mac_addresses"`    // Physical interfaces only
+	FingerprintHash string   `json:"fingerprint_hash"` // SHA-256 of the fields above
+}
+
+// UserProcessStats represents the combined processes of one user
+type UserProcessStats struct {
+	ProcessCount int     `json:"process_count"`
+	CPUPercent   float64 `json:"cpu_percent"` // Sum of per-process average CPU usage
+	MemoryMB     uint64  `json:"memory_mb"`   // Sum of resident memory
+}
+
+// DiskDetail represents security-relevant flags of a mounted filesystem
+type DiskDetail struct {
+	MountPoint        string `json:"mount_point"`
+	Fstype            string `json:"fstype"`
+	ReadOnly          bool   `json:"read_only"` // Mounted "ro" (unexpected on data volumes)
+	NoExec            bool   `json:"no_exec"`   // Mounted "noexec" (expected on /tmp)
+	NoSuid            bool   `json:"no_suid"`   // Mounted "nosuid"
+	UsedBytes         uint64 `json:"used_bytes"`
+	UsedBytesDelta    int64  `json:"used_bytes_delta"`   // Change in used bytes since the previous cycle
+	TrendingDirection string `json:"trending_direction"` // "up" or "down" (more than 10 MB change) or "stable"
+}
+
+// LVInfo represents an LVM logical volume
+type LVInfo struct {
+	LVName      string  `json:"lv_name"`
+	VGName      string  `json:"vg_name"`
+	SizeBytes   uint64  `json:"size_bytes"`
+	DataPercent float64 `json:"data_percent,omitempty"` // Thin pool/volume data usage (a full thin pool corrupts data)
+	IsActive    bool    `json:"is_active"`
 }
 
 // PortInfo represents information about an open network port
 type PortInfo struct {
-	Protocol    string `json:"protocol"`     // "tcp" or "udp"
-	Port        int    `json:"port"`         // Port number
-	Process     string `json:"process"`      // Process name or "unknown"
-	PID         int    `json:"pid,omitempty"` // Process ID if available
-	ServiceType string `json:"service_type,omitempty"` // Detected service type (docker, nginx, mysql, etc.)
-	ServiceName string `json:"service_name,omitempty"`  // Human-readable service name
+	Protocol      string `json:"protocol"`                 // "tcp", "udp" or "sctp"
+	Port          int    `json:"port"`                     // Port number
+	Process       string `json:"process"`                  // Process name or "unknown"
+	PID           int    `json:"pid,omitempty"`            // Process ID if available
+	ServiceType   string `json:"service_type,omitempty"`   // Detected service type (docker, nginx, mysql, etc.)
+	ServiceName   string `json:"service_name,omitempty"`   // Human-readable service name
+	ListenAddress string `json:"listen_address,omitempty"` // Bound address ("0.0.0.0", "::" or a specific IP)
+	// EstablishedConnections is a point-in-time snapshot taken during collection (TCP only)
+	EstablishedConnections int `json:"established_connections"`
 }
 
 // SSLInfo represents SSL certificate information for a domain
 type SSLInfo struct {
-	Domain     string    `json:"domain"`
-	ValidFrom  time.Time `json:"valid_from,omitempty"`
-	ValidUntil time.Time `json:"valid_until"`
-	DaysLeft   int       `json:"days_left"` // Days until expiration (negative if expired)
-	Issuer     string    `json:"issuer,omitempty"`
+	Domain       string       `json:"domain"`
+	ValidFrom    time.Time    `json:"valid_from,omitempty"`
+	ValidUntil   time.Time    `json:"valid_until"`
+	DaysLeft     int          `json:"days_left"` // Days until expiration (negative if expired)
+	Issuer       string       `json:"issuer,omitempty"`
+	CTLogEntries []CTLogEntry `json:"ct_log_entries,omitempty"` // Recently logged certificates (if CT log check is enabled)
+}
+
+// SSLCheckResult represents the outcome of checking one SSL domain
+type SSLCheckResult struct {
+	SSLInfo
+	Error string `json:"error,omitempty"` // Why the check failed (empty on success)
+}
+
+// CTLogEntry represents a certificate found in the certificate transparency logs
+type CTLogEntry struct {
+	IssuerCAID int       `json:"issuer_ca_id"` // crt.sh issuer CA identifier
+	LoggedAt   time.Time `json:"logged_at"`
+	NotBefore  time.Time `json:"not_before"`
+	NotAfter   time.Time `json:"not_after"`
+	NameValue  string    `json:"name_value"` // Names covered by the certificate (newline separated)
 }
 
 // LogEntry represents a sanitized log entry from a monitored log file
 type LogEntry struct {
-	Path    string `json:"path"`     // Path to the log file
-	Message string `json:"message"`  // Sanitized log content
-	Lines   int    `json:"lines"`    // Number of lines read
-	Level   string `json:"level,omitempty"` // Log level if detected (info, warn, error, critical)
+	Path       string `json:"path"`            // Path to the log file
+	Message    string `json:"message"`         // Sanitized log content
+	Lines      int    `json:"lines"`           // Number of lines read
+	Level      string `json:"level,omitempty"` // Log level if detected (info, warn, error, critical)
+	ErrorCount int    `json:"error_count"`     // Number of error/critical lines read
+}
+
+// LogAnomaly represents a sudden spike in the error rate of a log file
+type LogAnomaly struct {
+	Path         string  `json:"path"`          // Path to the log file
+	Baseline     float64 `json:"baseline"`      // Average error count over recent cycles
+	CurrentCount int     `json:"current_count"` // Error count in this cycle
 }
 
 // ServiceInfo represents a detected service on the system
 type ServiceInfo struct {
-	Type      string `json:"type"`       // Service type (docker, nginx, mysql, etc.)
-	Name      string `json:"name"`       // Human-readable name
-	Version   string `json:"version,omitempty"` // Service version
-	IsRunning bool   `json:"is_running"` // Whether service is currently running
-	Port      int    `json:"port,omitempty"` // Port if applicable
+	Type         string   `json:"type"`                    // Service type (docker, nginx, mysql, etc.)
+	Name         string   `json:"name"`                    // Human-readable name
+	Version      string   `json:"version,omitempty"`       // Service version
+	IsRunning    bool     `json:"is_running"`              // Whether service is currently running
+	Port         int      `json:"port,omitempty"`          // Port if applicable
+	IsSealed     bool     `json:"is_sealed,omitempty"`     // Vault only: server is sealed (critical)
+	MemoryMB     uint64   `json:"memory_mb,omitempty"`     // Resident memory of the main process
+	CPUPercent   float64  `json:"cpu_percent,omitempty"`   // CPU usage of the main process since the last cycle
+	RouterCount  int      `json:"router_count,omitempty"`  // Traefik only: HTTP routers
+	ServiceCount int      `json:"service_count,omitempty"` // Traefik only: HTTP services
+	Mode         string   `json:"mode,omitempty"`          // Jenkins only: node mode (NORMAL or EXCLUSIVE)
+	Description  string   `json:"description,omitempty"`   // Jenkins only: node description
+	ConfigFiles  []string `json:"config_files,omitempty"`  // Existing well-known config files (max 10)
+}
+
+// CronJob represents a scheduled cron entry found on the system
+type CronJob struct {
+	User     string `json:"user"`     // User the job runs as
+	Schedule string `json:"schedule"` // Cron schedule expression (e.g. "*/5 * * * *" or "@reboot")
+	Command  string `json:"command"`  // Command executed by the job
+	Source   string `json:"source"`   // File or command the entry was read from
+}
+
+// SSHConfigAudit represents the security-relevant settings of the SSH daemon
+type SSHConfigAudit struct {
+	PermitRootLogin        bool     `json:"permit_root_login"`       // Root can log in (any mode other than "no")
+	PasswordAuthentication bool     `json:"password_authentication"` // Password logins are allowed
+	Port                   int      `json:"port"`                    // Port sshd listens on
+	AllowUsers             []string `json:"allow_users"`             // AllowUsers entries (empty = no restriction)
+	ListenAddresses        []string `json:"listen_addresses"`        // ListenAddress entries (empty = all addresses)
+}
+
+// HTTPEndpointConfig describes an HTTP endpoint to check for uptime
+type HTTPEndpointConfig struct {
+	URL                string            `json:"url"`                            // URL to request with GET
+	ExpectedStatusCode int               `json:"expected_status_code,omitempty"` // Expected status (0 = any 2xx)
+	TimeoutSeconds     int               `json:"timeout_seconds,omitempty"`      // Request timeout (default: 10)
+	Headers            map[string]string `json:"headers,omitempty"`              // Extra request headers
+}
+
+// HTTPEndpointResult represents the outcome of an HTTP endpoint check
+type HTTPEndpointResult struct {
+	URL            string `json:"url"`
+	StatusCode     int    `json:"status_code,omitempty"`      // HTTP status (0 if unreachable)
+	Reachable      bool   `json:"reachable"`                  // Whether a response was received
+	ResponseTimeMs int    `json:"response_time_ms,omitempty"` // Time to response headers
+	Error          string `json:"error,omitempty"`            // Connection error or status mismatch
+}
+
+// FileAuditEntry represents a SUID or world-writable file found during a security audit
+type FileAuditEntry struct {
+	Path           string `json:"path"`
+	Mode           string `json:"mode"`              // Octal permissions including special bits (e.g. "4755")
+	Owner          string `json:"owner,omitempty"`   // Owning user
+	IsNewSinceLast bool   `json:"is_new_since_last"` // Not present in the previous scan
+}
+
+// SecurityFinding represents a potential security issue found during an audit
+type SecurityFinding struct {
+	Severity    string `json:"severity"` // "low", "medium" or "high"
+	Description string `json:"description"`
+	Port        int    `json:"port,omitempty"`
+	Process     string `json:"process,omitempty"`
+}
+
+// ModifiedFile represents a recently modified configuration file
+type ModifiedFile struct {
+	Path       string    `json:"path"`
+	ModifiedAt time.Time `json:"modified_at"` // Last modification time (UTC)
+	SizeBytes  int64     `json:"size_bytes"`
+	Mode       string    `json:"mode"` // Octal permissions (e.g. "0644")
+}
+
+// ServiceEdge represents a relationship between two detected services
+type ServiceEdge struct {
+	From string `json:"from"` // Service type that depends on To
+	To   string `json:"to"`   // Service type depended upon
+	Type string `json:"type"` // "requires" or "connects_to"
+}
+
+// ServiceDependencyGraph represents the inferred service topology of the host
+type ServiceDependencyGraph struct {
+	Edges []ServiceEdge `json:"edges"`
+}
+
+// PackageUpdate represents a package with an available update
+type PackageUpdate struct {
+	Name             string `json:"name"`
+	CurrentVersion   string `json:"current_version,omitempty"` // Not reported by yum
+	AvailableVersion string `json:"available_version"`
+	IsSecurityUpdate bool   `json:"is_security_update"`
+}
+
+// OOMEvent represents a process killed by the kernel OOM killer
+type OOMEvent struct {
+	PID         int       `json:"pid"`
+	ProcessName string    `json:"process_name"`
+	Score       int       `json:"score"`               // OOM score (old kernels) or oom_score_adj (newer kernels)
+	Timestamp   time.Time `json:"timestamp,omitempty"` // From the log line (zero if unparseable)
+}
+
+// PortConflict represents a well-known port held by an unexpected process
+type PortConflict struct {
+	Port            int    `json:"port"`
+	ExpectedService string `json:"expected_service"` // Service normally listening on the port
+	ActualProcess   string `json:"actual_process"`   // Process actually listening
+}
+
+// StatsDMetric represents a StatsD metric aggregated over one collection interval
+type StatsDMetric struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`  // StatsD type: "c", "g", "ms", "h", "d" or "s"
+	Value string `json:"value"` // Counter sum, last gauge value, timer mean or set size
+}
+
+// AgentStats represents the state of the agent itself
+type AgentStats struct {
+	Version          string           `json:"version"`
+	UptimeSeconds    int64            `json:"uptime_seconds"`
+	UpdateAvailable  bool             `json:"update_available"`            // A newer release was reported at startup
+	LatestVersion    string           `json:"latest_version,omitempty"`    // Latest release known to the backend
+	SubsystemTimings map[string]int64 `json:"subsystem_timings,omitempty"` // Collection time per subsystem in milliseconds (metrics, ports, services, ssl, logs, commands)
 }
 
 // Payload represents the complete data payload sent to the backend
 type Payload struct {
-	Host      string        `json:"host"`      // Server hostname
-	Timestamp time.Time     `json:"timestamp"` // UTC timestamp
-	System    SystemMetrics `json:"system"`    // System metrics
-	Ports     []PortInfo    `json:"ports"`     // Open ports
-	Services  []ServiceInfo `json:"services,omitempty"` // Detected services
-	SSL       []SSLInfo     `json:"ssl"`       // SSL certificate status
-	Logs      []LogEntry    `json:"logs"`      // Sanitized log entries
+	Host             string                  `json:"host"`                         // Server hostname
+	PublicIP         string                  `json:"public_ip,omitempty"`          // Outbound IP (if GeoIP is enabled)
+	IPCountry        string                  `json:"ip_country,omitempty"`         // Country code of the outbound IP
+	IPASN            string                  `json:"ip_asn,omitempty"`             // ASN and organization of the outbound IP
+	Timestamp        time.Time               `json:"timestamp"`                    // UTC timestamp
+	Agent            *AgentStats             `json:"agent,omitempty"`              // Agent version and uptime
+	System           SystemMetrics           `json:"system"`                       // System metrics
+	Fingerprint      SystemFingerprint       `json:"fingerprint"`                  // Hardware identity for change detection
+	Ports            []PortInfo              `json:"ports"`                        // Open ports
+	Services         []ServiceInfo           `json:"services,omitempty"`           // Detected services
+	ServiceGraph     *ServiceDependencyGraph `json:"service_graph,omitempty"`      // Inferred service dependencies
+	PortConflicts    []PortConflict          `json:"port_conflicts,omitempty"`     // Well-known ports held by unexpected processes
+	SSL              []SSLCheckResult        `json:"ssl"`                          // SSL certificate status per domain
+	HTTPEndpoints    []HTTPEndpointResult    `json:"http_endpoints,omitempty"`     // HTTP uptime checks
+	Logs             []LogEntry              `json:"logs"`                         // Sanitized log entries
+	Anomalies        []LogAnomaly            `json:"anomalies,omitempty"`          // Log error-rate spikes
+	OOMEvents        []OOMEvent              `json:"oom_events,omitempty"`         // OOM killer events (if OOM detection is enabled)
+	CronJobs         []CronJob               `json:"cron_jobs,omitempty"`          // Cron jobs (if cron audit is enabled)
+	SSHConfig        *SSHConfigAudit         `json:"ssh_config,omitempty"`         // SSH daemon audit (if SSH audit is enabled)
+	FileAudit        []FileAuditEntry        `json:"file_audit,omitempty"`         // SUID/world-writable files (if file audit is enabled)
+	RecentEtcChanges []ModifiedFile          `json:"recent_etc_changes,omitempty"` // Recently modified /etc files (if /etc audit is enabled)
+	SecurityFindings []SecurityFinding       `json:"security_findings,omitempty"`  // Unusual listeners and similar issues (if security audit is enabled)
+	PendingUpdates   []PackageUpdate         `json:"pending_updates,omitempty"`    // Available package updates (if package audit is enabled)
+	LVMVolumes       []LVInfo                `json:"lvm_volumes,omitempty"`        // LVM logical volumes (if LVM metrics are enabled)
+	StatsD           []StatsDMetric          `json:"statsd,omitempty"`             // Metrics received on the StatsD listener (if enabled)
 }
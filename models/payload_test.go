@@ -0,0 +1,389 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTime is a UTC timestamp with sub-second precision
+var testTime = time.Date(2026, 3, 14, 15, 9, 26, 535897932, time.UTC)
+
+// fullPayload returns a Payload with every field (including nested ones) set
+func fullPayload() Payload {
+	return Payload{
+		Host:      "web-01",
+		PublicIP:  "203.0.113.10",
+		IPCountry: "DE",
+		IPASN:     "AS64500 Example Hosting",
+		Timestamp: testTime,
+		Agent: &AgentStats{
+			Version:          "1.4.0",
+			UptimeSeconds:    86400,
+			UpdateAvailable:  true,
+			LatestVersion:    "1.5.0",
+			SubsystemTimings: map[string]int64{"metrics": 120, "ports": 35},
+		},
+		System: SystemMetrics{
+			CPUPercent:             42.5,
+			CPUPerCore:             []float64{40, 45},
+			MemoryUsedMB:           3072,
+			MemoryTotalMB:          8192,
+			MemoryPercent:          37.5,
+			MemoryAvailableMB:      4608,
+			MemoryAvailablePercent: 56.25,
+			MemoryCachedMB:         1024,
+			MemoryBuffersMB:        128,
+			SwapUsedMB:             256,
+			SwapTotalMB:            2048,
+			SwapPercent:            12.5,
+			DiskUsage:              map[string]float64{"/": 71.2, "/var": 33.3},
+			MountOptions:           map[string][]string{"/tmp": {"rw", "noexec", "nosuid"}},
+			Disks: []DiskDetail{{
+				MountPoint:        "/tmp",
+				Fstype:            "tmpfs",
+				ReadOnly:          true,
+				NoExec:            true,
+				NoSuid:            true,
+				UsedBytes:         1 << 30,
+				UsedBytesDelta:    -(20 << 20),
+				TrendingDirection: "down",
+			}},
+			NetworkRXMB:              5120,
+			NetworkTXMB:              2560,
+			OpenFileDescriptors:      1984,
+			MaxFileDescriptors:       65536,
+			AgentOpenFileDescriptors: 23,
+			AgentMaxFileDescriptors:  1024,
+			UserStats:                map[string]UserProcessStats{"www-data": {ProcessCount: 12, CPUPercent: 8.5, MemoryMB: 640}},
+			CPUVulnerabilities:       map[string]string{"spectre_v2": "Mitigation: Retpolines"},
+		},
+		Fingerprint: SystemFingerprint{
+			CPUModel:        "AMD EPYC 7B13",
+			CPUCores:        4,
+			MemoryTotalMB:   8192,
+			DiskDevices:     []string{"/dev/sda1"},
+			MacAddresses:    []string{"02:00:00:00:00:01"},
+			FingerprintHash: strings.Repeat("ab", 32),
+		},
+		Ports: []PortInfo{{
+			Protocol:               "tcp",
+			Port:                   443,
+			Process:                "nginx",
+			PID:                    812,
+			ServiceType:            "nginx",
+			ServiceName:            "Nginx",
+			ListenAddress:          "0.0.0.0",
+			EstablishedConnections: 17,
+		}},
+		Services: []ServiceInfo{{
+			Type:         "jenkins",
+			Name:         "Jenkins",
+			Version:      "2.440",
+			IsRunning:    true,
+			Port:         8080,
+			IsSealed:     true,
+			MemoryMB:     1536,
+			CPUPercent:   3.25,
+			RouterCount:  2,
+			ServiceCount: 3,
+			Mode:         "NORMAL",
+			Description:  "build node",
+			ConfigFiles:  []string{"/var/lib/jenkins/config.xml"},
+		}},
+		ServiceGraph: &ServiceDependencyGraph{
+			Edges: []ServiceEdge{{From: "nginx", To: "php-fpm", Type: "connects_to"}},
+		},
+		PortConflicts: []PortConflict{{Port: 3306, ExpectedService: "mysql", ActualProcess: "nc"}},
+		SSL: []SSLCheckResult{{
+			SSLInfo: SSLInfo{
+				Domain:     "example.com",
+				ValidFrom:  testTime.Add(-60 * 24 * time.Hour),
+				ValidUntil: testTime.Add(30 * 24 * time.Hour),
+				DaysLeft:   30,
+				Issuer:     "R11",
+				CTLogEntries: []CTLogEntry{{
+					IssuerCAID: 295810,
+					LoggedAt:   testTime.Add(-time.Hour),
+					NotBefore:  testTime.Add(-2 * time.Hour),
+					NotAfter:   testTime.Add(88 * 24 * time.Hour),
+					NameValue:  "example.com\nwww.example.com",
+				}},
+			},
+			Error: "certificate expires soon",
+		}},
+		HTTPEndpoints: []HTTPEndpointResult{{
+			URL:            "https://example.com/health",
+			StatusCode:     503,
+			Reachable:      true,
+			ResponseTimeMs: 84,
+			Error:          "unexpected status 503",
+		}},
+		Logs: []LogEntry{{
+			Path:       "/var/log/syslog",
+			Message:    "disk error on sda",
+			Lines:      3,
+			Level:      "error",
+			ErrorCount: 1,
+		}},
+		Anomalies: []LogAnomaly{{Path: "/var/log/syslog", Baseline: 1.5, CurrentCount: 40}},
+		OOMEvents: []OOMEvent{{PID: 4242, ProcessName: "java", Score: 987, Timestamp: testTime}},
+		CronJobs:  []CronJob{{User: "root", Schedule: "*/5 * * * *", Command: "/usr/local/bin/backup", Source: "/etc/crontab"}},
+		SSHConfig: &SSHConfigAudit{
+			PermitRootLogin:        true,
+			PasswordAuthentication: true,
+			Port:                   2222,
+			AllowUsers:             []string{"deploy"},
+			ListenAddresses:        []string{"0.0.0.0"},
+		},
+		FileAudit:        []FileAuditEntry{{Path: "/usr/bin/passwd", Mode: "4755", Owner: "root", IsNewSinceLast: true}},
+		RecentEtcChanges: []ModifiedFile{{Path: "/etc/hosts", ModifiedAt: testTime, SizeBytes: 221, Mode: "0644"}},
+		SecurityFindings: []SecurityFinding{{Severity: "high", Description: "redis listening on all interfaces", Port: 6379, Process: "redis-server"}},
+		PendingUpdates:   []PackageUpdate{{Name: "openssl", CurrentVersion: "3.0.2-0ubuntu1.14", AvailableVersion: "3.0.2-0ubuntu1.15", IsSecurityUpdate: true}},
+		LVMVolumes:       []LVInfo{{LVName: "data", VGName: "vg0", SizeBytes: 100 << 30, DataPercent: 81.5, IsActive: true}},
+		StatsD:           []StatsDMetric{{Name: "requests", Type: "c", Value: "1200"}},
+	}
+}
+
+// assertAllSet fails for every zero field, empty slice or empty map reachable from value
+func assertAllSet(t *testing.T, path string, value reflect.Value) {
+	t.Helper()
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			t.Errorf("%s is nil", path)
+			return
+		}
+		assertAllSet(t, path, value.Elem())
+	case reflect.Struct:
+		if value.Type() == reflect.TypeOf(time.Time{}) {
+			if value.Interface().(time.Time).IsZero() {
+				t.Errorf("%s is the zero time", path)
+			}
+			return
+		}
+		for i := 0; i < value.NumField(); i++ {
+			assertAllSet(t, path+"."+value.Type().Field(i).Name, value.Field(i))
+		}
+	case reflect.Slice:
+		if value.Len() == 0 {
+			t.Errorf("%s is empty", path)
+		}
+		for i := 0; i < value.Len(); i++ {
+			assertAllSet(t, path+"["+strconv.Itoa(i)+"]", value.Index(i))
+		}
+	case reflect.Map:
+		if value.Len() == 0 {
+			t.Errorf("%s is empty", path)
+		}
+		iter := value.MapRange()
+		for iter.Next() {
+			assertAllSet(t, path+"["+iter.Key().String()+"]", iter.Value())
+		}
+	default:
+		if value.IsZero() {
+			t.Errorf("%s is not set", path)
+		}
+	}
+}
+
+// roundTrip marshals payload to JSON and unmarshals it into a new Payload
+func roundTrip(t *testing.T, payload Payload) ([]byte, Payload) {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded Payload
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return data, decoded
+}
+
+func TestFullPayloadSetsEveryField(t *testing.T) {
+	// Keeps fullPayload complete when fields are added
+	assertAllSet(t, "Payload", reflect.ValueOf(fullPayload()))
+}
+
+func TestPayloadRoundTrip(t *testing.T) {
+	payload := fullPayload()
+	_, decoded := roundTrip(t, payload)
+	if !reflect.DeepEqual(decoded, payload) {
+		t.Errorf("round trip mismatch\n got: %+v\nwant: %+v", decoded, payload)
+	}
+}
+
+func TestPayloadRoundTripEmbeddedSSLInfo(t *testing.T) {
+	// SSLInfo is embedded, so its fields sit next to "error" rather than in a nested object
+	data, err := json.Marshal(fullPayload().SSL[0])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	for _, key := range []string{"domain", "valid_until", "days_left", "ct_log_entries", "error"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("key %q missing from %s", key, data)
+		}
+	}
+	if _, ok := fields["SSLInfo"]; ok {
+		t.Errorf("SSLInfo encoded as a nested object: %s", data)
+	}
+}
+
+func TestPayloadTimestampUTC(t *testing.T) {
+	payload := fullPayload()
+	data, _ := roundTrip(t, payload)
+	if want := `"timestamp":"2026-03-14T15:09:26.535897932Z"`; !strings.Contains(string(data), want) {
+		t.Errorf("encoded payload missing %s: %s", want, data)
+	}
+
+	// A timestamp in another zone keeps its instant and normalizes back to the same UTC value
+	zone := time.FixedZone("IST", 5*3600+1800)
+	payload.Timestamp = testTime.In(zone)
+	data, decoded := roundTrip(t, payload)
+	if want := `"timestamp":"2026-03-14T20:39:26.535897932+05:30"`; !strings.Contains(string(data), want) {
+		t.Errorf("encoded payload missing %s: %s", want, data)
+	}
+	if !decoded.Timestamp.Equal(testTime) {
+		t.Errorf("Timestamp = %v, want instant %v", decoded.Timestamp, testTime)
+	}
+	if got := decoded.Timestamp.UTC(); got != testTime {
+		t.Errorf("Timestamp.UTC() = %v, want %v", got, testTime)
+	}
+}
+
+func TestPayloadOmitEmpty(t *testing.T) {
+	data, err := json.Marshal(Payload{})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	payloadType := reflect.TypeOf(Payload{})
+	for i := 0; i < payloadType.NumField(); i++ {
+		name, options, _ := strings.Cut(payloadType.Field(i).Tag.Get("json"), ",")
+		_, present := fields[name]
+		omitEmpty := options == "omitempty"
+		if omitEmpty && present {
+			t.Errorf("zero %q should be omitted: %s", name, data)
+		}
+		if !omitEmpty && !present {
+			t.Errorf("zero %q should be present: %s", name, data)
+		}
+	}
+}
+
+func TestNestedOmitEmpty(t *testing.T) {
+	// omitempty has no effect on struct values, so the zero time.Time of
+	// valid_from and an unparseable OOM timestamp is still encoded
+	const zeroTime = `"0001-01-01T00:00:00Z"`
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		absent  []string
+		present map[string]string
+	}{
+		{
+			name:    "SystemMetrics",
+			value:   SystemMetrics{},
+			absent:  []string{"swap_used_mb", "swap_total_mb", "swap_percent", "mount_options", "disks", "open_file_descriptors", "max_file_descriptors", "user_stats", "cpu_vulnerabilities"},
+			present: map[string]string{"memory_used_mb": "0", "cpu_per_core": "null", "disk_usage": "null"},
+		},
+		{
+			name:    "PortInfo",
+			value:   PortInfo{},
+			absent:  []string{"pid", "service_type", "service_name", "listen_address"},
+			present: map[string]string{"port": "0", "established_connections": "0"},
+		},
+		{
+			name:    "SSLCheckResult",
+			value:   SSLCheckResult{},
+			absent:  []string{"issuer", "ct_log_entries", "error"},
+			present: map[string]string{"valid_from": zeroTime, "valid_until": zeroTime, "days_left": "0"},
+		},
+		{
+			name:    "OOMEvent",
+			value:   OOMEvent{},
+			present: map[string]string{"timestamp": zeroTime, "pid": "0"},
+		},
+		{
+			name:    "ServiceInfo",
+			value:   ServiceInfo{},
+			absent:  []string{"version", "port", "is_sealed", "memory_mb", "cpu_percent", "router_count", "service_count", "mode", "description", "config_files"},
+			present: map[string]string{"is_running": "false"},
+		},
+		{
+			name:    "AgentStats",
+			value:   AgentStats{},
+			absent:  []string{"latest_version", "subsystem_timings"},
+			present: map[string]string{"uptime_seconds": "0", "update_available": "false"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			for _, key := range tt.absent {
+				if _, ok := fields[key]; ok {
+					t.Errorf("zero %q should be omitted: %s", key, data)
+				}
+			}
+			for key, want := range tt.present {
+				if got, ok := fields[key]; !ok || string(got) != want {
+					t.Errorf("%q = %s, want %s", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPayloadLargeIntegers(t *testing.T) {
+	payload := fullPayload()
+	payload.System.MemoryUsedMB = math.MaxUint64
+	payload.System.MemoryTotalMB = math.MaxUint64 - 1
+	payload.System.NetworkRXMB = math.MaxUint64
+	payload.System.NetworkTXMB = math.MaxUint64 - 1
+	payload.System.OpenFileDescriptors = math.MaxUint64
+	payload.System.Disks[0].UsedBytes = math.MaxUint64
+	payload.System.Disks[0].UsedBytesDelta = math.MinInt64
+	payload.LVMVolumes[0].SizeBytes = math.MaxUint64 - 1
+	payload.RecentEtcChanges[0].SizeBytes = math.MaxInt64
+	payload.Agent.UptimeSeconds = math.MaxInt64
+
+	data, decoded := roundTrip(t, payload)
+	if !reflect.DeepEqual(decoded, payload) {
+		t.Errorf("round trip mismatch\n got: %+v\nwant: %+v", decoded, payload)
+	}
+
+	// Values are encoded as exact integers, not as lossy floats
+	for _, want := range []string{
+		`"memory_used_mb":18446744073709551615`,
+		`"memory_total_mb":18446744073709551614`,
+		`"used_bytes":18446744073709551615`,
+		`"used_bytes_delta":-9223372036854775808`,
+		`"size_bytes":9223372036854775807`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("encoded payload missing %s", want)
+		}
+	}
+}
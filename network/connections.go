@@ -0,0 +1,99 @@
+package network
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"vpsentinel-agent/models"
+)
+
+// addConnectionCounts sets EstablishedConnections on TCP ports
+func addConnectionCounts(ports []models.PortInfo) {
+	counts, err := establishedByLocalPort()
+	if err != nil {
+		return // Leave counts at zero, the port list is still useful
+	}
+
+	for i := range ports {
+		if ports[i].Protocol == "tcp" {
+			ports[i].EstablishedConnections = counts[ports[i].Port]
+		}
+	}
+}
+
+// establishedByLocalPort counts established TCP connections per local port
+// Uses 'ss' when available and falls back to /proc/net/tcp{,6}
+func establishedByLocalPort() (map[int]int, error) {
+	output, err := cmdExecutor.Output("ss", "-tn", "state", "established")
+	if err == nil {
+		return parseSSEstablished(string(output)), nil
+	}
+
+	counts := make(map[int]int)
+	var lastErr error
+	found := false
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		if err := countProcNetTCP(path, counts); err != nil {
+			lastErr = err
+			continue
+		}
+		found = true
+	}
+	if !found {
+		return nil, lastErr
+	}
+	return counts, nil
+}
+
+// parseSSEstablished parses 'ss -tn state established' output
+// Format: Recv-Q Send-Q Local Address:Port Peer Address:Port Process
+func parseSSEstablished(output string) map[int]int {
+	counts := make(map[int]int)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] == "Recv-Q" {
+			continue
+		}
+
+		local := fields[2]
+		idx := strings.LastIndex(local, ":")
+		if idx == -1 {
+			continue
+		}
+		if port, err := strconv.Atoi(local[idx+1:]); err == nil {
+			counts[port]++
+		}
+	}
+	return counts
+}
+
+// countProcNetTCP adds established connections from a /proc/net/tcp style file
+// Format: sl local_address rem_address st ... with hex "ADDR:PORT" and state 01 = ESTABLISHED
+func countProcNetTCP(path string, counts map[int]int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Skip header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[3] != "01" {
+			continue
+		}
+
+		local := fields[1]
+		idx := strings.LastIndex(local, ":")
+		if idx == -1 {
+			continue
+		}
+		if port, err := strconv.ParseInt(local[idx+1:], 16, 32); err == nil {
+			counts[int(port)]++
+		}
+	}
+	return scanner.Err()
+}
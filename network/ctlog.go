@@ -0,0 +1,86 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+const (
+	ctLogURL      = "https://crt.sh/"
+	ctLogTimeout  = 10 * time.Second
+	ctLogLookback = 30 * 24 * time.Hour
+
+	// crt.sh timestamps are UTC without a zone suffix
+	ctTimeLayout = "2006-01-02T15:04:05.999999999"
+)
+
+// crtShEntry is the subset of the crt.sh JSON response format we use
+type crtShEntry struct {
+	IssuerCAID     int    `json:"issuer_ca_id"`
+	NameValue      string `json:"name_value"`
+	EntryTimestamp string `json:"entry_timestamp"`
+	NotBefore      string `json:"not_before"`
+	NotAfter       string `json:"not_after"`
+}
+
+// CheckCTLogs returns certificates logged for a domain in the last 30 days
+// Unexpected entries may indicate a certificate issued without authorization
+func CheckCTLogs(domain string) ([]models.CTLogEntry, error) {
+	// Query by hostname only
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		domain = host
+	}
+
+	client := &http.Client{Timeout: ctLogTimeout}
+	req, err := http.NewRequest("GET", ctLogURL+"?q="+url.QueryEscape(domain)+"&output=json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var raw []crtShEntry
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	cutoff := time.Now().Add(-ctLogLookback)
+	entries := []models.CTLogEntry{}
+	for _, r := range raw {
+		loggedAt, err := time.Parse(ctTimeLayout, r.EntryTimestamp)
+		if err != nil || loggedAt.Before(cutoff) {
+			continue
+		}
+
+		// Validity dates are informational, keep the entry even if they don't parse
+		notBefore, _ := time.Parse(ctTimeLayout, r.NotBefore)
+		notAfter, _ := time.Parse(ctTimeLayout, r.NotAfter)
+
+		entries = append(entries, models.CTLogEntry{
+			IssuerCAID: r.IssuerCAID,
+			LoggedAt:   loggedAt,
+			NotBefore:  notBefore,
+			NotAfter:   notAfter,
+			NameValue:  r.NameValue,
+		})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,84 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultGeoIPURL is the IP-info API used when none is configured
+	DefaultGeoIPURL = "https://ipinfo.io/json"
+
+	geoIPTimeout  = 5 * time.Second
+	geoIPCacheTTL = 1 * time.Hour
+)
+
+// geoIPCache holds the last successful lookup to avoid hammering the API
+var geoIPCache struct {
+	sync.Mutex
+	url       string
+	ip        string
+	country   string
+	org       string
+	fetchedAt time.Time
+}
+
+// ipInfoResponse is the subset of the ipinfo.io response format we use
+type ipInfoResponse struct {
+	IP      string `json:"ip"`
+	Country string `json:"country"`
+	Org     string `json:"org"` // e.g. "AS15169 Google LLC"
+}
+
+// GetPublicIP returns the agent's outbound IP with its country and network owner
+// Results are cached for an hour
+func GetPublicIP(apiURL string) (ip, country, org string, err error) {
+	if apiURL == "" {
+		apiURL = DefaultGeoIPURL
+	}
+
+	geoIPCache.Lock()
+	defer geoIPCache.Unlock()
+
+	if geoIPCache.url == apiURL && time.Since(geoIPCache.fetchedAt) < geoIPCacheTTL {
+		return geoIPCache.ip, geoIPCache.country, geoIPCache.org, nil
+	}
+
+	client := &http.Client{Timeout: geoIPTimeout}
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", "", "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var info ipInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", "", "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if info.IP == "" {
+		return "", "", "", fmt.Errorf("response did not include an IP address")
+	}
+
+	geoIPCache.url = apiURL
+	geoIPCache.ip = info.IP
+	geoIPCache.country = info.Country
+	geoIPCache.org = info.Org
+	geoIPCache.fetchedAt = time.Now()
+
+	return info.IP, info.Country, info.Org, nil
+}
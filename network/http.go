@@ -0,0 +1,78 @@
+package network
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+// defaultEndpointTimeout is used when an endpoint doesn't configure its own timeout
+const defaultEndpointTimeout = 10 * time.Second
+
+// CheckHTTPEndpoints performs an HTTP GET against each configured endpoint
+// Endpoints are checked in parallel; results keep the configured order
+func CheckHTTPEndpoints(endpoints []models.HTTPEndpointConfig) []models.HTTPEndpointResult {
+	results := make([]models.HTTPEndpointResult, len(endpoints))
+
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint models.HTTPEndpointConfig) {
+			defer wg.Done()
+			results[i] = checkHTTPEndpoint(endpoint)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// checkHTTPEndpoint checks a single endpoint
+func checkHTTPEndpoint(endpoint models.HTTPEndpointConfig) models.HTTPEndpointResult {
+	result := models.HTTPEndpointResult{URL: endpoint.URL}
+
+	timeout := defaultEndpointTimeout
+	if endpoint.TimeoutSeconds > 0 {
+		timeout = time.Duration(endpoint.TimeoutSeconds) * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	req, err := http.NewRequest("GET", endpoint.URL, nil)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid request: %v", err)
+		return result
+	}
+	for name, value := range endpoint.Headers {
+		req.Header.Set(name, value)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	result.ResponseTimeMs = int(time.Since(start).Milliseconds())
+
+	// Drain a bounded amount of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	result.StatusCode = resp.StatusCode
+	result.Reachable = true
+
+	// Without an expected status code any 2xx response is healthy
+	if endpoint.ExpectedStatusCode > 0 {
+		if resp.StatusCode != endpoint.ExpectedStatusCode {
+			result.Error = fmt.Sprintf("expected status %d, got %d", endpoint.ExpectedStatusCode, resp.StatusCode)
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+
+	return result
+}
@@ -1,42 +1,205 @@
 package network
 
 import (
-	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"vpsentinel-agent/executor"
 	"vpsentinel-agent/models"
 	"vpsentinel-agent/services"
 )
 
+// cmdExecutor runs the external commands used for port detection
+var cmdExecutor executor.Executor = executor.System{}
+
+// SetExecutor replaces the command executor (used to inject fixture output in tests)
+// Returns the previous executor so it can be restored
+func SetExecutor(e executor.Executor) executor.Executor {
+	previous := cmdExecutor
+	cmdExecutor = e
+	return previous
+}
+
 // GetOpenPorts collects information about open network ports
 // If portsToMonitor is non-empty, only monitors those specific ports
 func GetOpenPorts(portsToMonitor []int) ([]models.PortInfo, error) {
 	// Try 'ss' command first (Linux, preferred)
 	ports, err := getPortsWithSS(portsToMonitor)
-	if err == nil {
-		return ports, nil
+	if err != nil {
+		// Fallback to 'lsof' if 'ss' is not available (macOS)
+		ports, err = getPortsWithLSOF(portsToMonitor)
 	}
-
-	// Fallback to 'netstat' if 'ss' is not available
-	ports, err = getPortsWithNetstat(portsToMonitor)
 	if err != nil {
-		return nil, err
+		// Test-bind local ports on macOS/FreeBSD (netstat there doesn't show processes)
+		ports, err = getPortsByProbing(portsToMonitor)
 	}
+	if err != nil {
+		// Fallback to 'netstat' as a last resort
+		ports, err = getPortsWithNetstat(portsToMonitor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Count active connections on each listening port
+	addConnectionCounts(ports)
 
 	return ports, nil
 }
 
 // getPortsWithSS uses the 'ss' command (Linux, preferred method)
+// IPv6 sockets are queried separately since 'ss -tulpn' doesn't list them reliably
+// Each (protocol, port) is reported once, like the lsof fallback
 func getPortsWithSS(portsToMonitor []int) ([]models.PortInfo, error) {
-	cmd := exec.Command("ss", "-tulpn")
-	output, err := cmd.Output()
+	output, err := cmdExecutor.Output("ss", "-tulpn")
+	if err != nil {
+		return nil, err
+	}
+
+	ports, err := parseSSOutput(string(output), portsToMonitor, []string{"tcp", "udp"})
+	if err != nil {
+		return nil, err
+	}
+
+	// IPv6 results are best effort (IPv6 may be disabled)
+	output6, err := cmdExecutor.Output("ss", "-6tulpn")
+	if err == nil {
+		ports6, err := parseSSOutput(string(output6), portsToMonitor, []string{"tcp", "udp"})
+		if err == nil {
+			ports = mergePorts(ports, ports6)
+		}
+	}
+
+	// SCTP results are best effort (the sctp module is often not loaded)
+	if portsSCTP, err := getSCTPPortsWithSS(portsToMonitor); err == nil {
+		ports = mergePorts(ports, portsSCTP)
+	}
+
+	return ports, nil
+}
+
+// getSCTPPortsWithSS lists SCTP listening sockets (used by telco and VoIP software)
+func getSCTPPortsWithSS(portsToMonitor []int) ([]models.PortInfo, error) {
+	output, err := cmdExecutor.Output("ss", "-Slpn")
 	if err != nil {
 		return nil, err
 	}
 
-	return parseSSOutput(string(output), portsToMonitor)
+	return parseSSOutput(string(output), portsToMonitor, []string{"sctp"})
+}
+
+// mergePorts appends extra ports that aren't already present, deduplicating by (protocol, port)
+func mergePorts(ports, extra []models.PortInfo) []models.PortInfo {
+	seen := make(map[string]bool)
+	for _, p := range ports {
+		seen[p.Protocol+"/"+strconv.Itoa(p.Port)] = true
+	}
+
+	for _, p := range extra {
+		key := p.Protocol + "/" + strconv.Itoa(p.Port)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ports = append(ports, p)
+	}
+
+	return ports
+}
+
+// normalizeListenAddress converts an ss local address to a plain IP
+// "[::]" -> "::", "*" -> "::" (dual-stack), "127.0.0.53%lo" -> "127.0.0.53"
+func normalizeListenAddress(address string) string {
+	address = strings.TrimPrefix(address, "[")
+	address = strings.TrimSuffix(address, "]")
+	if i := strings.Index(address, "%"); i >= 0 {
+		address = address[:i]
+	}
+	if address == "*" || address == "" {
+		return "::"
+	}
+	return address
+}
+
+// getPortsWithLSOF uses 'lsof' (macOS and systems without ss)
+func getPortsWithLSOF(portsToMonitor []int) ([]models.PortInfo, error) {
+	output, err := cmdExecutor.Output("lsof", "-i", "-nP", "-sTCP:LISTEN")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLSOFOutput(string(output), portsToMonitor)
+}
+
+// parseLSOFOutput parses output from 'lsof -i -nP -sTCP:LISTEN'
+// Format: COMMAND PID USER FD TYPE DEVICE SIZE/OFF NODE NAME
+// e.g.    nginx   123 root 6u IPv4 0x1a2b 0t0      TCP  *:80 (LISTEN)
+func parseLSOFOutput(output string, portsToMonitor []int) ([]models.PortInfo, error) {
+	var ports []models.PortInfo
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 9 || fields[0] == "COMMAND" {
+			continue
+		}
+
+		// NODE is the protocol, NAME follows it
+		protocol := strings.ToLower(fields[7])
+		if protocol != "tcp" && protocol != "udp" {
+			continue
+		}
+		name := fields[8]
+		if strings.Contains(name, "->") {
+			continue // Connected socket, not a listener
+		}
+
+		idx := strings.LastIndex(name, ":")
+		if idx == -1 {
+			continue
+		}
+		port, err := strconv.Atoi(name[idx+1:])
+		if err != nil {
+			continue
+		}
+
+		// Check if we should monitor this port
+		if !shouldMonitorPort(port, portsToMonitor) {
+			continue
+		}
+
+		// lsof lists one line per file descriptor (e.g. every nginx worker)
+		key := protocol + "/" + strconv.Itoa(port)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		processName := fields[0]
+		pid, _ := strconv.Atoi(fields[1])
+
+		// Detect service type
+		serviceInfo := services.DetectService(processName, port, pid)
+
+		portInfo := models.PortInfo{
+			Protocol:      protocol,
+			Port:          port,
+			Process:       processName,
+			PID:           pid,
+			ListenAddress: normalizeListenAddress(name[:idx]),
+		}
+
+		// Add service information if detected
+		if serviceInfo.Type != services.ServiceTypeUnknown {
+			portInfo.ServiceType = string(serviceInfo.Type)
+			portInfo.ServiceName = serviceInfo.Name
+		}
+
+		ports = append(ports, portInfo)
+	}
+
+	return ports, nil
 }
 
 // getPortsWithNetstat uses 'netstat' as a fallback
@@ -51,8 +214,7 @@ func getPortsWithNetstat(portsToMonitor []int) ([]models.PortInfo, error) {
 	var output []byte
 	var err error
 	for _, args := range commands {
-		cmd := exec.Command(args[0], args[1:]...)
-		output, err = cmd.Output()
+		output, err = cmdExecutor.Output(args[0], args[1:]...)
 		if err == nil {
 			break
 		}
@@ -65,14 +227,17 @@ func getPortsWithNetstat(portsToMonitor []int) ([]models.PortInfo, error) {
 	return parseNetstatOutput(string(output), portsToMonitor)
 }
 
-// parseSSOutput parses output from 'ss -tulpn' command
-// Format: State      Recv-Q Send-Q Local Address:Port  Peer Address:Port  Process
-func parseSSOutput(output string, portsToMonitor []int) ([]models.PortInfo, error) {
+// parseSSOutput parses output from 'ss -tulpn' or 'ss -Slpn' commands
+// Format: [Netid] State Recv-Q Send-Q Local Address:Port  Peer Address:Port  Process
+// protocols lists the protocols the output may contain; the first one is used
+// when a line has no Netid column
+func parseSSOutput(output string, portsToMonitor []int, protocols []string) ([]models.PortInfo, error) {
 	var ports []models.PortInfo
 	lines := strings.Split(output, "\n")
 
 	// Regex to match: LISTEN 0 128 0.0.0.0:80 0.0.0.0:* users:(("nginx",pid=1234,fd=7))
-	ssPattern := regexp.MustCompile(`LISTEN\s+\d+\s+\d+\s+.*?:(\d+)\s+.*?\s+users:\(\("([^"]+)",pid=(\d+),`)
+	// IPv6 local addresses look like [::]:80 or :::80, so split on the last colon
+	ssPattern := regexp.MustCompile(`LISTEN\s+\d+\s+\d+\s+(\S+):(\d+)\s+.*?\s+users:\(\("([^"]+)",pid=(\d+),`)
 
 	for _, line := range lines {
 		if !strings.Contains(line, "LISTEN") {
@@ -80,12 +245,12 @@ func parseSSOutput(output string, portsToMonitor []int) ([]models.PortInfo, erro
 		}
 
 		matches := ssPattern.FindStringSubmatch(line)
-		if len(matches) < 4 {
+		if len(matches) < 5 {
 			// Try simpler pattern without process info
-			simplePattern := regexp.MustCompile(`LISTEN\s+\d+\s+\d+\s+.*?:(\d+)\s+`)
+			simplePattern := regexp.MustCompile(`LISTEN\s+\d+\s+\d+\s+(\S+):(\d+)\s+`)
 			simpleMatches := simplePattern.FindStringSubmatch(line)
-			if len(simpleMatches) >= 2 {
-				port, err := strconv.Atoi(simpleMatches[1])
+			if len(simpleMatches) >= 3 {
+				port, err := strconv.Atoi(simpleMatches[2])
 				if err != nil {
 					continue
 				}
@@ -96,31 +261,29 @@ func parseSSOutput(output string, portsToMonitor []int) ([]models.PortInfo, erro
 				}
 
 				// Determine protocol from line
-				protocol := "tcp"
-				if strings.Contains(line, "udp") {
-					protocol = "udp"
-				}
+				protocol := lineProtocol(line, protocols)
 
 				// Detect service by port only
 				serviceInfo := services.DetectService("unknown", port, 0)
-				
+
 				portInfo := models.PortInfo{
-					Protocol: protocol,
-					Port:     port,
-					Process:  "unknown",
+					Protocol:      protocol,
+					Port:          port,
+					Process:       "unknown",
+					ListenAddress: normalizeListenAddress(simpleMatches[1]),
 				}
-				
+
 				if serviceInfo.Type != services.ServiceTypeUnknown {
 					portInfo.ServiceType = string(serviceInfo.Type)
 					portInfo.ServiceName = serviceInfo.Name
 				}
-				
+
 				ports = append(ports, portInfo)
 			}
 			continue
 		}
 
-		port, err := strconv.Atoi(matches[1])
+		port, err := strconv.Atoi(matches[2])
 		if err != nil {
 			continue
 		}
@@ -130,35 +293,50 @@ func parseSSOutput(output string, portsToMonitor []int) ([]models.PortInfo, erro
 			continue
 		}
 
-		processName := matches[2]
-		pid, _ := strconv.Atoi(matches[3])
+		processName := matches[3]
+		pid, _ := strconv.Atoi(matches[4])
 
 		// Determine protocol from line
-		protocol := "tcp"
-		if strings.Contains(line, "udp") {
-			protocol = "udp"
-		}
+		protocol := lineProtocol(line, protocols)
 
 		// Detect service type
 		serviceInfo := services.DetectService(processName, port, pid)
-		
+
 		portInfo := models.PortInfo{
-			Protocol: protocol,
-			Port:     port,
-			Process:  processName,
-			PID:      pid,
+			Protocol:      protocol,
+			Port:          port,
+			Process:       processName,
+			PID:           pid,
+			ListenAddress: normalizeListenAddress(matches[1]),
 		}
-		
+
 		// Add service information if detected
 		if serviceInfo.Type != services.ServiceTypeUnknown {
 			portInfo.ServiceType = string(serviceInfo.Type)
 			portInfo.ServiceName = serviceInfo.Name
 		}
-		
+
 		ports = append(ports, portInfo)
 	}
 
-	return ports, nil
+	// Dual-stack listeners appear once per address family (0.0.0.0:80 and [::]:80)
+	return mergePorts(nil, ports), nil
+}
+
+// lineProtocol returns the protocol named in the Netid column of an ss line
+func lineProtocol(line string, protocols []string) string {
+	fields := strings.Fields(line)
+	if len(fields) > 0 {
+		for _, protocol := range protocols {
+			if fields[0] == protocol {
+				return protocol
+			}
+		}
+	}
+	if len(protocols) == 0 {
+		return "tcp"
+	}
+	return protocols[0]
 }
 
 // parseNetstatOutput parses output from 'netstat' command
@@ -199,20 +377,20 @@ func parseNetstatOutput(output string, portsToMonitor []int) ([]models.PortInfo,
 
 		// Detect service type
 		serviceInfo := services.DetectService(processName, port, pid)
-		
+
 		portInfo := models.PortInfo{
 			Protocol: protocol,
 			Port:     port,
 			Process:  processName,
 			PID:      pid,
 		}
-		
+
 		// Add service information if detected
 		if serviceInfo.Type != services.ServiceTypeUnknown {
 			portInfo.ServiceType = string(serviceInfo.Type)
 			portInfo.ServiceName = serviceInfo.Name
 		}
-		
+
 		ports = append(ports, portInfo)
 	}
 
@@ -220,6 +398,7 @@ func parseNetstatOutput(output string, portsToMonitor []int) ([]models.PortInfo,
 }
 
 // shouldMonitorPort checks if a port should be monitored based on the filter list
+// The filter applies to TCP, UDP and SCTP ports alike
 // If portsToMonitor is empty, monitor all ports
 func shouldMonitorPort(port int, portsToMonitor []int) bool {
 	if len(portsToMonitor) == 0 {
@@ -0,0 +1,239 @@
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vpsentinel-agent/executor"
+	"vpsentinel-agent/models"
+	"vpsentinel-agent/services"
+)
+
+// withMockExecutors runs commands of both the network and services packages
+// through mock, so tests never shell out to the host
+func withMockExecutors(t *testing.T, mock *executor.Mock) {
+	t.Helper()
+	previous := SetExecutor(mock)
+	previousServices := services.SetExecutor(mock)
+	t.Cleanup(func() {
+		SetExecutor(previous)
+		services.SetExecutor(previousServices)
+	})
+}
+
+// readFixture reads a captured command output from testdata
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return data
+}
+
+// findPort returns the port entry for protocol/port, or nil
+func findPort(ports []models.PortInfo, protocol string, port int) *models.PortInfo {
+	for i := range ports {
+		if ports[i].Protocol == protocol && ports[i].Port == port {
+			return &ports[i]
+		}
+	}
+	return nil
+}
+
+func TestParseSSOutput(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []models.PortInfo
+	}{
+		{
+			fixture: "ss_tulpn.txt",
+			want: []models.PortInfo{
+				{Protocol: "tcp", Port: 80, Process: "nginx", PID: 1201, ListenAddress: "0.0.0.0", ServiceType: "nginx", ServiceName: "Nginx"},
+				{Protocol: "tcp", Port: 53, Process: "systemd-resolve", PID: 612, ListenAddress: "127.0.0.53"},
+				{Protocol: "tcp", Port: 22, Process: "sshd", PID: 845, ListenAddress: "0.0.0.0"},
+				{Protocol: "tcp", Port: 3306, Process: "mysqld", PID: 990, ListenAddress: "127.0.0.1", ServiceType: "mysql", ServiceName: "MySQL"},
+			},
+		},
+		{
+			fixture: "ss_6tulpn.txt",
+			want: []models.PortInfo{
+				{Protocol: "tcp", Port: 80, Process: "nginx", PID: 1201, ListenAddress: "::", ServiceType: "nginx", ServiceName: "Nginx"},
+				{Protocol: "tcp", Port: 22, Process: "sshd", PID: 845, ListenAddress: "::"},
+				{Protocol: "tcp", Port: 9100, Process: "node_exporter", PID: 700, ListenAddress: "::"},
+				{Protocol: "tcp", Port: 6379, Process: "redis-server", PID: 880, ListenAddress: "::1", ServiceType: "redis", ServiceName: "Redis"},
+			},
+		},
+		{
+			// Older ss versions print IPv6 wildcards as :::PORT
+			fixture: "ss_6tulpn_legacy.txt",
+			want: []models.PortInfo{
+				{Protocol: "tcp", Port: 22, Process: "sshd", PID: 845, ListenAddress: "::"},
+				{Protocol: "tcp", Port: 8080, Process: "java", PID: 1500, ListenAddress: "::"},
+			},
+		},
+		{
+			// Without root, ss can't show the owning process
+			fixture: "ss_tulpn_no_process.txt",
+			want: []models.PortInfo{
+				{Protocol: "tcp", Port: 22, Process: "unknown", ListenAddress: "0.0.0.0"},
+				{Protocol: "tcp", Port: 5432, Process: "unknown", ListenAddress: "127.0.0.1", ServiceType: "postgresql", ServiceName: "PostgreSQL"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			withMockExecutors(t, executor.NewMock(nil))
+
+			got, err := parseSSOutput(string(readFixture(t, tt.fixture)), nil, []string{"tcp", "udp"})
+			if err != nil {
+				t.Fatalf("parseSSOutput() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseSSOutput() returned %d ports, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("port %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSSOutputPortsToMonitor(t *testing.T) {
+	withMockExecutors(t, executor.NewMock(nil))
+
+	got, err := parseSSOutput(string(readFixture(t, "ss_tulpn.txt")), []int{3306}, []string{"tcp", "udp"})
+	if err != nil {
+		t.Fatalf("parseSSOutput() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Port != 3306 {
+		t.Errorf("parseSSOutput() = %+v, want only port 3306", got)
+	}
+}
+
+func TestGetPortsWithSSMergesIPv6(t *testing.T) {
+	withMockExecutors(t, executor.NewMock(map[string]executor.MockResult{
+		"ss -tulpn":  {Output: readFixture(t, "ss_tulpn.txt")},
+		"ss -6tulpn": {Output: readFixture(t, "ss_6tulpn.txt")},
+	}))
+
+	ports, err := getPortsWithSS(nil)
+	if err != nil {
+		t.Fatalf("getPortsWithSS() error = %v", err)
+	}
+
+	// Each (protocol, port) is reported once; dual-stack listeners keep the
+	// first address seen and the IPv6-only listeners (9100 and 6379) are added
+	counts := make(map[int]int)
+	for _, p := range ports {
+		counts[p.Port]++
+	}
+	for _, port := range []int{80, 22, 53, 3306, 9100, 6379} {
+		if counts[port] != 1 {
+			t.Errorf("tcp/%d reported %d times, want 1", port, counts[port])
+		}
+	}
+	if len(ports) != 6 {
+		t.Errorf("getPortsWithSS() returned %d ports, want 6: %+v", len(ports), ports)
+	}
+	if p := findPort(ports, "tcp", 80); p == nil || p.ListenAddress != "0.0.0.0" {
+		t.Errorf("tcp/80 = %+v, want listen address 0.0.0.0", p)
+	}
+
+	if p := findPort(ports, "tcp", 6379); p == nil || p.ListenAddress != "::1" {
+		t.Errorf("tcp/6379 = %+v, want listen address ::1", p)
+	}
+}
+
+func TestGetPortsWithSSWithoutIPv6(t *testing.T) {
+	// A failing IPv6 query still returns the IPv4 results
+	withMockExecutors(t, executor.NewMock(map[string]executor.MockResult{
+		"ss -tulpn": {Output: readFixture(t, "ss_tulpn.txt")},
+	}))
+
+	ports, err := getPortsWithSS(nil)
+	if err != nil {
+		t.Fatalf("getPortsWithSS() error = %v", err)
+	}
+	if len(ports) != 4 {
+		t.Errorf("getPortsWithSS() returned %d ports, want 4: %+v", len(ports), ports)
+	}
+}
+
+func TestNormalizeListenAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"0.0.0.0", "0.0.0.0"},
+		{"[::]", "::"},
+		{"::", "::"},
+		{"*", "::"},
+		{"127.0.0.53%lo", "127.0.0.53"},
+		{"[fe80::1%eth0]", "fe80::1"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeListenAddress(tt.address); got != tt.want {
+			t.Errorf("normalizeListenAddress(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestParseLSOFOutput(t *testing.T) {
+	withMockExecutors(t, executor.NewMock(nil))
+
+	got, err := parseLSOFOutput(string(readFixture(t, "lsof_listen.txt")), nil)
+	if err != nil {
+		t.Fatalf("parseLSOFOutput() error = %v", err)
+	}
+
+	// One entry per (protocol, port), even with several file descriptors or
+	// address families; the connected Google socket is skipped
+	want := []models.PortInfo{
+		{Protocol: "tcp", Port: 22, Process: "launchd", PID: 1, ListenAddress: "::"},
+		{Protocol: "tcp", Port: 80, Process: "nginx", PID: 512, ListenAddress: "::", ServiceType: "nginx", ServiceName: "Nginx"},
+		{Protocol: "tcp", Port: 3306, Process: "mysqld", PID: 640, ListenAddress: "127.0.0.1", ServiceType: "mysql", ServiceName: "MySQL"},
+		{Protocol: "tcp", Port: 6379, Process: "redis-ser", PID: 701, ListenAddress: "::1", ServiceType: "redis", ServiceName: "Redis"},
+		{Protocol: "udp", Port: 5353, Process: "mDNSRespo", PID: 210, ListenAddress: "::"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseLSOFOutput() returned %d ports, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("port %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseLSOFOutputPortsToMonitor(t *testing.T) {
+	withMockExecutors(t, executor.NewMock(nil))
+
+	got, err := parseLSOFOutput(string(readFixture(t, "lsof_listen.txt")), []int{80, 443})
+	if err != nil {
+		t.Fatalf("parseLSOFOutput() error = %v", err)
+	}
+	// 443 only appears as the remote end of a connection
+	if len(got) != 1 || got[0].Port != 80 {
+		t.Errorf("parseLSOFOutput() = %+v, want only port 80", got)
+	}
+}
+
+func TestGetPortsWithLSOF(t *testing.T) {
+	withMockExecutors(t, executor.NewMock(map[string]executor.MockResult{
+		"lsof -i -nP -sTCP:LISTEN": {Output: readFixture(t, "lsof_listen.txt")},
+	}))
+
+	ports, err := getPortsWithLSOF(nil)
+	if err != nil {
+		t.Fatalf("getPortsWithLSOF() error = %v", err)
+	}
+	if len(ports) != 5 {
+		t.Errorf("getPortsWithLSOF() returned %d ports, want 5: %+v", len(ports), ports)
+	}
+}
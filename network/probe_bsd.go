@@ -0,0 +1,117 @@
+//go:build darwin || freebsd
+
+package network
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"vpsentinel-agent/models"
+	"vpsentinel-agent/services"
+)
+
+// probeWorkers is the number of ports tested concurrently
+const probeWorkers = 16
+
+// probeAddresses are the local addresses a test listener is bound to, in order
+// A wildcard listener collides with the wildcard bind, a loopback-only one with
+// the loopback bind; the first collision marks the port as in use
+var probeAddresses = []struct {
+	network string
+	address string
+}{
+	{"tcp4", "0.0.0.0"},
+	{"tcp4", "127.0.0.1"},
+	{"tcp6", "::"},
+	{"tcp6", "::1"},
+}
+
+// getPortsByProbing finds TCP listeners by trying to bind each port locally
+// Used on macOS and FreeBSD when neither ss nor lsof is available (netstat there
+// doesn't show processes). A port whose bind fails with EADDRINUSE is in use.
+// No connections are made, so services never see the probe. The kernel's socket
+// list (sysctl net.inet.tcp.pcblist) uses private structures that change between
+// OS releases, so it isn't parsed.
+// Limitations: UDP ports and listeners bound only to a non-loopback address aren't
+// found, ports below 1024 can only be tested as root, and the owning process and
+// listen address are unknown
+func getPortsByProbing(portsToMonitor []int) ([]models.PortInfo, error) {
+	candidates := portsToMonitor
+	if len(candidates) == 0 {
+		candidates = make([]int, 0, 65535)
+		for port := 1; port <= 65535; port++ {
+			candidates = append(candidates, port)
+		}
+	}
+
+	targets := make(chan int)
+	var mu sync.Mutex
+	inUse := make(map[int]bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < probeWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for port := range targets {
+				if !portInUse(port) {
+					continue
+				}
+				mu.Lock()
+				inUse[port] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, port := range candidates {
+		targets <- port
+	}
+	close(targets)
+	wg.Wait()
+
+	found := make([]int, 0, len(inUse))
+	for port := range inUse {
+		found = append(found, port)
+	}
+	sort.Ints(found)
+
+	ports := []models.PortInfo{}
+	for _, port := range found {
+		portInfo := models.PortInfo{
+			Protocol: "tcp",
+			Port:     port,
+			Process:  "unknown",
+		}
+
+		// Only the port is known, so detection relies on well-known port numbers
+		serviceInfo := services.DetectService("", port, 0)
+		if serviceInfo.Type != services.ServiceTypeUnknown {
+			portInfo.ServiceType = string(serviceInfo.Type)
+			portInfo.ServiceName = serviceInfo.Name
+		}
+
+		ports = append(ports, portInfo)
+	}
+
+	return ports, nil
+}
+
+// portInUse reports whether binding a test listener to port fails with EADDRINUSE
+// Other errors (no IPv6, permission denied) say nothing about the port
+func portInUse(port int) bool {
+	for _, probe := range probeAddresses {
+		listener, err := net.Listen(probe.network, net.JoinHostPort(probe.address, strconv.Itoa(port)))
+		if err == nil {
+			listener.Close()
+			continue
+		}
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+//go:build darwin || freebsd
+
+package network
+
+import (
+	"net"
+	"testing"
+
+	"vpsentinel-agent/executor"
+)
+
+func TestGetPortsByProbing(t *testing.T) {
+	withMockExecutors(t, executor.NewMock(nil))
+
+	for _, address := range []string{"127.0.0.1:0", "0.0.0.0:0"} {
+		listener, err := net.Listen("tcp4", address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+
+		ports, err := getPortsByProbing([]int{port})
+		if err != nil {
+			t.Fatalf("getPortsByProbing() error = %v", err)
+		}
+		if len(ports) != 1 || ports[0].Port != port || ports[0].Protocol != "tcp" {
+			t.Errorf("getPortsByProbing() with a listener on %s = %+v, want one tcp/%d entry", listener.Addr(), ports, port)
+		}
+
+		listener.Close()
+		if portInUse(port) {
+			t.Errorf("portInUse(%d) = true after the listener closed", port)
+		}
+	}
+}
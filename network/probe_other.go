@@ -0,0 +1,14 @@
+//go:build !darwin && !freebsd
+
+package network
+
+import (
+	"errors"
+
+	"vpsentinel-agent/models"
+)
+
+// getPortsByProbing is only used on macOS and FreeBSD
+func getPortsByProbing(portsToMonitor []int) ([]models.PortInfo, error) {
+	return nil, errors.New("port probing is not supported on this platform")
+}
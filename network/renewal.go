@@ -0,0 +1,97 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+// acmeProbeTimeout bounds the HTTP request to the ACME challenge path
+const acmeProbeTimeout = 10 * time.Second
+
+// CheckCertRenewal checks whether each domain's certificate is due for renewal
+// and whether the HTTP-01 challenge path is served on port 80
+// The probe is made from this host, so it shows that the web server answers on
+// port 80 but can't rule out a firewall blocking outside traffic
+// Domains are checked in parallel (up to maxConcurrentSSLChecks at once) and
+// reported in the configured order; cancelling ctx aborts the remaining probes
+func CheckCertRenewal(ctx context.Context, domains []string, daysThreshold int) []models.CertRenewalCheck {
+	var targets []string
+	for _, domain := range domains {
+		if domain = cleanSSLDomain(domain); domain != "" {
+			targets = append(targets, domain)
+		}
+	}
+
+	checks := make([]models.CertRenewalCheck, len(targets))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentSSLChecks)
+	for i, domain := range targets {
+		wg.Add(1)
+		go func(i int, domain string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			checks[i] = checkCertRenewal(ctx, domain, daysThreshold)
+		}(i, domain)
+	}
+	wg.Wait()
+
+	return checks
+}
+
+// checkCertRenewal checks the certificate and ACME challenge path of one domain
+func checkCertRenewal(ctx context.Context, domain string, daysThreshold int) models.CertRenewalCheck {
+	host, _ := splitSSLTarget(domain)
+	check := models.CertRenewalCheck{Domain: host}
+
+	if sslInfo, err := checkSingleSSLContext(ctx, domain); err != nil {
+		check.SSLError = err.Error()
+		check.NeedsRenewal = true // An unreachable or invalid certificate needs attention
+	} else {
+		check.DaysLeft = sslInfo.DaysLeft
+		check.NeedsRenewal = sslInfo.DaysLeft <= daysThreshold
+	}
+
+	check.HTTPStatusCode, check.HTTPError = probeACMEChallenge(ctx, host)
+	check.HTTPReachable = check.HTTPStatusCode == http.StatusNotFound
+
+	return check
+}
+
+// probeACMEChallenge requests a random HTTP-01 challenge token on port 80
+// A 404 means the challenge path is served (the token doesn't exist)
+func probeACMEChallenge(ctx context.Context, host string) (int, string) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return 0, fmt.Sprintf("failed to generate probe token: %v", err)
+	}
+	url := fmt.Sprintf("http://%s/.well-known/acme-challenge/probe-%s", net.JoinHostPort(host, "80"), hex.EncodeToString(token))
+
+	// Redirects are followed, like ACME validation servers do
+	client := &http.Client{Timeout: acmeProbeTimeout}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err.Error()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode != http.StatusNotFound {
+		return resp.StatusCode, fmt.Sprintf("expected HTTP 404, got %d", resp.StatusCode)
+	}
+	return resp.StatusCode, ""
+}
@@ -0,0 +1,77 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// stalledListener accepts connections but never answers, like a hung TLS server
+func stalledListener(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		var conns []net.Conn
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				// Listener closed at the end of the test
+				for _, c := range conns {
+					c.Close()
+				}
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestCheckCertRenewalOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	domains := []string{"https://a.invalid/", "", "b.invalid:8443", "c.invalid"}
+	checks := CheckCertRenewal(ctx, domains, 30)
+
+	want := []string{"a.invalid", "b.invalid", "c.invalid"}
+	if len(checks) != len(want) {
+		t.Fatalf("CheckCertRenewal() returned %d checks, want %d: %+v", len(checks), len(want), checks)
+	}
+	for i, domain := range want {
+		if checks[i].Domain != domain {
+			t.Errorf("check %d domain = %q, want %q", i, checks[i].Domain, domain)
+		}
+		if checks[i].SSLError == "" || !checks[i].NeedsRenewal || checks[i].HTTPReachable {
+			t.Errorf("check %d with a cancelled context = %+v, want failed", i, checks[i])
+		}
+	}
+}
+
+func TestCheckCertRenewalHonorsContext(t *testing.T) {
+	// Every TLS handshake stalls; without the context each would take the full 5s
+	address := stalledListener(t)
+	domains := make([]string, 2*maxConcurrentSSLChecks)
+	for i := range domains {
+		domains[i] = address
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	checks := CheckCertRenewal(ctx, domains, 30)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("CheckCertRenewal() took %v after the context expired", elapsed)
+	}
+	for i, check := range checks {
+		if check.SSLError == "" {
+			t.Errorf("check %d = %+v, want an SSL error", i, check)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+// DefaultPortCacheMaxAge is how long a port scan is reused by default
+const DefaultPortCacheMaxAge = 10 * time.Second
+
+// PortScanner caches GetOpenPorts results so back-to-back collections
+// (e.g. a command-triggered collection right after a cycle) don't rescan
+type PortScanner struct {
+	mu          sync.Mutex
+	cacheMaxAge time.Duration
+	cached      []models.PortInfo
+	cachedKey   string // Port filter the cached result was scanned with
+	scannedAt   time.Time
+}
+
+// NewPortScanner creates a scanner that reuses results for up to cacheMaxAge
+// A non-positive cacheMaxAge uses DefaultPortCacheMaxAge
+func NewPortScanner(cacheMaxAge time.Duration) *PortScanner {
+	if cacheMaxAge <= 0 {
+		cacheMaxAge = DefaultPortCacheMaxAge
+	}
+	return &PortScanner{cacheMaxAge: cacheMaxAge}
+}
+
+// Scan returns open ports, from the cache if it is fresh enough
+func (s *PortScanner) Scan(portsToMonitor []int) ([]models.PortInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprint(portsToMonitor)
+	if s.cached != nil && s.cachedKey == key && time.Since(s.scannedAt) < s.cacheMaxAge {
+		return append([]models.PortInfo{}, s.cached...), nil
+	}
+
+	ports, err := GetOpenPorts(portsToMonitor)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cached = ports
+	s.cachedKey = key
+	s.scannedAt = time.Now()
+	return append([]models.PortInfo{}, ports...), nil
+}
+
+// Invalidate drops the cached result so the next Scan rescans
+func (s *PortScanner) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cached = nil
+}
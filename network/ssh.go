@@ -0,0 +1,86 @@
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"vpsentinel-agent/models"
+)
+
+const sshdConfigPath = "/etc/ssh/sshd_config"
+
+// CheckSSHConfig audits the SSH daemon configuration for common hardening issues
+// Values not set in the file are reported with OpenSSH defaults
+func CheckSSHConfig() (*models.SSHConfigAudit, error) {
+	file, err := os.Open(sshdConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sshd config: %w", err)
+	}
+	defer file.Close()
+
+	// OpenSSH defaults: root login allowed with keys, passwords allowed, port 22
+	audit := &models.SSHConfigAudit{
+		PermitRootLogin:        true,
+		PasswordAuthentication: true,
+		Port:                   22,
+		AllowUsers:             []string{},
+		ListenAddresses:        []string{},
+	}
+
+	// sshd uses the first value obtained for single-value keywords
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Keyword and arguments may be separated by whitespace or "="
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+		if len(fields) < 2 {
+			continue
+		}
+		keyword := strings.ToLower(fields[0])
+		value := strings.ToLower(fields[1])
+
+		// Match blocks only apply conditionally, stop at the first one
+		if keyword == "match" {
+			break
+		}
+
+		switch keyword {
+		case "permitrootlogin":
+			if !seen[keyword] {
+				// "prohibit-password" and "forced-commands-only" still allow root logins
+				audit.PermitRootLogin = value != "no"
+			}
+		case "passwordauthentication":
+			if !seen[keyword] {
+				audit.PasswordAuthentication = value == "yes"
+			}
+		case "port":
+			if !seen[keyword] {
+				if port, err := strconv.Atoi(value); err == nil {
+					audit.Port = port
+				}
+			}
+		case "allowusers":
+			// AllowUsers accumulates across lines
+			audit.AllowUsers = append(audit.AllowUsers, fields[1:]...)
+		case "listenaddress":
+			audit.ListenAddresses = append(audit.ListenAddresses, fields[1])
+		}
+		seen[keyword] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sshd config: %w", err)
+	}
+
+	return audit, nil
+}
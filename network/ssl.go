@@ -4,56 +4,99 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"strings"
+	"sync"
 	"time"
 
 	"vpsentinel-agent/models"
 )
 
-// CheckSSL checks SSL certificate expiration for multiple domains
-// Returns SSL information for each successfully checked domain
-func CheckSSL(domains []string) ([]models.SSLInfo, error) {
-	if len(domains) == 0 {
-		return []models.SSLInfo{}, nil
-	}
-
-	var results []models.SSLInfo
-	var errors []error
+// maxConcurrentSSLChecks limits how many certificates are fetched at once
+const maxConcurrentSSLChecks = 5
 
-	// Check each domain (sequentially to avoid overwhelming network)
-	// In the future, this could be parallelized with a limit
+// CheckSSL checks SSL certificate expiration for multiple domains
+// Returns one result per domain in the configured order; failed checks carry
+// the failure in Error. An error is returned only if every domain failed
+func CheckSSL(domains []string) ([]models.SSLCheckResult, error) {
+	var targets []string
 	for _, domain := range domains {
-		// Clean domain (remove protocol if present)
-		domain = strings.TrimPrefix(domain, "https://")
-		domain = strings.TrimPrefix(domain, "http://")
-		domain = strings.TrimSuffix(domain, "/")
-		domain = strings.TrimSpace(domain)
-
-		if domain == "" {
-			continue
+		if domain = cleanSSLDomain(domain); domain != "" {
+			targets = append(targets, domain)
 		}
+	}
 
-		sslInfo, err := checkSingleSSL(domain)
-		if err != nil {
-			errors = append(errors, fmt.Errorf("domain %s: %w", domain, err))
-			continue // Continue with other domains
-		}
+	results := make([]models.SSLCheckResult, len(targets))
+	if len(targets) == 0 {
+		return results, nil
+	}
 
-		if sslInfo != nil {
-			results = append(results, *sslInfo)
-		}
+	// Check domains in parallel, with a limit to avoid overwhelming the network
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentSSLChecks)
+	for i, domain := range targets {
+		wg.Add(1)
+		go func(i int, domain string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			sslInfo, err := checkSingleSSL(domain)
+			if err != nil {
+				host, _ := splitSSLTarget(domain)
+				results[i] = models.SSLCheckResult{
+					SSLInfo: models.SSLInfo{Domain: host},
+					Error:   err.Error(),
+				}
+				return
+			}
+			results[i] = models.SSLCheckResult{SSLInfo: *sslInfo}
+		}(i, domain)
 	}
+	wg.Wait()
 
-	// Return first error if any occurred, but still return partial results
-	if len(errors) > 0 && len(results) == 0 {
-		return results, errors[0]
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	if failed == len(results) {
+		return results, fmt.Errorf("domain %s: %s", results[0].Domain, results[0].Error)
 	}
 
 	return results, nil
 }
 
+// cleanSSLDomain strips the protocol, path and query from a configured domain, keeping an explicit port
+func cleanSSLDomain(domain string) string {
+	domain = strings.TrimSpace(domain)
+	domain = strings.TrimPrefix(domain, "https://")
+	domain = strings.TrimPrefix(domain, "http://")
+	if i := strings.IndexAny(domain, "/?#"); i >= 0 {
+		domain = domain[:i]
+	}
+	return domain
+}
+
+// splitSSLTarget returns the host and dial address of a domain
+// domain may include a port ("example.com:8443"); 443 is used otherwise
+func splitSSLTarget(domain string) (string, string) {
+	host, port, err := net.SplitHostPort(domain)
+	if err != nil {
+		host, port = strings.Trim(domain, "[]"), "443"
+	}
+	return host, net.JoinHostPort(host, port)
+}
+
 // checkSingleSSL checks SSL certificate for a single domain
+// domain may include a port ("example.com:8443"); 443 is used otherwise
 func checkSingleSSL(domain string) (*models.SSLInfo, error) {
+	return checkSingleSSLContext(context.Background(), domain)
+}
+
+// checkSingleSSLContext is checkSingleSSL with cancellation
+func checkSingleSSLContext(parent context.Context, domain string) (*models.SSLInfo, error) {
 	// Connect with timeout
 	dialer := &tls.Dialer{
 		Config: &tls.Config{
@@ -61,14 +104,11 @@ func checkSingleSSL(domain string) (*models.SSLInfo, error) {
 		},
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
 	defer cancel()
 
 	// Add port if not present
-	address := domain
-	if !strings.Contains(domain, ":") {
-		address = domain + ":443"
-	}
+	host, address := splitSSLTarget(domain)
 
 	// Establish TLS connection
 	conn, err := dialer.DialContext(ctx, "tcp", address)
@@ -102,7 +142,7 @@ func checkSingleSSL(domain string) (*models.SSLInfo, error) {
 	}
 
 	return &models.SSLInfo{
-		Domain:     domain,
+		Domain:     host,
 		ValidFrom:  cert.NotBefore,
 		ValidUntil: cert.NotAfter,
 		DaysLeft:   daysLeft,
@@ -0,0 +1,77 @@
+package network
+
+import "testing"
+
+func TestCleanSSLDomain(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"example.com", "example.com"},
+		{"  example.com  ", "example.com"},
+		{"https://example.com", "example.com"},
+		{"http://example.com", "example.com"},
+		{"https://example.com/", "example.com"},
+		{"https://example.com/login?next=/", "example.com"},
+		{"https://example.com?x=1", "example.com"},
+		{"https://example.com#top", "example.com"},
+		{"https://example.com:8443/health", "example.com:8443"},
+		{"example.com:8443", "example.com:8443"},
+		{"https://[2001:db8::1]:8443/", "[2001:db8::1]:8443"},
+		{"2001:db8::1", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		if got := cleanSSLDomain(tt.domain); got != tt.want {
+			t.Errorf("cleanSSLDomain(%q) = %q, want %q", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestSplitSSLTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		domain   string
+		wantHost string
+		wantAddr string
+	}{
+		{"host", "example.com", "example.com", "example.com:443"},
+		{"host and port", "example.com:8443", "example.com", "example.com:8443"},
+		{"IPv4", "192.0.2.10", "192.0.2.10", "192.0.2.10:443"},
+		{"IPv4 and port", "192.0.2.10:8443", "192.0.2.10", "192.0.2.10:8443"},
+		{"bare IPv6", "2001:db8::1", "2001:db8::1", "[2001:db8::1]:443"},
+		{"bracketed IPv6", "[2001:db8::1]", "2001:db8::1", "[2001:db8::1]:443"},
+		{"IPv6 and port", "[2001:db8::1]:8443", "2001:db8::1", "[2001:db8::1]:8443"},
+		{"IPv6 loopback", "::1", "::1", "[::1]:443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, addr := splitSSLTarget(tt.domain)
+			if host != tt.wantHost || addr != tt.wantAddr {
+				t.Errorf("splitSSLTarget(%q) = (%q, %q), want (%q, %q)", tt.domain, host, addr, tt.wantHost, tt.wantAddr)
+			}
+		})
+	}
+}
+
+func TestSSLTargetFromURL(t *testing.T) {
+	// Configured domains go through both steps before dialing
+	tests := []struct {
+		domain   string
+		wantHost string
+		wantAddr string
+	}{
+		{"https://example.com/path", "example.com", "example.com:443"},
+		{"https://example.com:8443/path?q=1", "example.com", "example.com:8443"},
+		{"https://[2001:db8::1]/", "2001:db8::1", "[2001:db8::1]:443"},
+		{"https://[2001:db8::1]:8443/", "2001:db8::1", "[2001:db8::1]:8443"},
+	}
+
+	for _, tt := range tests {
+		host, addr := splitSSLTarget(cleanSSLDomain(tt.domain))
+		if host != tt.wantHost || addr != tt.wantAddr {
+			t.Errorf("splitSSLTarget(cleanSSLDomain(%q)) = (%q, %q), want (%q, %q)", tt.domain, host, addr, tt.wantHost, tt.wantAddr)
+		}
+	}
+}
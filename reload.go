@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"vpsentinel-agent/config"
+	"vpsentinel-agent/logging"
+	"vpsentinel-agent/logs"
+	"vpsentinel-agent/metrics"
+)
+
+// liveConfig holds the config used by the collection loop
+// It is replaced by the soft_reload command; transport settings and
+// listeners (backend, TLS, StatsD, health endpoint) still need a restart
+var liveConfig atomic.Pointer[config.Config]
+
+// applyRuntimeConfig applies the settings that can change without a restart
+// and makes cfg the config used by the next collection cycle
+func applyRuntimeConfig(cfg *config.Config) error {
+	// Some log formats need less aggressive scrubbing
+	if err := logs.SetDisabledSanitizeRules(cfg.DisableSanitizeRules); err != nil {
+		return fmt.Errorf("invalid disable_sanitize_rules: %w", err)
+	}
+
+	// Keep snap loop devices and similar mounts out of disk metrics
+	metrics.SetDiskFilters(cfg.ExcludeMountPoints, cfg.ExcludeFSTypes)
+
+	// Keep loopback and container bridges out of network totals
+	metrics.SetInterfaceFilters(cfg.ExcludeNetworkInterfaces)
+
+	if previous := liveConfig.Swap(cfg); previous != nil {
+		logging.Infof("Config reloaded: interval=%ds", cfg.IntervalSeconds)
+	}
+	return nil
+}
+
+// persistRotatedAPIKey saves a key the client rotated to as the primary api_key
+// so the agent keeps using it after a restart
+func persistRotatedAPIKey(configPath, apiKey string) {
+	cfg, err := config.LoadForUpdate(configPath)
+	if errors.Is(err, config.ErrNoConfigFile) {
+		logging.Warnf("Rotated API key can't be saved without a config file (update VPSENTINEL_API_KEY manually)")
+		return
+	}
+	if err != nil {
+		logging.Warnf("Failed to save rotated API key (update api_key manually): %v", err)
+		return
+	}
+	cfg.PromoteAPIKey(apiKey)
+	if err := config.SaveWithBackup(configPath, cfg); err != nil {
+		logging.Warnf("Failed to save rotated API key (update api_key manually): %v", err)
+		return
+	}
+	logging.Infof("Rotated API key saved to config")
+}
@@ -0,0 +1,61 @@
+package security
+
+import (
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+const (
+	etcDir = "/etc"
+
+	// maxEtcFileSize skips large files (databases, caches) that aren't config
+	maxEtcFileSize = 1024 * 1024
+	// maxModifiedFiles caps the number of files reported per cycle
+	maxModifiedFiles = 200
+)
+
+// CheckEtcChanges finds files under /etc modified within the given duration
+func CheckEtcChanges(since time.Duration) ([]models.ModifiedFile, error) {
+	cutoff := time.Now().Add(-since)
+	var files []models.ModifiedFile
+
+	err := filepath.WalkDir(etcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable directories but keep walking
+			if d != nil && d.IsDir() && path != etcDir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if len(files) >= maxModifiedFiles {
+			return fs.SkipAll
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.Size() > maxEtcFileSize || info.ModTime().Before(cutoff) {
+			return nil
+		}
+
+		files = append(files, models.ModifiedFile{
+			Path:       path,
+			ModifiedAt: info.ModTime().UTC(),
+			SizeBytes:  info.Size(),
+			Mode:       formatMode(info.Mode()),
+		})
+		return nil
+	})
+	if err != nil {
+		return files, err
+	}
+
+	return files, nil
+}
@@ -0,0 +1,145 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"vpsentinel-agent/models"
+)
+
+const (
+	// maxFileAuditEntries caps the number of files reported per cycle
+	maxFileAuditEntries = 500
+
+	// defaultFileAuditState is where previously seen files are recorded
+	defaultFileAuditState = "vpsentinel-file-audit.json"
+)
+
+// DefaultSUIDRoots are scanned when no roots are configured
+var DefaultSUIDRoots = []string{"/usr", "/bin", "/sbin"}
+
+// StatePath is the local file used to remember files seen in earlier scans
+var StatePath = defaultFileAuditState
+
+// CheckSUIDFiles walks the given roots and reports regular files that are
+// SUID or world-writable. Files not present in the previous scan are flagged as new,
+// and this scan becomes the new baseline.
+func CheckSUIDFiles(roots []string) ([]models.FileAuditEntry, error) {
+	return checkSUIDFiles(roots, true)
+}
+
+// CompareSUIDFiles reports files like CheckSUIDFiles without recording the scan
+// Used for on-demand collections so a new file is still flagged in the next scheduled cycle
+func CompareSUIDFiles(roots []string) ([]models.FileAuditEntry, error) {
+	return checkSUIDFiles(roots, false)
+}
+
+// checkSUIDFiles scans the roots and saves the seen files when record is set
+func checkSUIDFiles(roots []string, record bool) ([]models.FileAuditEntry, error) {
+	if len(roots) == 0 {
+		roots = DefaultSUIDRoots
+	}
+
+	var entries []models.FileAuditEntry
+	for _, root := range roots {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Skip unreadable directories but keep walking
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if len(entries) >= maxFileAuditEntries {
+				return fs.SkipAll
+			}
+			// Only regular files (symlinks are not followed)
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			mode := info.Mode()
+			if mode&os.ModeSetuid == 0 && mode.Perm()&0002 == 0 {
+				return nil
+			}
+
+			entries = append(entries, models.FileAuditEntry{
+				Path:  path,
+				Mode:  formatMode(mode),
+				Owner: fileOwner(info),
+			})
+			return nil
+		})
+	}
+
+	// Compare against the previous scan
+	previous, hadState := loadSeenFiles(StatePath)
+	current := make([]string, 0, len(entries))
+	for i := range entries {
+		// On the very first scan everything is baseline, not new
+		entries[i].IsNewSinceLast = hadState && !previous[entries[i].Path]
+		current = append(current, entries[i].Path)
+	}
+
+	if !record {
+		return entries, nil
+	}
+	if err := saveSeenFiles(StatePath, current); err != nil {
+		return entries, fmt.Errorf("failed to save file audit state: %w", err)
+	}
+
+	return entries, nil
+}
+
+// formatMode renders permissions in octal including special bits (e.g. "4755")
+func formatMode(mode os.FileMode) string {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return fmt.Sprintf("%04o", bits)
+}
+
+// loadSeenFiles reads the set of paths from the previous scan
+// The second return value is false when no state exists yet
+func loadSeenFiles(path string) (map[string]bool, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, false
+	}
+
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		seen[p] = true
+	}
+	return seen, true
+}
+
+// saveSeenFiles records the paths found in this scan
+func saveSeenFiles(path string, paths []string) error {
+	sort.Strings(paths)
+	data, err := json.Marshal(paths)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
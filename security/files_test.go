@@ -0,0 +1,98 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vpsentinel-agent/models"
+)
+
+// withStatePath points the file audit state at a temporary file
+func withStatePath(t *testing.T) {
+	t.Helper()
+	previous := StatePath
+	StatePath = filepath.Join(t.TempDir(), "state.json")
+	t.Cleanup(func() { StatePath = previous })
+}
+
+// writeWorldWritable creates a world-writable file in dir
+func writeWorldWritable(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newFiles returns the paths flagged as new since the last recorded scan
+func newFiles(t *testing.T, scan func([]string) ([]models.FileAuditEntry, error), root string) []string {
+	t.Helper()
+	entries, err := scan([]string{root})
+	if err != nil {
+		t.Fatalf("scan error = %v", err)
+	}
+	var paths []string
+	for _, e := range entries {
+		if e.IsNewSinceLast {
+			paths = append(paths, e.Path)
+		}
+	}
+	return paths
+}
+
+func TestCheckSUIDFilesFlagsNewFiles(t *testing.T) {
+	withStatePath(t)
+	root := t.TempDir()
+	writeWorldWritable(t, root, "old")
+
+	// The first scan is the baseline
+	if got := newFiles(t, CheckSUIDFiles, root); len(got) != 0 {
+		t.Errorf("first scan flagged %v as new", got)
+	}
+
+	added := writeWorldWritable(t, root, "added")
+	if got := newFiles(t, CheckSUIDFiles, root); len(got) != 1 || got[0] != added {
+		t.Errorf("second scan flagged %v, want [%s]", got, added)
+	}
+	if got := newFiles(t, CheckSUIDFiles, root); len(got) != 0 {
+		t.Errorf("third scan flagged %v as new", got)
+	}
+}
+
+func TestCompareSUIDFilesDoesNotRecord(t *testing.T) {
+	withStatePath(t)
+	root := t.TempDir()
+	writeWorldWritable(t, root, "old")
+	if _, err := CheckSUIDFiles([]string{root}); err != nil {
+		t.Fatalf("CheckSUIDFiles() error = %v", err)
+	}
+
+	// An on-demand scan sees the new file but leaves it new for the next scheduled scan
+	added := writeWorldWritable(t, root, "added")
+	for i := 0; i < 2; i++ {
+		if got := newFiles(t, CompareSUIDFiles, root); len(got) != 1 || got[0] != added {
+			t.Errorf("CompareSUIDFiles() flagged %v, want [%s]", got, added)
+		}
+	}
+	if got := newFiles(t, CheckSUIDFiles, root); len(got) != 1 || got[0] != added {
+		t.Errorf("CheckSUIDFiles() flagged %v, want [%s]", got, added)
+	}
+}
+
+func TestCompareSUIDFilesWithoutState(t *testing.T) {
+	withStatePath(t)
+	root := t.TempDir()
+	writeWorldWritable(t, root, "file")
+
+	if got := newFiles(t, CompareSUIDFiles, root); len(got) != 0 {
+		t.Errorf("CompareSUIDFiles() flagged %v without a baseline", got)
+	}
+	if _, err := os.Stat(StatePath); !os.IsNotExist(err) {
+		t.Errorf("CompareSUIDFiles() created the state file (stat error = %v)", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package security
+
+import "os"
+
+// fileOwner is not supported on Windows (ownership is ACL based)
+func fileOwner(info os.FileInfo) string {
+	return ""
+}
@@ -0,0 +1,62 @@
+package security
+
+import (
+	"fmt"
+	"sort"
+
+	"vpsentinel-agent/models"
+	"vpsentinel-agent/services"
+)
+
+// DetectUnusualPorts flags non-privileged ports (above 1024) where neither the
+// port nor the listening process matches a known service
+// Listeners reachable from other hosts are reported with a higher severity
+func DetectUnusualPorts(ports []models.PortInfo) []models.SecurityFinding {
+	findings := []models.SecurityFinding{}
+	seen := make(map[string]bool)
+
+	for _, port := range ports {
+		if port.Port <= 1024 {
+			continue
+		}
+		if services.ServiceTypeForPort(port.Port) != services.ServiceTypeUnknown {
+			continue
+		}
+		if services.ServiceTypeForProcess(port.Process) != services.ServiceTypeUnknown {
+			continue
+		}
+
+		// The same listener often appears once per protocol or address family
+		key := fmt.Sprintf("%d/%s", port.Port, port.Process)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		severity := "medium"
+		scope := "on all interfaces"
+		if isLoopbackAddress(port.ListenAddress) {
+			severity = "low"
+			scope = "on localhost"
+		}
+
+		findings = append(findings, models.SecurityFinding{
+			Severity:    severity,
+			Description: fmt.Sprintf("Unrecognized process %q listening on %s port %d %s", port.Process, port.Protocol, port.Port, scope),
+			Port:        port.Port,
+			Process:     port.Process,
+		})
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Port < findings[j].Port })
+	return findings
+}
+
+// isLoopbackAddress checks whether a listen address is only reachable locally
+func isLoopbackAddress(address string) bool {
+	switch address {
+	case "127.0.0.1", "::1", "localhost":
+		return true
+	}
+	return len(address) > 4 && address[:4] == "127."
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// maxConfigFiles caps how many config file paths are reported per service
+const maxConfigFiles = 10
+
+// serviceConfigPaths are well-known config file locations (globs allowed)
+var serviceConfigPaths = map[ServiceType][]string{
+	ServiceTypeNginx:        {"/etc/nginx/nginx.conf", "/etc/nginx/conf.d/*.conf", "/etc/nginx/sites-enabled/*"},
+	ServiceTypeApache:       {"/etc/apache2/apache2.conf", "/etc/httpd/conf/httpd.conf", "/etc/apache2/sites-enabled/*"},
+	ServiceTypeMySQL:        {"/etc/mysql/my.cnf", "/etc/my.cnf", "/etc/mysql/mysql.conf.d/*.cnf", "/etc/mysql/mariadb.conf.d/*.cnf"},
+	ServiceTypePostgreSQL:   {"/etc/postgresql/*/main/postgresql.conf", "/etc/postgresql/*/main/pg_hba.conf", "/var/lib/pgsql/data/postgresql.conf"},
+	ServiceTypeRedis:        {"/etc/redis/redis.conf", "/etc/redis.conf"},
+	ServiceTypeMongoDB:      {"/etc/mongod.conf"},
+	ServiceTypeDocker:       {"/etc/docker/daemon.json"},
+	ServiceTypeVault:        {"/etc/vault.d/vault.hcl"},
+	ServiceTypePrometheus:   {"/etc/prometheus/prometheus.yml"},
+	ServiceTypeGrafana:      {"/etc/grafana/grafana.ini"},
+	ServiceTypeAlertmanager: {"/etc/alertmanager/alertmanager.yml"},
+	ServiceTypeTraefik:      {"/etc/traefik/traefik.yml", "/etc/traefik/traefik.toml"},
+	ServiceTypeJenkins:      {"/etc/default/jenkins", "/etc/sysconfig/jenkins", "/var/lib/jenkins/config.xml"},
+}
+
+// findConfigFiles returns the existing config files of a service (at most maxConfigFiles)
+func findConfigFiles(serviceType ServiceType) []string {
+	var found []string
+	for _, pattern := range serviceConfigPaths[serviceType] {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		for _, path := range matches {
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+			found = append(found, path)
+			if len(found) == maxConfigFiles {
+				return found
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"strings"
+
+	"vpsentinel-agent/models"
+)
+
+// wellKnownPorts maps ports to the service expected to listen on them
+var wellKnownPorts = map[int]ServiceType{
+	3306:  ServiceTypeMySQL,
+	5432:  ServiceTypePostgreSQL,
+	6379:  ServiceTypeRedis,
+	27017: ServiceTypeMongoDB,
+	8200:  ServiceTypeVault,
+	9092:  ServiceTypeKafka,
+	2181:  ServiceTypeZookeeper,
+}
+
+// conflictExemptProcesses may legitimately listen on any well-known port
+var conflictExemptProcesses = map[string]bool{
+	"unknown":      true, // Process not visible (agent not running as root)
+	"docker-proxy": true, // Port published by a container
+	"java":         true, // JVM services can't be told apart by process name
+}
+
+// DetectPortConflicts reports well-known ports held by a process other than the expected service
+// Such ports point to misconfigured services or something impersonating a service
+func DetectPortConflicts(services []ServiceInfo, ports []models.PortInfo) []models.PortConflict {
+	// PIDs already attributed to a service (e.g. systemd MainPID)
+	servicePIDs := make(map[int]ServiceType)
+	for _, svc := range services {
+		if svc.PID > 0 {
+			servicePIDs[svc.PID] = svc.Type
+		}
+	}
+
+	var conflicts []models.PortConflict
+	for _, port := range ports {
+		expected, ok := wellKnownPorts[port.Port]
+		if !ok || port.Protocol != "tcp" {
+			continue
+		}
+
+		process := strings.ToLower(port.Process)
+		if process == "" || conflictExemptProcesses[process] {
+			continue
+		}
+		if detectByProcessName(process) == expected || servicePIDs[port.PID] == expected {
+			continue
+		}
+
+		conflicts = append(conflicts, models.PortConflict{
+			Port:            port.Port,
+			ExpectedService: getServiceName(expected),
+			ActualProcess:   port.Process,
+		})
+	}
+
+	return conflicts
+}
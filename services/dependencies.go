@@ -0,0 +1,68 @@
+package services
+
+import (
+	"vpsentinel-agent/models"
+)
+
+// Dependency edge types
+const (
+	EdgeRequires   = "requires"    // From can't serve requests without To
+	EdgeConnectsTo = "connects_to" // From commonly talks to To
+)
+
+// knownDependency describes a typical relationship between two service types
+type knownDependency struct {
+	from     ServiceType
+	to       ServiceType
+	edgeType string
+}
+
+// knownDependencies lists the relationships inferred when both services are present
+var knownDependencies = []knownDependency{
+	// Web servers hand PHP requests to PHP-FPM
+	{ServiceTypeNginx, ServiceTypePHP, EdgeRequires},
+	{ServiceTypeApache, ServiceTypePHP, EdgeRequires},
+
+	// Web servers reverse-proxy to application runtimes
+	{ServiceTypeNginx, ServiceTypeNodeJS, EdgeConnectsTo},
+	{ServiceTypeNginx, ServiceTypePython, EdgeConnectsTo},
+	{ServiceTypeApache, ServiceTypeNodeJS, EdgeConnectsTo},
+	{ServiceTypeApache, ServiceTypePython, EdgeConnectsTo},
+
+	// Application runtimes use databases and caches
+	{ServiceTypeNodeJS, ServiceTypeRedis, EdgeConnectsTo},
+	{ServiceTypeNodeJS, ServiceTypeMongoDB, EdgeConnectsTo},
+	{ServiceTypeNodeJS, ServiceTypeMySQL, EdgeConnectsTo},
+	{ServiceTypeNodeJS, ServiceTypePostgreSQL, EdgeConnectsTo},
+	{ServiceTypePython, ServiceTypeRedis, EdgeConnectsTo},
+	{ServiceTypePython, ServiceTypeMySQL, EdgeConnectsTo},
+	{ServiceTypePython, ServiceTypePostgreSQL, EdgeConnectsTo},
+	{ServiceTypePython, ServiceTypeMongoDB, EdgeConnectsTo},
+	{ServiceTypePHP, ServiceTypeMySQL, EdgeConnectsTo},
+	{ServiceTypePHP, ServiceTypePostgreSQL, EdgeConnectsTo},
+	{ServiceTypePHP, ServiceTypeRedis, EdgeConnectsTo},
+}
+
+// BuildDependencyGraph infers a service topology from the detected services
+// Edges are only added when both ends were detected on this host
+func BuildDependencyGraph(services []ServiceInfo) models.ServiceDependencyGraph {
+	present := make(map[ServiceType]bool)
+	for _, svc := range services {
+		if svc.Type != ServiceTypeUnknown && svc.Type != "" {
+			present[svc.Type] = true
+		}
+	}
+
+	graph := models.ServiceDependencyGraph{Edges: []models.ServiceEdge{}}
+	for _, dep := range knownDependencies {
+		if present[dep.from] && present[dep.to] {
+			graph.Edges = append(graph.Edges, models.ServiceEdge{
+				From: string(dep.from),
+				To:   string(dep.to),
+				Type: dep.edgeType,
+			})
+		}
+	}
+
+	return graph
+}
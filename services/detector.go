@@ -1,57 +1,87 @@
 package services
 
 import (
+	"errors"
 	"os/exec"
 	"regexp"
 	"strings"
+
+	"vpsentinel-agent/executor"
 )
 
 // ServiceType represents the type of service detected
 type ServiceType string
 
 const (
-	ServiceTypeDocker      ServiceType = "docker"
-	ServiceTypeNginx       ServiceType = "nginx"
-	ServiceTypeApache      ServiceType = "apache"
-	ServiceTypeMySQL       ServiceType = "mysql"
-	ServiceTypePostgreSQL  ServiceType = "postgresql"
-	ServiceTypeRedis       ServiceType = "redis"
-	ServiceTypeMongoDB     ServiceType = "mongodb"
-	ServiceTypeNodeJS      ServiceType = "nodejs"
-	ServiceTypePython      ServiceType = "python"
-	ServiceTypePHP         ServiceType = "php"
-	ServiceTypeUnknown     ServiceType = "unknown"
+	ServiceTypeDocker       ServiceType = "docker"
+	ServiceTypeNginx        ServiceType = "nginx"
+	ServiceTypeApache       ServiceType = "apache"
+	ServiceTypeMySQL        ServiceType = "mysql"
+	ServiceTypePostgreSQL   ServiceType = "postgresql"
+	ServiceTypeRedis        ServiceType = "redis"
+	ServiceTypeMongoDB      ServiceType = "mongodb"
+	ServiceTypeNodeJS       ServiceType = "nodejs"
+	ServiceTypePython       ServiceType = "python"
+	ServiceTypePHP          ServiceType = "php"
+	ServiceTypeVault        ServiceType = "vault"
+	ServiceTypeKafka        ServiceType = "kafka"
+	ServiceTypeZookeeper    ServiceType = "zookeeper"
+	ServiceTypePrometheus   ServiceType = "prometheus"
+	ServiceTypeGrafana      ServiceType = "grafana"
+	ServiceTypeAlertmanager ServiceType = "alertmanager"
+	ServiceTypeTraefik      ServiceType = "traefik"
+	ServiceTypeJenkins      ServiceType = "jenkins"
+	ServiceTypeUnknown      ServiceType = "unknown"
 )
 
+// cmdExecutor runs the external commands used for detection
+var cmdExecutor executor.Executor = executor.System{}
+
+// SetExecutor replaces the command executor (used to inject fixture output in tests)
+// Returns the previous executor so it can be restored
+func SetExecutor(e executor.Executor) executor.Executor {
+	previous := cmdExecutor
+	cmdExecutor = e
+	return previous
+}
+
 // ServiceInfo contains information about a detected service
 type ServiceInfo struct {
-	Type        ServiceType `json:"type"`
-	Name        string      `json:"name"`
-	Version     string      `json:"version,omitempty"`
-	IsRunning   bool        `json:"is_running"`
-	Port        int         `json:"port,omitempty"`
-	ProcessName string      `json:"process_name,omitempty"`
-	PID         int         `json:"pid,omitempty"`
+	Type         ServiceType `json:"type"`
+	Name         string      `json:"name"`
+	Version      string      `json:"version,omitempty"`
+	IsRunning    bool        `json:"is_running"`
+	Port         int         `json:"port,omitempty"`
+	ProcessName  string      `json:"process_name,omitempty"`
+	PID          int         `json:"pid,omitempty"`
+	IsSealed     bool        `json:"is_sealed,omitempty"` // Vault only: server is sealed
+	MemoryMB     uint64      `json:"memory_mb,omitempty"`
+	CPUPercent   float64     `json:"cpu_percent,omitempty"`
+	RouterCount  int         `json:"router_count,omitempty"`  // Traefik only: HTTP routers
+	ServiceCount int         `json:"service_count,omitempty"` // Traefik only: HTTP services
+	Mode         string      `json:"mode,omitempty"`          // Jenkins only: node mode (NORMAL or EXCLUSIVE)
+	Description  string      `json:"description,omitempty"`   // Jenkins only: node description
+	ConfigFiles  []string    `json:"config_files,omitempty"`  // Existing well-known config files
 }
 
 // DetectService detects what service is running based on process name, port, and system checks
 func DetectService(processName string, port int, pid int) ServiceInfo {
 	processNameLower := strings.ToLower(processName)
-	
+
 	// Detect by process name
 	serviceType := detectByProcessName(processNameLower)
-	
+
 	// Detect by port if process name didn't match
 	if serviceType == ServiceTypeUnknown {
 		serviceType = detectByPort(port)
 	}
-	
+
 	// Get version if possible
 	version := getServiceVersion(serviceType, processName)
-	
+
 	// Check if service is actually running
 	isRunning := checkServiceRunning(serviceType)
-	
+
 	return ServiceInfo{
 		Type:        serviceType,
 		Name:        getServiceName(serviceType),
@@ -63,13 +93,23 @@ func DetectService(processName string, port int, pid int) ServiceInfo {
 	}
 }
 
+// ServiceTypeForProcess returns the service a process name belongs to (ServiceTypeUnknown if none)
+func ServiceTypeForProcess(processName string) ServiceType {
+	return detectByProcessName(strings.ToLower(processName))
+}
+
+// ServiceTypeForPort returns the service normally listening on a port (ServiceTypeUnknown if none)
+func ServiceTypeForPort(port int) ServiceType {
+	return detectByPort(port)
+}
+
 // detectByProcessName detects service type from process name
 func detectByProcessName(processName string) ServiceType {
 	// Docker
 	if strings.Contains(processName, "docker") || strings.Contains(processName, "dockerd") || strings.Contains(processName, "containerd") {
 		return ServiceTypeDocker
 	}
-	
+
 	// Web servers
 	if strings.Contains(processName, "nginx") {
 		return ServiceTypeNginx
@@ -77,9 +117,9 @@ func detectByProcessName(processName string) ServiceType {
 	if strings.Contains(processName, "apache") || strings.Contains(processName, "httpd") {
 		return ServiceTypeApache
 	}
-	
+
 	// Databases
-	if strings.Contains(processName, "mysql") || strings.Contains(processName, "mysqld") {
+	if strings.Contains(processName, "mysql") || strings.Contains(processName, "mysqld") || strings.Contains(processName, "mariadb") {
 		return ServiceTypeMySQL
 	}
 	if strings.Contains(processName, "postgres") || strings.Contains(processName, "postmaster") {
@@ -91,9 +131,9 @@ func detectByProcessName(processName string) ServiceType {
 	if strings.Contains(processName, "mongod") || strings.Contains(processName, "mongo") {
 		return ServiceTypeMongoDB
 	}
-	
+
 	// Application runtimes
-	if strings.Contains(processName, "node") || strings.Contains(processName, "nodejs") {
+	if isNodeProcess(processName) {
 		return ServiceTypeNodeJS
 	}
 	if strings.Contains(processName, "python") || strings.Contains(processName, "python3") {
@@ -102,10 +142,55 @@ func detectByProcessName(processName string) ServiceType {
 	if strings.Contains(processName, "php") || strings.Contains(processName, "php-fpm") {
 		return ServiceTypePHP
 	}
-	
+
+	// Secrets management
+	if strings.Contains(processName, "vault") {
+		return ServiceTypeVault
+	}
+
+	// Message brokers (JVM processes, matched on their main class)
+	if strings.Contains(processName, "kafka.kafka") || strings.Contains(processName, "kafkaserver") {
+		return ServiceTypeKafka
+	}
+	if strings.Contains(processName, "org.apache.zookeeper") {
+		return ServiceTypeZookeeper
+	}
+
+	// Monitoring stack
+	if strings.Contains(processName, "alertmanager") {
+		return ServiceTypeAlertmanager
+	}
+	if strings.Contains(processName, "prometheus") {
+		return ServiceTypePrometheus
+	}
+	if strings.Contains(processName, "grafana") {
+		return ServiceTypeGrafana
+	}
+
+	// Reverse proxies
+	if strings.Contains(processName, "traefik") {
+		return ServiceTypeTraefik
+	}
+
+	// CI servers
+	if strings.Contains(processName, "jenkins") {
+		return ServiceTypeJenkins
+	}
+
 	return ServiceTypeUnknown
 }
 
+// isNodeProcess reports whether a process name is the Node.js runtime
+// ("node", "nodejs" or a versioned binary like "node18"); substring matching
+// would also claim node_exporter and similar tools
+func isNodeProcess(processName string) bool {
+	if processName == "node" || processName == "nodejs" {
+		return true
+	}
+	rest, ok := strings.CutPrefix(processName, "node")
+	return ok && rest != "" && strings.Trim(rest, "0123456789.") == ""
+}
+
 // detectByPort detects service type from common port numbers
 func detectByPort(port int) ServiceType {
 	switch port {
@@ -126,6 +211,16 @@ func detectByPort(port int) ServiceType {
 	case 2375, 2376:
 		// Docker daemon ports
 		return ServiceTypeDocker
+	case 8200:
+		return ServiceTypeVault
+	case 9092:
+		return ServiceTypeKafka
+	case 2181:
+		return ServiceTypeZookeeper
+	case 9090:
+		return ServiceTypePrometheus
+	case 9093:
+		return ServiceTypeAlertmanager
 	default:
 		return ServiceTypeUnknown
 	}
@@ -154,6 +249,22 @@ func getServiceName(serviceType ServiceType) string {
 		return "Python"
 	case ServiceTypePHP:
 		return "PHP"
+	case ServiceTypeVault:
+		return "Vault"
+	case ServiceTypeKafka:
+		return "Kafka"
+	case ServiceTypeZookeeper:
+		return "Zookeeper"
+	case ServiceTypePrometheus:
+		return "Prometheus"
+	case ServiceTypeGrafana:
+		return "Grafana"
+	case ServiceTypeAlertmanager:
+		return "Alertmanager"
+	case ServiceTypeTraefik:
+		return "Traefik"
+	case ServiceTypeJenkins:
+		return "Jenkins"
 	default:
 		return "Unknown Service"
 	}
@@ -161,75 +272,113 @@ func getServiceName(serviceType ServiceType) string {
 
 // getServiceVersion attempts to get the version of a service
 func getServiceVersion(serviceType ServiceType, processName string) string {
-	var cmd *exec.Cmd
-	
+	var command []string
+
 	switch serviceType {
 	case ServiceTypeDocker:
-		cmd = exec.Command("docker", "--version")
+		command = []string{"docker", "--version"}
 	case ServiceTypeNginx:
-		cmd = exec.Command("nginx", "-v")
+		command = []string{"nginx", "-v"}
 	case ServiceTypeApache:
-		cmd = exec.Command("apache2", "-v")
+		command = []string{"apache2", "-v"}
 	case ServiceTypeMySQL:
-		cmd = exec.Command("mysql", "--version")
+		command = []string{"mysql", "--version"}
 	case ServiceTypePostgreSQL:
-		cmd = exec.Command("psql", "--version")
+		command = []string{"psql", "--version"}
 	case ServiceTypeRedis:
-		cmd = exec.Command("redis-server", "--version")
+		command = []string{"redis-server", "--version"}
 	case ServiceTypeNodeJS:
-		cmd = exec.Command("node", "--version")
+		command = []string{"node", "--version"}
 	case ServiceTypePython:
-		cmd = exec.Command("python3", "--version")
+		command = []string{"python3", "--version"}
 	case ServiceTypePHP:
-		cmd = exec.Command("php", "--version")
+		command = []string{"php", "--version"}
+	case ServiceTypeVault:
+		command = []string{"vault", "version"}
+	case ServiceTypeKafka:
+		command = []string{"kafka-topics.sh", "--version"}
 	default:
 		return ""
 	}
-	
-	output, err := cmd.Output()
+
+	output, err := cmdExecutor.Output(command[0], command[1:]...)
 	if err != nil {
 		return ""
 	}
-	
+
 	// Extract version from output
 	versionRegex := regexp.MustCompile(`(\d+\.\d+\.\d+)`)
 	matches := versionRegex.FindStringSubmatch(string(output))
 	if len(matches) > 1 {
 		return matches[1]
 	}
-	
+
 	return strings.TrimSpace(string(output))
 }
 
 // checkServiceRunning checks if a service is actually running
 func checkServiceRunning(serviceType ServiceType) bool {
-	var cmd *exec.Cmd
-	
+	var command []string
+
 	switch serviceType {
 	case ServiceTypeDocker:
-		cmd = exec.Command("docker", "info")
+		command = []string{"docker", "info"}
 	case ServiceTypeNginx:
-		cmd = exec.Command("systemctl", "is-active", "--quiet", "nginx")
+		command = []string{"systemctl", "is-active", "--quiet", "nginx"}
 	case ServiceTypeApache:
-		cmd = exec.Command("systemctl", "is-active", "--quiet", "apache2")
+		command = []string{"systemctl", "is-active", "--quiet", "apache2"}
 	case ServiceTypeMySQL:
-		cmd = exec.Command("systemctl", "is-active", "--quiet", "mysql")
+		command = []string{"systemctl", "is-active", "--quiet", "mysql"}
 	case ServiceTypePostgreSQL:
-		cmd = exec.Command("systemctl", "is-active", "--quiet", "postgresql")
+		command = []string{"systemctl", "is-active", "--quiet", "postgresql"}
 	case ServiceTypeRedis:
-		cmd = exec.Command("systemctl", "is-active", "--quiet", "redis")
+		command = []string{"systemctl", "is-active", "--quiet", "redis"}
+	case ServiceTypeVault:
+		command = []string{"systemctl", "is-active", "--quiet", "vault"}
+	case ServiceTypeTraefik:
+		command = []string{"systemctl", "is-active", "--quiet", "traefik"}
+	case ServiceTypeJenkins:
+		command = []string{"systemctl", "is-active", "--quiet", "jenkins"}
 	default:
 		return true // Assume running if we can't check
 	}
-	
-	err := cmd.Run()
+
+	err := cmdExecutor.Run(command[0], command[1:]...)
+
+	// Containers and Alpine often have no systemd, look for the process instead
+	if err != nil && command[0] == "systemctl" && errors.Is(err, exec.ErrNotFound) {
+		return checkServiceRunningByProcess(serviceProcessPatterns[serviceType])
+	}
 	return err == nil
 }
 
+// serviceProcessPatterns are 'pgrep -f' patterns for each service's main process
+var serviceProcessPatterns = map[ServiceType][]string{
+	ServiceTypeNginx:      {"nginx: master process", "(^|/)nginx( |$)"},
+	ServiceTypeApache:     {"(^|/)(apache2|httpd)( |$)"},
+	ServiceTypeMySQL:      {"(^|/)(mysqld|mariadbd)( |$)"},
+	ServiceTypePostgreSQL: {"(^|/)(postgres|postmaster)( |$)"},
+	ServiceTypeRedis:      {"(^|/)redis-server( |$)"},
+	ServiceTypeVault:      {"(^|/)vault server"},
+	ServiceTypeTraefik:    {"(^|/)traefik( |$)"},
+	ServiceTypeJenkins:    {"jenkins\\.war"},
+}
+
+// checkServiceRunningByProcess checks whether any process matches one of the patterns
+func checkServiceRunningByProcess(processPatterns []string) bool {
+	for _, pattern := range processPatterns {
+		// pgrep exits 0 only if at least one process matched
+		if err := cmdExecutor.Run("pgrep", "-f", pattern); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // DetectAllServices scans the system for all running services
 func DetectAllServices() []ServiceInfo {
 	var services []ServiceInfo
-	
+
 	// Check for Docker
 	if checkServiceRunning(ServiceTypeDocker) {
 		services = append(services, ServiceInfo{
@@ -239,7 +388,7 @@ func DetectAllServices() []ServiceInfo {
 			IsRunning: true,
 		})
 	}
-	
+
 	// Check for web servers
 	if checkServiceRunning(ServiceTypeNginx) {
 		services = append(services, ServiceInfo{
@@ -257,7 +406,7 @@ func DetectAllServices() []ServiceInfo {
 			IsRunning: true,
 		})
 	}
-	
+
 	// Check for databases
 	if checkServiceRunning(ServiceTypeMySQL) {
 		services = append(services, ServiceInfo{
@@ -283,6 +432,46 @@ func DetectAllServices() []ServiceInfo {
 			IsRunning: true,
 		})
 	}
-	
+
+	// Check for secrets management
+	if vault, found := detectVault(); found {
+		services = append(services, vault)
+	}
+
+	// Check for message brokers
+	if kafka, found := detectKafka(); found {
+		services = append(services, kafka)
+	}
+	if zookeeper, found := detectZookeeper(); found {
+		services = append(services, zookeeper)
+	}
+
+	// Check for the monitoring stack
+	if prometheus, found := detectPrometheus(); found {
+		services = append(services, prometheus)
+	}
+	if grafana, found := detectGrafana(); found {
+		services = append(services, grafana)
+	}
+	if alertmanager, found := detectAlertmanager(); found {
+		services = append(services, alertmanager)
+	}
+
+	// Check for reverse proxies
+	if traefik, found := detectTraefik(); found {
+		services = append(services, traefik)
+	}
+
+	// Check for CI servers
+	if jenkins, found := detectJenkins(); found {
+		services = append(services, jenkins)
+	}
+
+	// Attach resource usage of each service's main process and its config files
+	for i := range services {
+		addResourceUsage(&services[i])
+		services[i].ConfigFiles = findConfigFiles(services[i].Type)
+	}
+
 	return services
 }
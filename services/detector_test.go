@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"vpsentinel-agent/executor"
+)
+
+// errExitStatus stands in for a command that ran and exited non-zero
+var errExitStatus = errors.New("exit status 3")
+
+// withExecutor swaps the package executor for the duration of a test
+func withExecutor(t *testing.T, e executor.Executor) {
+	t.Helper()
+	previous := cmdExecutor
+	SetExecutor(e)
+	t.Cleanup(func() { SetExecutor(previous) })
+}
+
+func TestCheckServiceRunning(t *testing.T) {
+	services := []struct {
+		serviceType ServiceType
+		check       string
+	}{
+		{ServiceTypeDocker, "docker info"},
+		{ServiceTypeNginx, "systemctl is-active --quiet nginx"},
+		{ServiceTypeApache, "systemctl is-active --quiet apache2"},
+		{ServiceTypeMySQL, "systemctl is-active --quiet mysql"},
+		{ServiceTypePostgreSQL, "systemctl is-active --quiet postgresql"},
+		{ServiceTypeRedis, "systemctl is-active --quiet redis"},
+		{ServiceTypeVault, "systemctl is-active --quiet vault"},
+		{ServiceTypeTraefik, "systemctl is-active --quiet traefik"},
+		{ServiceTypeJenkins, "systemctl is-active --quiet jenkins"},
+	}
+
+	for _, svc := range services {
+		t.Run(string(svc.serviceType), func(t *testing.T) {
+			cases := []struct {
+				name    string
+				results map[string]executor.MockResult
+				want    bool
+			}{
+				{"running", map[string]executor.MockResult{svc.check: {}}, true},
+				{"not running", map[string]executor.MockResult{svc.check: {Err: errExitStatus}}, false},
+				// No results at all: the check command and pgrep are both missing
+				{"not found", nil, false},
+			}
+			for _, tc := range cases {
+				t.Run(tc.name, func(t *testing.T) {
+					withExecutor(t, executor.NewMock(tc.results))
+					if got := checkServiceRunning(svc.serviceType); got != tc.want {
+						t.Errorf("checkServiceRunning(%s) = %v, want %v", svc.serviceType, got, tc.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestCheckServiceRunningUnchecked(t *testing.T) {
+	// Services without a status check are assumed to be running
+	mock := executor.NewMock(nil)
+	withExecutor(t, mock)
+
+	if !checkServiceRunning(ServiceTypeNodeJS) {
+		t.Error("checkServiceRunning(nodejs) = false, want true")
+	}
+	if calls := mock.Calls(); len(calls) != 0 {
+		t.Errorf("unexpected commands run: %v", calls)
+	}
+}
+
+func TestGetServiceVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceType ServiceType
+		results     map[string]executor.MockResult
+		want        string
+	}{
+		{
+			name:        "docker",
+			serviceType: ServiceTypeDocker,
+			results: map[string]executor.MockResult{
+				"docker --version": {Output: []byte("Docker version 24.0.7, build afdd53b\n")},
+			},
+			want: "24.0.7",
+		},
+		{
+			name:        "redis",
+			serviceType: ServiceTypeRedis,
+			results: map[string]executor.MockResult{
+				"redis-server --version": {Output: []byte("Redis server v=7.0.15 sha=00000000:0 malloc=jemalloc-5.3.0 bits=64\n")},
+			},
+			want: "7.0.15",
+		},
+		{
+			name:        "binary missing",
+			serviceType: ServiceTypeMySQL,
+			want:        "",
+		},
+		{
+			name:        "no version command",
+			serviceType: ServiceTypeGrafana,
+			want:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withExecutor(t, executor.NewMock(tt.results))
+			if got := getServiceVersion(tt.serviceType, string(tt.serviceType)); got != tt.want {
+				t.Errorf("getServiceVersion(%s) = %q, want %q", tt.serviceType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectByProcessName(t *testing.T) {
+	tests := []struct {
+		process string
+		want    ServiceType
+	}{
+		{"nginx", ServiceTypeNginx},
+		{"mysqld", ServiceTypeMySQL},
+		{"redis-server", ServiceTypeRedis},
+		{"postgres", ServiceTypePostgreSQL},
+		{"traefik", ServiceTypeTraefik},
+		{"node", ServiceTypeNodeJS},
+		{"nodejs", ServiceTypeNodeJS},
+		{"node18", ServiceTypeNodeJS},
+		{"node_exporter", ServiceTypeUnknown},
+		{"nodemanager", ServiceTypeUnknown},
+		{"sshd", ServiceTypeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.process, func(t *testing.T) {
+			if got := detectByProcessName(tt.process); got != tt.want {
+				t.Errorf("detectByProcessName(%q) = %s, want %s", tt.process, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckServiceRunningPgrepFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		results   map[string]executor.MockResult
+		want      bool
+		wantPgrep bool
+	}{
+		{
+			name: "systemctl missing, process found",
+			results: map[string]executor.MockResult{
+				"pgrep -f (^|/)redis-server( |$)": {},
+			},
+			want:      true,
+			wantPgrep: true,
+		},
+		{
+			name: "systemctl missing, no process",
+			results: map[string]executor.MockResult{
+				"pgrep -f (^|/)redis-server( |$)": {Err: errExitStatus},
+			},
+			want:      false,
+			wantPgrep: true,
+		},
+		{
+			// A unit that is merely inactive must not fall back to process scanning
+			name: "systemctl present, unit inactive",
+			results: map[string]executor.MockResult{
+				"systemctl is-active --quiet redis": {Err: errExitStatus},
+				"pgrep -f (^|/)redis-server( |$)":   {},
+			},
+			want:      false,
+			wantPgrep: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := executor.NewMock(tt.results)
+			withExecutor(t, mock)
+
+			if got := checkServiceRunning(ServiceTypeRedis); got != tt.want {
+				t.Errorf("checkServiceRunning(redis) = %v, want %v", got, tt.want)
+			}
+
+			usedPgrep := false
+			for _, call := range mock.Calls() {
+				if strings.HasPrefix(call, "pgrep ") {
+					usedPgrep = true
+				}
+			}
+			if usedPgrep != tt.wantPgrep {
+				t.Errorf("pgrep used = %v, want %v (calls: %v)", usedPgrep, tt.wantPgrep, mock.Calls())
+			}
+		})
+	}
+}
+
+func TestCheckServiceRunningPgrepPatterns(t *testing.T) {
+	// Each pattern is tried in order until one matches
+	mock := executor.NewMock(map[string]executor.MockResult{
+		"pgrep -f nginx: master process": {Err: errExitStatus},
+		"pgrep -f (^|/)nginx( |$)":       {},
+	})
+	withExecutor(t, mock)
+
+	if !checkServiceRunning(ServiceTypeNginx) {
+		t.Fatalf("checkServiceRunning(nginx) = false, want true (calls: %v)", mock.Calls())
+	}
+	want := []string{
+		"systemctl is-active --quiet nginx",
+		"pgrep -f nginx: master process",
+		"pgrep -f (^|/)nginx( |$)",
+	}
+	if got := mock.Calls(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// jenkinsPort is Jenkins' default HTTP port
+const jenkinsPort = 8080
+
+// jenkinsInfo is the subset of Jenkins' /api/json response we use
+type jenkinsInfo struct {
+	Mode            string `json:"mode"`
+	NodeDescription string `json:"nodeDescription"`
+}
+
+// detectJenkins detects a Jenkins server via systemd or its API on port 8080
+// Jenkins sends an X-Jenkins version header even when anonymous read is
+// disabled, so the mode and description are only set when /api/json is readable
+func detectJenkins() (ServiceInfo, bool) {
+	running := checkServiceRunning(ServiceTypeJenkins)
+
+	var version string
+	var info jenkinsInfo
+	apiAvailable := false
+	if probeTCP(jenkinsPort) {
+		version, apiAvailable = probeJenkinsAPI(&info)
+	}
+
+	// Port 8080 is common for other apps, so only the X-Jenkins header counts
+	if !running && version == "" {
+		return ServiceInfo{}, false
+	}
+
+	svc := ServiceInfo{
+		Type:      ServiceTypeJenkins,
+		Name:      getServiceName(ServiceTypeJenkins),
+		Version:   version,
+		IsRunning: true,
+	}
+	if version != "" {
+		svc.Port = jenkinsPort
+	}
+	if apiAvailable {
+		svc.Mode = info.Mode
+		svc.Description = info.NodeDescription
+	}
+	return svc, true
+}
+
+// probeJenkinsAPI requests /api/json and returns the X-Jenkins version header
+// and whether the response body could be decoded into info
+func probeJenkinsAPI(info *jenkinsInfo) (string, bool) {
+	client := &http.Client{Timeout: monitoringProbeTimeout}
+
+	resp, err := client.Get("http://localhost:8080/api/json")
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+
+	version := resp.Header.Get("X-Jenkins")
+	if version == "" || resp.StatusCode != 200 {
+		return version, false
+	}
+	return version, json.NewDecoder(resp.Body).Decode(info) == nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	kafkaPort     = 9092
+	zookeeperPort = 2181
+
+	tcpProbeTimeout = 2 * time.Second
+)
+
+// Java main classes identifying Kafka brokers and Zookeeper servers
+var (
+	kafkaCmdlineMarkers     = []string{"kafka.Kafka", "KafkaServer"}
+	zookeeperCmdlineMarkers = []string{"org.apache.zookeeper.server.quorum.QuorumPeerMain"}
+)
+
+// detectKafka detects a Kafka broker by its JVM command line or port 9092
+func detectKafka() (ServiceInfo, bool) {
+	pid := findProcessByCmdline(kafkaCmdlineMarkers)
+	if pid == 0 && !probeTCP(kafkaPort) {
+		return ServiceInfo{}, false
+	}
+
+	return ServiceInfo{
+		Type:      ServiceTypeKafka,
+		Name:      getServiceName(ServiceTypeKafka),
+		Version:   getServiceVersion(ServiceTypeKafka, "kafka"),
+		IsRunning: true,
+		Port:      kafkaPort,
+		PID:       pid,
+	}, true
+}
+
+// detectZookeeper detects a Zookeeper server by its JVM command line or port 2181
+func detectZookeeper() (ServiceInfo, bool) {
+	pid := findProcessByCmdline(zookeeperCmdlineMarkers)
+	if pid == 0 && !probeTCP(zookeeperPort) {
+		return ServiceInfo{}, false
+	}
+
+	return ServiceInfo{
+		Type:      ServiceTypeZookeeper,
+		Name:      getServiceName(ServiceTypeZookeeper),
+		IsRunning: true,
+		Port:      zookeeperPort,
+		PID:       pid,
+	}, true
+}
+
+// findProcessByCmdline returns the PID of the first process whose command line
+// contains one of the markers (0 if none is found or /proc is unavailable)
+func findProcessByCmdline(markers []string) int {
+	cmdlines, err := filepath.Glob("/proc/[0-9]*/cmdline")
+	if err != nil {
+		return 0
+	}
+
+	for _, path := range cmdlines {
+		data, err := os.ReadFile(path)
+		if err != nil || len(data) == 0 {
+			continue // Process exited or is a kernel thread
+		}
+
+		cmdline := string(data)
+		for _, marker := range markers {
+			if strings.Contains(cmdline, marker) {
+				pid, _ := strconv.Atoi(filepath.Base(filepath.Dir(path)))
+				return pid
+			}
+		}
+	}
+
+	return 0
+}
+
+// probeTCP checks whether something accepts connections on a local port
+func probeTCP(port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), tcpProbeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	prometheusPort   = 9090
+	grafanaPort      = 3000
+	alertmanagerPort = 9093
+
+	monitoringProbeTimeout = 2 * time.Second
+)
+
+// prometheusBuildInfo is the subset of /api/v1/status/buildinfo we use
+type prometheusBuildInfo struct {
+	Status string `json:"status"`
+	Data   struct {
+		Version string `json:"version"`
+	} `json:"data"`
+}
+
+// grafanaHealth is the subset of Grafana's /api/health response we use
+type grafanaHealth struct {
+	Database string `json:"database"`
+	Version  string `json:"version"`
+}
+
+// alertmanagerStatus is the subset of Alertmanager's /api/v2/status response we use
+type alertmanagerStatus struct {
+	VersionInfo struct {
+		Version string `json:"version"`
+	} `json:"versionInfo"`
+}
+
+// detectPrometheus detects a local Prometheus server via its build info API
+func detectPrometheus() (ServiceInfo, bool) {
+	if !probeTCP(prometheusPort) {
+		return ServiceInfo{}, false
+	}
+
+	var info prometheusBuildInfo
+	if !getLocalJSON("http://localhost:9090/api/v1/status/buildinfo", &info) || info.Status != "success" {
+		return ServiceInfo{}, false
+	}
+
+	return monitoringService(ServiceTypePrometheus, info.Data.Version, prometheusPort), true
+}
+
+// detectGrafana detects a local Grafana server via its health API
+// Port 3000 is also common for Node.js apps, so the response must look like Grafana's
+func detectGrafana() (ServiceInfo, bool) {
+	if !probeTCP(grafanaPort) {
+		return ServiceInfo{}, false
+	}
+
+	var health grafanaHealth
+	if !getLocalJSON("http://localhost:3000/api/health", &health) || health.Database == "" {
+		return ServiceInfo{}, false
+	}
+
+	return monitoringService(ServiceTypeGrafana, health.Version, grafanaPort), true
+}
+
+// detectAlertmanager detects a local Alertmanager via its status API
+func detectAlertmanager() (ServiceInfo, bool) {
+	if !probeTCP(alertmanagerPort) {
+		return ServiceInfo{}, false
+	}
+
+	var status alertmanagerStatus
+	if !getLocalJSON("http://localhost:9093/api/v2/status", &status) {
+		return ServiceInfo{}, false
+	}
+
+	return monitoringService(ServiceTypeAlertmanager, status.VersionInfo.Version, alertmanagerPort), true
+}
+
+// monitoringService builds the ServiceInfo for a detected monitoring service
+func monitoringService(serviceType ServiceType, version string, port int) ServiceInfo {
+	return ServiceInfo{
+		Type:      serviceType,
+		Name:      getServiceName(serviceType),
+		Version:   version,
+		IsRunning: true,
+		Port:      port,
+	}
+}
+
+// getLocalJSON fetches a local HTTP endpoint and decodes its JSON body
+// Returns false on connection errors, non-200 responses or invalid JSON
+func getLocalJSON(url string, v interface{}) bool {
+	client := &http.Client{Timeout: monitoringProbeTimeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return false
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v) == nil
+}
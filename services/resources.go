@@ -0,0 +1,116 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// trackedProcesses keeps process handles between cycles so CPU usage is
+// measured over the collection interval instead of the process lifetime
+var trackedProcesses struct {
+	sync.Mutex
+	byPID map[int32]*process.Process
+}
+
+// systemdUnit returns the systemd unit name for a service type
+func systemdUnit(serviceType ServiceType) string {
+	switch serviceType {
+	case ServiceTypeDocker:
+		return "docker"
+	case ServiceTypeNginx:
+		return "nginx"
+	case ServiceTypeApache:
+		return "apache2"
+	case ServiceTypeMySQL:
+		return "mysql"
+	case ServiceTypePostgreSQL:
+		return "postgresql"
+	case ServiceTypeRedis:
+		return "redis"
+	case ServiceTypeVault:
+		return "vault"
+	case ServiceTypeTraefik:
+		return "traefik"
+	case ServiceTypeJenkins:
+		return "jenkins"
+	default:
+		return ""
+	}
+}
+
+// mainPID looks up the main PID of a systemd unit (0 if unknown or not running)
+func mainPID(unit string) int {
+	output, err := cmdExecutor.Output("systemctl", "show", unit, "--property=MainPID")
+	if err != nil {
+		return 0
+	}
+
+	// Output format: MainPID=1234
+	value := strings.TrimPrefix(strings.TrimSpace(string(output)), "MainPID=")
+	pid, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// addResourceUsage fills in the PID, CPU and memory usage of a detected service
+func addResourceUsage(svc *ServiceInfo) {
+	if svc.PID == 0 {
+		unit := systemdUnit(svc.Type)
+		if unit == "" {
+			return
+		}
+		svc.PID = mainPID(unit)
+	}
+	if svc.PID <= 0 {
+		return
+	}
+
+	p := trackedProcess(int32(svc.PID))
+	if p == nil {
+		return
+	}
+
+	if mem, err := p.MemoryInfo(); err == nil {
+		svc.MemoryMB = mem.RSS / 1024 / 1024
+	}
+
+	// The first sample has no previous reading, so fall back to the lifetime average
+	if cpuPercent, err := p.Percent(0); err == nil && cpuPercent > 0 {
+		svc.CPUPercent = cpuPercent
+	} else if cpuPercent, err := p.CPUPercent(); err == nil {
+		svc.CPUPercent = cpuPercent
+	}
+}
+
+// trackedProcess returns a cached process handle, creating one if needed
+func trackedProcess(pid int32) *process.Process {
+	trackedProcesses.Lock()
+	defer trackedProcesses.Unlock()
+
+	if trackedProcesses.byPID == nil {
+		trackedProcesses.byPID = make(map[int32]*process.Process)
+	}
+	if p, ok := trackedProcesses.byPID[pid]; ok {
+		return p
+	}
+
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return nil
+	}
+
+	// Drop handles for processes that have exited (PIDs change on restart)
+	for oldPID, old := range trackedProcesses.byPID {
+		if running, err := old.IsRunning(); err != nil || !running {
+			delete(trackedProcesses.byPID, oldPID)
+		}
+	}
+
+	trackedProcesses.byPID[pid] = p
+	return p
+}
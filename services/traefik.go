@@ -0,0 +1,53 @@
+package services
+
+// traefikAPIPort is the default port of Traefik's API and dashboard
+const traefikAPIPort = 8080
+
+// traefikOverview is the subset of Traefik's /api/overview response we use
+type traefikOverview struct {
+	HTTP struct {
+		Routers struct {
+			Total int `json:"total"`
+		} `json:"routers"`
+		Services struct {
+			Total int `json:"total"`
+		} `json:"services"`
+	} `json:"http"`
+}
+
+// traefikVersion is the subset of Traefik's /api/version response we use
+type traefikVersion struct {
+	Version string `json:"Version"`
+}
+
+// detectTraefik detects a Traefik proxy via systemd or its API on port 8080
+// Router and service counts are only available when the API is enabled
+func detectTraefik() (ServiceInfo, bool) {
+	running := checkServiceRunning(ServiceTypeTraefik)
+
+	// Port 8080 is common for other apps, so the response must look like Traefik's
+	var overview traefikOverview
+	apiAvailable := probeTCP(traefikAPIPort) &&
+		getLocalJSON("http://localhost:8080/api/overview", &overview)
+	if !running && !apiAvailable {
+		return ServiceInfo{}, false
+	}
+
+	svc := ServiceInfo{
+		Type:      ServiceTypeTraefik,
+		Name:      getServiceName(ServiceTypeTraefik),
+		IsRunning: true,
+	}
+	if apiAvailable {
+		svc.Port = traefikAPIPort
+		svc.RouterCount = overview.HTTP.Routers.Total
+		svc.ServiceCount = overview.HTTP.Services.Total
+
+		// The overview has no version, it is served separately
+		var version traefikVersion
+		if getLocalJSON("http://localhost:8080/api/version", &version) {
+			svc.Version = version.Version
+		}
+	}
+	return svc, true
+}
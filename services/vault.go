@@ -0,0 +1,69 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	vaultHealthURL     = "http://localhost:8200/v1/sys/health"
+	vaultHealthTimeout = 2 * time.Second
+)
+
+// vaultHealth is the subset of Vault's /v1/sys/health response we use
+type vaultHealth struct {
+	Version     string `json:"version"`
+	Sealed      bool   `json:"sealed"`
+	Initialized bool   `json:"initialized"`
+	Standby     bool   `json:"standby"`
+}
+
+// checkVaultHealth queries the local Vault health endpoint
+// Vault answers with 200, 429, 472, 473, 501 or 503 depending on its state,
+// and the body is valid JSON in all of those cases
+func checkVaultHealth() (*vaultHealth, bool) {
+	client := &http.Client{Timeout: vaultHealthTimeout}
+
+	resp, err := client.Get(vaultHealthURL)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 200, 429, 472, 473, 501, 503:
+	default:
+		return nil, false
+	}
+
+	var health vaultHealth
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, false
+	}
+
+	return &health, true
+}
+
+// detectVault detects a HashiCorp Vault server via systemd or its health endpoint
+func detectVault() (ServiceInfo, bool) {
+	health, reachable := checkVaultHealth()
+	if !reachable && !checkServiceRunning(ServiceTypeVault) {
+		return ServiceInfo{}, false
+	}
+
+	info := ServiceInfo{
+		Type:      ServiceTypeVault,
+		Name:      getServiceName(ServiceTypeVault),
+		IsRunning: true,
+		Port:      8200,
+	}
+
+	if health != nil {
+		info.Version = health.Version
+		// A sealed Vault can't serve secrets, the backend treats this as critical
+		info.IsSealed = health.Sealed
+	}
+
+	return info, true
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"sync/atomic"
+
+	"vpsentinel-agent/logging"
+)
+
+// AgentState describes what the agent is currently doing
+type AgentState string
+
+const (
+	StateStarting     AgentState = "starting"      // Loading config and initializing
+	StateCollecting   AgentState = "collecting"    // Running a collection cycle
+	StateRetrying     AgentState = "retrying"      // Sending failed, retrying or waiting for the next cycle
+	StateShuttingDown AgentState = "shutting_down" // Stopping after a signal or stop command
+	StateIdle         AgentState = "idle"          // Waiting for the next cycle after a successful send
+)
+
+// agentState holds the current AgentState
+var agentState atomic.Value
+
+func init() {
+	agentState.Store(StateStarting)
+}
+
+// currentState returns the agent's current state
+func currentState() AgentState {
+	return agentState.Load().(AgentState)
+}
+
+// setState switches the agent to a new state and logs the transition
+func setState(state AgentState) {
+	previous := agentState.Swap(state).(AgentState)
+	if previous != state {
+		logging.Infof("Agent state: %s -> %s", previous, state)
+	}
+}
@@ -0,0 +1,122 @@
+package transport
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"vpsentinel-agent/logging"
+)
+
+// CircuitState represents the state of a circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "CLOSED"    // Requests flow normally
+	CircuitOpen     CircuitState = "OPEN"      // Requests are rejected until the open period ends
+	CircuitHalfOpen CircuitState = "HALF-OPEN" // A single trial request is allowed through
+
+	// Consecutive failures before the circuit opens
+	defaultFailureThreshold = 5
+	// Default time the circuit stays open before allowing a trial request
+	defaultOpenDuration = 60 * time.Second
+)
+
+// ErrCircuitOpen is returned when a send is skipped because the circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker is open, skipping send")
+
+// CircuitBreaker stops send attempts after repeated failures so callers
+// don't pile up against a backend that is down
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	state            CircuitState
+	failures         int
+	failureThreshold int
+	openDuration     time.Duration
+	openedAt         time.Time
+	trialInFlight    bool
+}
+
+// NewCircuitBreaker creates a circuit breaker that opens after failureThreshold
+// consecutive failures and stays open for openDuration
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = defaultFailureThreshold
+	}
+	if openDuration <= 0 {
+		openDuration = defaultOpenDuration
+	}
+
+	return &CircuitBreaker{
+		state:            CircuitClosed,
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+	}
+}
+
+// Allow reports whether a request may be attempted now
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.openDuration {
+			return false
+		}
+		cb.setState(CircuitHalfOpen)
+		cb.trialInFlight = true
+		return true
+	case CircuitHalfOpen:
+		// Only one trial request at a time
+		if cb.trialInFlight {
+			return false
+		}
+		cb.trialInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess records a successful request and closes the circuit
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.trialInFlight = false
+	if cb.state != CircuitClosed {
+		cb.setState(CircuitClosed)
+	}
+}
+
+// RecordFailure records a failed request and opens the circuit if needed
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	cb.trialInFlight = false
+
+	// A failed trial re-opens the circuit immediately
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.failureThreshold {
+		cb.openedAt = time.Now()
+		if cb.state != CircuitOpen {
+			cb.setState(CircuitOpen)
+		}
+	}
+}
+
+// State returns the current circuit state
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// setState transitions to a new state and logs it (caller must hold the lock)
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	logging.Infof("Circuit breaker: %s -> %s (consecutive failures: %d)", cb.state, state, cb.failures)
+	cb.state = state
+}
@@ -2,21 +2,27 @@ package transport
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	neturl "net/url"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 
+	"vpsentinel-agent/logging"
 	"vpsentinel-agent/models"
 )
 
 const (
 	// Retry configuration
-	maxRetries      = 5
-	initialDelay    = 1 * time.Second
-	maxDelay        = 60 * time.Second
+	maxRetries        = 5
+	initialDelay      = 1 * time.Second
+	maxDelay          = 60 * time.Second
 	backoffMultiplier = 2.0
 
 	// HTTP configuration
@@ -25,13 +31,25 @@ const (
 
 // Client handles HTTPS communication with the backend
 type Client struct {
-	url        string
-	apiKey     string
-	httpClient *http.Client
+	url           string
+	apiKeyMu      sync.RWMutex
+	apiKey        string
+	nextAPIKeys   []string // Keys to rotate to when the current one is deprecated
+	onKeyRotated  func(apiKey string)
+	httpClient    *http.Client
+	breaker       *CircuitBreaker
+	limiter       *rateLimiter
+	signingSecret []byte
+	userAgent     string
+	agentVersion  string
+	serializer    Serializer
+	customHeaders map[string]string
+	onRetry       func(attempt int)
 }
 
 // NewClient creates a new transport client
-func NewClient(url, apiKey string) *Client {
+// agentVersion is reported in the User-Agent header of every request
+func NewClient(url, apiKey, agentVersion string) *Client {
 	// Ensure URL ends with / for path concatenation
 	if url[len(url)-1] != '/' {
 		url += "/"
@@ -43,9 +61,165 @@ func NewClient(url, apiKey string) *Client {
 		httpClient: &http.Client{
 			Timeout: requestTimeout,
 		},
+		breaker:      NewCircuitBreaker(defaultFailureThreshold, defaultOpenDuration),
+		limiter:      newRateLimiter(defaultMaxRequestsPerSecond),
+		serializer:   JSONSerializer{},
+		agentVersion: agentVersion,
+		userAgent: fmt.Sprintf("VPSentinel-Agent/%s (go%s; %s/%s)",
+			agentVersion, strings.TrimPrefix(runtime.Version(), "go"), runtime.GOOS, runtime.GOARCH),
 	}
 }
 
+// setHeaders sets the headers shared by all backend requests
+// Custom headers are set first so they can't override the agent's own headers
+func (c *Client) setHeaders(req *http.Request) {
+	for name, value := range c.customHeaders {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey())
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+}
+
+// SetCircuitBreakerOpenDuration sets how long the circuit stays open after repeated failures
+func (c *Client) SetCircuitBreakerOpenDuration(d time.Duration) {
+	c.breaker = NewCircuitBreaker(defaultFailureThreshold, d)
+}
+
+// APIKey returns the API key currently used for requests
+func (c *Client) APIKey() string {
+	c.apiKeyMu.RLock()
+	defer c.apiKeyMu.RUnlock()
+	return c.apiKey
+}
+
+// SetAPIKey replaces the API key used for subsequent requests
+func (c *Client) SetAPIKey(apiKey string) {
+	c.apiKeyMu.Lock()
+	defer c.apiKeyMu.Unlock()
+	c.apiKey = apiKey
+}
+
+// SetRotationKeys sets the keys tried, in order, when the backend deprecates the current key
+// onRotated is called with the new key so it can be persisted
+func (c *Client) SetRotationKeys(keys []string, onRotated func(apiKey string)) {
+	c.apiKeyMu.Lock()
+	defer c.apiKeyMu.Unlock()
+	c.nextAPIKeys = keys
+	c.onKeyRotated = onRotated
+}
+
+// rotateAPIKey switches to the next rotation key
+// Returns false when there are no keys left to try
+func (c *Client) rotateAPIKey() bool {
+	c.apiKeyMu.Lock()
+	if len(c.nextAPIKeys) == 0 {
+		c.apiKeyMu.Unlock()
+		return false
+	}
+	c.apiKey = c.nextAPIKeys[0]
+	c.nextAPIKeys = c.nextAPIKeys[1:]
+	newKey, onRotated := c.apiKey, c.onKeyRotated
+	c.apiKeyMu.Unlock()
+
+	logging.Infof("API key deprecated by backend, switching to the next configured key")
+	if onRotated != nil {
+		onRotated(newKey)
+	}
+	return true
+}
+
+// VerifyAPIKey makes a test GET request with the given key and succeeds only on HTTP 200
+// verifyURL may be a path relative to the backend URL or an absolute URL on the backend host
+func (c *Client) VerifyAPIKey(verifyURL, apiKey string) error {
+	target, err := c.resolveBackendURL(verifyURL)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// resolveBackendURL resolves a URL against the backend URL and rejects other hosts
+// so credentials are never sent anywhere but the backend
+func (c *Client) resolveBackendURL(ref string) (string, error) {
+	base, err := neturl.Parse(c.url)
+	if err != nil {
+		return "", fmt.Errorf("invalid backend URL: %w", err)
+	}
+	parsed, err := neturl.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", ref, err)
+	}
+
+	resolved := base.ResolveReference(parsed)
+	if resolved.Scheme != "https" || resolved.Host != base.Host {
+		return "", fmt.Errorf("URL %q must use HTTPS on the backend host %s", ref, base.Host)
+	}
+
+	return resolved.String(), nil
+}
+
+// SetSerializer sets the encoding used for ingest payloads
+func (c *Client) SetSerializer(serializer Serializer) {
+	c.serializer = serializer
+}
+
+// reservedHeaders are set by the agent itself and can't be replaced by custom headers
+var reservedHeaders = []string{"Authorization", "Content-Type", "User-Agent", signatureHeader}
+
+// isReservedHeader reports whether name is one of reservedHeaders (case-insensitive)
+func isReservedHeader(name string) bool {
+	for _, reserved := range reservedHeaders {
+		if http.CanonicalHeaderKey(name) == http.CanonicalHeaderKey(reserved) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCustomHeaders sets extra headers sent with every backend request (e.g. X-Tenant-ID)
+// Reserved headers (authentication, signature, User-Agent) are ignored
+func (c *Client) SetCustomHeaders(headers map[string]string) {
+	custom := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if isReservedHeader(name) {
+			logging.Warnf("custom header %s is reserved by the agent, ignoring it", name)
+			continue
+		}
+		custom[name] = value
+	}
+	c.customHeaders = custom
+}
+
+// SetRetryCallback sets a function called before each send retry
+func (c *Client) SetRetryCallback(onRetry func(attempt int)) {
+	c.onRetry = onRetry
+}
+
+// SetSigningSecret enables HMAC-SHA256 signing of ingest requests
+func (c *Client) SetSigningSecret(secret string) {
+	c.signingSecret = []byte(secret)
+}
+
 // CheckCommands checks for pending commands from the backend
 func (c *Client) CheckCommands() ([]models.Command, error) {
 	url := c.url + "api/agent/commands"
@@ -54,10 +228,9 @@ func (c *Client) CheckCommands() ([]models.Command, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -95,10 +268,9 @@ func (c *Client) SendCommandResponse(commandID string, status, message string) e
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -112,6 +284,15 @@ func (c *Client) SendCommandResponse(commandID string, status, message string) e
 	return nil
 }
 
+// PayloadSize returns the encoded size of a payload in bytes
+func (c *Client) PayloadSize(payload models.Payload) (int, error) {
+	data, err := c.serializer.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return len(data), nil
+}
+
 // Send sends a payload to the backend with retry logic and exponential backoff
 func (c *Client) Send(payload models.Payload) error {
 	var lastErr error
@@ -120,14 +301,31 @@ func (c *Client) Send(payload models.Payload) error {
 		if attempt > 0 {
 			// Calculate backoff delay
 			delay := calculateBackoff(attempt)
-			log.Printf("Retrying after %v (attempt %d/%d)", delay, attempt+1, maxRetries)
+			logging.Infof("Retrying after %v (attempt %d/%d)", delay, attempt+1, maxRetries)
+			if c.onRetry != nil {
+				c.onRetry(attempt + 1)
+			}
 			time.Sleep(delay)
 		}
 
+		// Skip the attempt entirely while the backend is considered down
+		if !c.breaker.Allow() {
+			if lastErr != nil {
+				return fmt.Errorf("%w (last error: %v)", ErrCircuitOpen, lastErr)
+			}
+			return ErrCircuitOpen
+		}
+
 		err := c.sendRequest(payload)
+
+		// Retry right away with the next key while the backend reports the key as deprecated
+		for isKeyDeprecated(err) && c.rotateAPIKey() {
+			err = c.sendRequest(payload)
+		}
+		c.recordResult(err)
 		if err == nil {
 			if attempt > 0 {
-				log.Printf("Successfully sent after %d attempts", attempt+1)
+				logging.Infof("Successfully sent after %d attempts", attempt+1)
 			}
 			return nil
 		}
@@ -136,58 +334,98 @@ func (c *Client) Send(payload models.Payload) error {
 
 		// Don't retry on authentication errors (invalid API key)
 		if httpErr, ok := err.(*HTTPError); ok {
+			if isAgentNotFound(httpErr) {
+				logging.Warnf("backend no longer recognizes this agent (agent_not_found); re-register the server in the dashboard and update api_key")
+				return fmt.Errorf("%w: %v", ErrAgentNotFound, err)
+			}
 			if httpErr.StatusCode == 401 || httpErr.StatusCode == 403 {
-				log.Printf("Authentication error (status %d), stopping retries", httpErr.StatusCode)
+				logging.Errorf("Authentication failed (status %d), stopping retries", httpErr.StatusCode)
 				return err
 			}
 		}
 
-		log.Printf("Send attempt %d/%d failed: %v", attempt+1, maxRetries, err)
+		logging.Warnf("Send attempt %d/%d failed: %v", attempt+1, maxRetries, err)
 	}
 
 	return fmt.Errorf("failed to send after %d attempts: %w", maxRetries, lastErr)
 }
 
+// SendOnce makes a single ingest request bound to ctx, without retries
+// Unlike Send it ignores an open circuit breaker, but still records the outcome
+// Returns the HTTP status code (0 if no response was received)
+func (c *Client) SendOnce(ctx context.Context, payload models.Payload) (int, error) {
+	status, err := c.sendRequestContext(ctx, payload)
+	c.recordResult(err)
+	return status, err
+}
+
 // sendRequest performs a single HTTP request
 func (c *Client) sendRequest(payload models.Payload) error {
-	// Marshal payload to JSON
-	jsonData, err := json.Marshal(payload)
+	_, err := c.sendRequestContext(context.Background(), payload)
+	return err
+}
+
+// sendRequestContext performs a single HTTP request bound to ctx
+// Returns the HTTP status code (0 if no response was received)
+func (c *Client) sendRequestContext(ctx context.Context, payload models.Payload) (int, error) {
+	// Encode payload (JSON unless configured otherwise)
+	data, err := c.serializer.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return 0, fmt.Errorf("failed to marshal payload: %w", err)
 	}
+	logging.Debugf("Payload size: %d bytes (%s)", len(data), c.serializer.ContentType())
 
 	// Create HTTP request
 	url := c.url + "api/agent/ingest"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "VPSentinel-Agent/1.0")
+	c.setHeaders(req)
+	req.Header.Set("Content-Type", c.serializer.ContentType())
+
+	// Sign the body so the backend can reject tampered requests
+	if len(c.signingSecret) > 0 {
+		req.Header.Set(signatureHeader, signBody(c.signingSecret, data))
+	}
 
 	// Send request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return 0, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response body (for error messages)
 	body, _ := io.ReadAll(resp.Body)
+	logging.Debugf("Ingest response: HTTP %d", resp.StatusCode)
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return &HTTPError{
+		return resp.StatusCode, &HTTPError{
 			StatusCode: resp.StatusCode,
 			Status:     resp.Status,
 			Body:       string(body),
 		}
 	}
 
-	return nil
+	return resp.StatusCode, nil
+}
+
+// recordResult feeds the outcome of a request into the circuit breaker
+// Client errors (4xx) mean the backend is reachable, so they don't count as failures
+func (c *Client) recordResult(err error) {
+	if err == nil {
+		c.breaker.RecordSuccess()
+		return
+	}
+	if httpErr, ok := err.(*HTTPError); ok && httpErr.StatusCode < 500 {
+		c.breaker.RecordSuccess()
+		return
+	}
+	c.breaker.RecordFailure()
 }
 
 // calculateBackoff calculates the exponential backoff delay
@@ -199,6 +437,21 @@ func calculateBackoff(attempt int) time.Duration {
 	return time.Duration(delay)
 }
 
+// ErrAgentNotFound is returned when the backend has no record of this agent
+// (e.g. after a backend data reset), as opposed to a plain invalid API key
+var ErrAgentNotFound = errors.New("agent not registered with backend")
+
+// isAgentNotFound checks whether a 401 response reports an unknown agent
+func isAgentNotFound(httpErr *HTTPError) bool {
+	return httpErr.StatusCode == 401 && strings.Contains(httpErr.Body, "agent_not_found")
+}
+
+// isKeyDeprecated checks whether a request was rejected because its API key was rotated out
+func isKeyDeprecated(err error) bool {
+	httpErr, ok := err.(*HTTPError)
+	return ok && httpErr.StatusCode == 401 && strings.Contains(httpErr.Body, "key_deprecated")
+}
+
 // HTTPError represents an HTTP error response
 type HTTPError struct {
 	StatusCode int
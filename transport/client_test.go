@@ -0,0 +1,205 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"vpsentinel-agent/models"
+)
+
+// recordingServer is a fake HTTPS backend that keeps the headers of every request
+type recordingServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	headers map[string]http.Header // Keyed by request path
+}
+
+func newRecordingServer(t *testing.T) *recordingServer {
+	t.Helper()
+	s := &recordingServer{headers: make(map[string]http.Header)}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.headers[r.URL.Path] = r.Header.Clone()
+		s.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/agent/commands":
+			fmt.Fprint(w, "[]")
+		case "/api/agent/version":
+			fmt.Fprint(w, `{"version":"1.0.0"}`)
+		case "/api/agent/ping":
+			fmt.Fprint(w, `{"server_time":"2024-01-02T03:04:05Z"}`)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newClient returns a client for the fake backend that trusts its certificate
+func (s *recordingServer) newClient(agentVersion string) *Client {
+	client := NewClient(s.URL, "test-key", agentVersion)
+	client.httpClient = s.Client()
+	return client
+}
+
+// header returns the headers received on path
+func (s *recordingServer) header(t *testing.T, path string) http.Header {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.headers[path]
+	if !ok {
+		t.Fatalf("no request received on %s", path)
+	}
+	return h
+}
+
+// sendAllRequests calls every client method that talks to the backend
+func sendAllRequests(t *testing.T, client *Client) {
+	t.Helper()
+	if err := client.sendRequest(models.Payload{Host: "web-01"}); err != nil {
+		t.Fatalf("sendRequest() error = %v", err)
+	}
+	if _, err := client.CheckCommands(); err != nil {
+		t.Fatalf("CheckCommands() error = %v", err)
+	}
+	if err := client.SendCommandResponse("cmd-1", "success", "ok"); err != nil {
+		t.Fatalf("SendCommandResponse() error = %v", err)
+	}
+	if err := client.VerifyAPIKey("api/agent/verify", "new-key"); err != nil {
+		t.Fatalf("VerifyAPIKey() error = %v", err)
+	}
+	if _, _, err := client.CheckLatestVersion(); err != nil {
+		t.Fatalf("CheckLatestVersion() error = %v", err)
+	}
+	if _, _, err := client.Ping(); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+}
+
+// backendPaths are the paths hit by sendAllRequests
+var backendPaths = []string{
+	"/api/agent/ingest",
+	"/api/agent/commands",
+	"/api/agent/commands/respond",
+	"/api/agent/verify",
+	"/api/agent/version",
+	"/api/agent/ping",
+}
+
+func TestUserAgent(t *testing.T) {
+	server := newRecordingServer(t)
+	client := server.newClient("1.4.2")
+	sendAllRequests(t, client)
+
+	want := fmt.Sprintf("VPSentinel-Agent/1.4.2 (go%s; %s/%s)",
+		strings.TrimPrefix(runtime.Version(), "go"), runtime.GOOS, runtime.GOARCH)
+	for _, path := range backendPaths {
+		if got := server.header(t, path).Get("User-Agent"); got != want {
+			t.Errorf("%s: User-Agent = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCustomHeaders(t *testing.T) {
+	server := newRecordingServer(t)
+	client := server.newClient("1.4.2")
+	client.SetCustomHeaders(map[string]string{
+		"X-Tenant-ID": "tenant-42",
+		"X-Env":       "staging",
+	})
+	sendAllRequests(t, client)
+
+	for _, path := range backendPaths {
+		h := server.header(t, path)
+		if got := h.Get("X-Tenant-ID"); got != "tenant-42" {
+			t.Errorf("%s: X-Tenant-ID = %q, want %q", path, got, "tenant-42")
+		}
+		if got := h.Get("X-Env"); got != "staging" {
+			t.Errorf("%s: X-Env = %q, want %q", path, got, "staging")
+		}
+	}
+}
+
+func TestCustomHeadersCannotOverrideReserved(t *testing.T) {
+	tests := []struct {
+		name    string
+		signed  bool
+		headers map[string]string
+	}{
+		{
+			name: "canonical names",
+			headers: map[string]string{
+				"Authorization": "Bearer stolen",
+				"User-Agent":    "curl/8.0",
+				"Content-Type":  "text/plain",
+				signatureHeader: "sha256=forged",
+				"X-Tenant-ID":   "tenant-42",
+			},
+		},
+		{
+			name: "lower case names",
+			headers: map[string]string{
+				"authorization":          "Bearer stolen",
+				"user-agent":             "curl/8.0",
+				"x-vpsentinel-signature": "sha256=forged",
+				"X-Tenant-ID":            "tenant-42",
+			},
+		},
+		{
+			name:   "with signing enabled",
+			signed: true,
+			headers: map[string]string{
+				signatureHeader: "sha256=forged",
+				"X-Tenant-ID":   "tenant-42",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRecordingServer(t)
+			client := server.newClient("1.4.2")
+			if tt.signed {
+				client.SetSigningSecret("signing-secret")
+			}
+			client.SetCustomHeaders(tt.headers)
+			sendAllRequests(t, client)
+
+			for _, path := range backendPaths {
+				h := server.header(t, path)
+				wantAuth := "Bearer test-key"
+				if path == "/api/agent/verify" {
+					wantAuth = "Bearer new-key"
+				}
+				if got := h.Get("Authorization"); got != wantAuth {
+					t.Errorf("%s: Authorization = %q, want %q", path, got, wantAuth)
+				}
+				if got := h.Get("User-Agent"); !strings.HasPrefix(got, "VPSentinel-Agent/1.4.2 ") {
+					t.Errorf("%s: User-Agent = %q, want the agent's own", path, got)
+				}
+				if got := h.Get("Content-Type"); got != "application/json" {
+					t.Errorf("%s: Content-Type = %q, want %q", path, got, "application/json")
+				}
+				if got := h.Get(signatureHeader); got == "sha256=forged" {
+					t.Errorf("%s: %s was overridden by a custom header", path, signatureHeader)
+				}
+				if got := h.Get("X-Tenant-ID"); got != "tenant-42" {
+					t.Errorf("%s: X-Tenant-ID = %q, want %q", path, got, "tenant-42")
+				}
+			}
+
+			if tt.signed {
+				if got := server.header(t, "/api/agent/ingest").Get(signatureHeader); !strings.HasPrefix(got, signaturePrefix) {
+					t.Errorf("ingest: %s = %q, want a computed signature", signatureHeader, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// pingTimeout bounds a connectivity check, much shorter than regular requests
+const pingTimeout = 5 * time.Second
+
+// pingResponse is the body returned by GET /api/agent/ping
+type pingResponse struct {
+	ServerTime time.Time `json:"server_time"`
+}
+
+// Ping checks connectivity to the backend
+// Returns the round-trip time in milliseconds and the backend's clock
+func (c *Client) Ping() (int, time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url+"api/agent/ping", nil)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req)
+
+	start := time.Now()
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	latencyMs := int(time.Since(start).Milliseconds())
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return latencyMs, time.Time{}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result pingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return latencyMs, time.Time{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return latencyMs, result.ServerTime, nil
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"vpsentinel-agent/logging"
+)
+
+// NormalizeFingerprint converts a SHA-256 fingerprint to lowercase hex without separators
+// Accepts both "AB:CD:..." (openssl output) and plain hex forms
+func NormalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
+
+// SetTLSPins restricts backend connections to server leaf certificates with the
+// given SHA-256 fingerprints. Normal chain verification still applies.
+func (c *Client) SetTLSPins(pins []string) {
+	if len(pins) == 0 {
+		return
+	}
+
+	pinned := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		pinned[NormalizeFingerprint(pin)] = true
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("backend presented no certificates")
+			}
+
+			// rawCerts[0] is the leaf certificate
+			sum := sha256.Sum256(rawCerts[0])
+			fingerprint := hex.EncodeToString(sum[:])
+			if pinned[fingerprint] {
+				return nil
+			}
+
+			// Log the actual fingerprint so operators can update the pin after a legitimate rotation
+			logging.Errorf("TLS pin mismatch: backend certificate fingerprint is %s", fingerprint)
+			return fmt.Errorf("backend certificate fingerprint %s does not match any pinned fingerprint", fingerprint)
+		},
+	}
+	c.httpClient.Transport = transport
+}
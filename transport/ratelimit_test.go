@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitAll calls Wait perCaller times from each of goroutines concurrent callers
+// and returns the time of every granted request along with any errors
+// Fails the test instead of hanging if the limiter deadlocks
+func waitAll(t *testing.T, ctx context.Context, l *rateLimiter, goroutines, perCaller int) ([]time.Time, []error) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var granted []time.Time
+	var errs []error
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perCaller; j++ {
+				err := l.Wait(ctx)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					granted = append(granted, time.Now())
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("rate limiter deadlocked: waiters did not finish within 10s")
+	}
+
+	sort.Slice(granted, func(i, j int) bool { return granted[i].Before(granted[j]) })
+	return granted, errs
+}
+
+func TestRateLimiterConcurrent(t *testing.T) {
+	const (
+		rate       = 100.0
+		goroutines = 50
+		perCaller  = 4
+	)
+	l := newRateLimiter(rate)
+
+	start := time.Now()
+	granted, errs := waitAll(t, context.Background(), l, goroutines, perCaller)
+	elapsed := time.Since(start)
+
+	if len(errs) > 0 {
+		t.Fatalf("Wait() returned errors: %v", errs)
+	}
+	if len(granted) != goroutines*perCaller {
+		t.Fatalf("granted %d requests, want %d", len(granted), goroutines*perCaller)
+	}
+
+	// The first second's worth is a burst, the other 100 requests need a second of refill
+	minElapsed := time.Duration((goroutines*perCaller - rate) / rate * float64(time.Second))
+	if elapsed < minElapsed*9/10 {
+		t.Errorf("%d requests took %v, want at least %v at %v/s", len(granted), elapsed, minElapsed, rate)
+	}
+
+	// No window may see more than the burst plus what refilled during it
+	const window = 200 * time.Millisecond
+	maxInWindow := int(rate) + int(rate*window.Seconds()) + 1
+	for i := range granted {
+		n := sort.Search(len(granted), func(j int) bool { return granted[j].Sub(granted[i]) >= window })
+		if n-i > maxInWindow {
+			t.Fatalf("%d requests granted within %v, want at most %d", n-i, window, maxInWindow)
+		}
+	}
+
+	// After the burst is spent, the sustained rate holds
+	tail := granted[int(rate):]
+	tailRate := float64(len(tail)-1) / tail[len(tail)-1].Sub(tail[0]).Seconds()
+	if tailRate > rate*1.2 {
+		t.Errorf("sustained rate = %.1f/s, want at most %v/s", tailRate, rate)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l := newRateLimiter(1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	// The bucket is empty: every waiter blocks until its context is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	granted, errs := waitAll(t, ctx, l, 20, 1)
+	if len(granted) != 0 {
+		t.Errorf("granted %d requests with an empty bucket, want 0", len(granted))
+	}
+	if len(errs) != 20 {
+		t.Fatalf("got %d errors, want 20", len(errs))
+	}
+	for _, err := range errs {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Serializer encodes payloads for transmission
+type Serializer interface {
+	Marshal(v interface{}) ([]byte, error)
+	ContentType() string
+}
+
+// NewSerializer returns the serializer for a format name ("json" or "msgpack")
+func NewSerializer(format string) (Serializer, error) {
+	switch format {
+	case "", "json":
+		return JSONSerializer{}, nil
+	case "msgpack":
+		return MsgpackSerializer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown serialization format: %s", format)
+	}
+}
+
+// JSONSerializer encodes payloads as JSON
+type JSONSerializer struct{}
+
+// Marshal encodes v as JSON
+func (JSONSerializer) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// ContentType returns the JSON media type
+func (JSONSerializer) ContentType() string {
+	return "application/json"
+}
+
+// MsgpackSerializer encodes payloads as MessagePack
+// Values are converted through their JSON form first, so field names,
+// omitempty and time formatting match the JSON payload exactly
+type MsgpackSerializer struct{}
+
+// Marshal encodes v as MessagePack
+func (MsgpackSerializer) Marshal(v interface{}) ([]byte, error) {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber() // Keep integers exact
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := encodeMsgpack(&buf, generic); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ContentType returns the MessagePack media type
+func (MsgpackSerializer) ContentType() string {
+	return "application/msgpack"
+}
+
+// encodeMsgpack writes a JSON-decoded value in MessagePack format
+func encodeMsgpack(buf *bytes.Buffer, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if val {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		return encodeMsgpackNumber(buf, val)
+	case string:
+		encodeMsgpackString(buf, val)
+	case []interface{}:
+		writeMsgpackHeader(buf, len(val), 0x90, 15, 0xdc, 0xdd)
+		for _, item := range val {
+			if err := encodeMsgpack(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		// Sort keys so identical payloads encode identically (stable signatures)
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		writeMsgpackHeader(buf, len(val), 0x80, 15, 0xde, 0xdf)
+		for _, key := range keys {
+			encodeMsgpackString(buf, key)
+			if err := encodeMsgpack(buf, val[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+	return nil
+}
+
+// encodeMsgpackNumber writes integers in the smallest int64/uint64 form and everything else as float64
+func encodeMsgpackNumber(buf *bytes.Buffer, n json.Number) error {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		switch {
+		case i >= 0 && i <= 127:
+			buf.WriteByte(byte(i)) // positive fixint
+		case i < 0 && i >= -32:
+			buf.WriteByte(byte(int8(i))) // negative fixint
+		default:
+			buf.WriteByte(0xd3)
+			binary.Write(buf, binary.BigEndian, i)
+		}
+		return nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, u)
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return fmt.Errorf("msgpack: invalid number %q", n)
+	}
+	buf.WriteByte(0xcb)
+	binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+	return nil
+}
+
+// encodeMsgpackString writes a UTF-8 string
+func encodeMsgpackString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n <= 31:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	default:
+		writeMsgpackHeader(buf, n, 0, -1, 0xda, 0xdb)
+	}
+	buf.WriteString(s)
+}
+
+// writeMsgpackHeader writes a length header using the fix, 16-bit or 32-bit form
+// fixMax < 0 disables the fix form
+func writeMsgpackHeader(buf *bytes.Buffer, n int, fixPrefix byte, fixMax int, code16, code32 byte) {
+	switch {
+	case n <= fixMax:
+		buf.WriteByte(fixPrefix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(code32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
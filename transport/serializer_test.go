@@ -0,0 +1,229 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+// msgpackHex encodes a JSON document with MsgpackSerializer and returns the bytes as hex
+func msgpackHex(t *testing.T, document string) string {
+	t.Helper()
+	data, err := MsgpackSerializer{}.Marshal(json.RawMessage(document))
+	if err != nil {
+		t.Fatalf("Marshal(%s) error = %v", document, err)
+	}
+	return hex.EncodeToString(data)
+}
+
+// jsonString returns s as a JSON string literal
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// jsonArray returns a JSON array of n zeros
+func jsonArray(n int) string {
+	return "[" + strings.TrimSuffix(strings.Repeat("0,", n), ",") + "]"
+}
+
+// jsonMap returns a JSON object with n keys ("kaa", "kab", ...) set to 0, and the keys in sorted order
+func jsonMap(n int) (string, []string) {
+	var fields, keys []string
+	for i := 0; i < n; i++ {
+		key := "k" + string(rune('a'+i/26)) + string(rune('a'+i%26))
+		keys = append(keys, key)
+		fields = append(fields, jsonString(key)+":0")
+	}
+	return "{" + strings.Join(fields, ",") + "}", keys
+}
+
+func TestMsgpackScalars(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{"nil", `null`, "c0"},
+		{"false", `false`, "c2"},
+		{"true", `true`, "c3"},
+		{"zero", `0`, "00"},
+		{"positive fixint max", `127`, "7f"},
+		{"int64 above fixint", `128`, "d30000000000000080"},
+		{"negative fixint", `-1`, "ff"},
+		{"negative fixint min", `-32`, "e0"},
+		{"int64 below fixint", `-33`, "d3ffffffffffffffdf"},
+		{"int64 min", `-9223372036854775808`, "d38000000000000000"},
+		{"int64 max", `9223372036854775807`, "d37fffffffffffffff"},
+		{"uint64 above int64", `9223372036854775808`, "cf8000000000000000"},
+		{"uint64 max", `18446744073709551615`, "cfffffffffffffffff"},
+		{"float", `1.5`, "cb3ff8000000000000"},
+		{"negative float", `-0.25`, "cbbfd0000000000000"},
+		{"float exponent", `1e300`, "cb7e37e43c8800759c"},
+		{"empty fixstr", `""`, "a0"},
+		{"fixstr", `"abc"`, "a3616263"},
+		{"fixstr utf-8", `"é"`, "a2c3a9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := msgpackHex(t, tt.document); got != tt.want {
+				t.Errorf("msgpack(%s) = %s, want %s", tt.document, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpackStringLengths(t *testing.T) {
+	tests := []struct {
+		length int
+		header string
+	}{
+		{31, "bf"},            // Largest fixstr
+		{32, "d920"},          // str8
+		{255, "d9ff"},         // Largest str8
+		{256, "da0100"},       // str16
+		{65535, "daffff"},     // Largest str16
+		{65536, "db00010000"}, // str32
+	}
+
+	for _, tt := range tests {
+		s := strings.Repeat("x", tt.length)
+		want := tt.header + hex.EncodeToString([]byte(s))
+		if got := msgpackHex(t, jsonString(s)); got != want {
+			t.Errorf("string of %d bytes starts with %s, want header %s", tt.length, got[:min(len(got), 12)], tt.header)
+		}
+	}
+}
+
+func TestMsgpackArrays(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{"empty", `[]`, "90"},
+		{"fixarray", `[1,"a",null]`, "9301a161c0"},
+		{"largest fixarray", jsonArray(15), "9f" + strings.Repeat("00", 15)},
+		{"array16", jsonArray(16), "dc0010" + strings.Repeat("00", 16)},
+		{"array32", jsonArray(65536), "dd00010000" + strings.Repeat("00", 65536)},
+		{"nested", `[[],[1,[2]]]`, "929092019102"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := msgpackHex(t, tt.document); got != tt.want {
+				t.Errorf("msgpack(%s) = %s, want %s", tt.name, got[:min(len(got), 40)], tt.want[:min(len(tt.want), 40)])
+			}
+		})
+	}
+}
+
+func TestMsgpackMaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{"empty", `{}`, "80"},
+		// Keys are written in sorted order whatever the input order
+		{"sorted keys", `{"b":1,"a":2,"c":3}`, "83" + "a16102" + "a16201" + "a16303"},
+		{"nested", `{"z":{"y":[true,null]},"a":{}}`, "82" + "a16180" + "a17a81a17992c3c0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := msgpackHex(t, tt.document); got != tt.want {
+				t.Errorf("msgpack(%s) = %s, want %s", tt.document, got, tt.want)
+			}
+		})
+	}
+
+	// fixmap holds up to 15 entries, map16 is used above that
+	for _, tt := range []struct {
+		entries int
+		header  string
+	}{
+		{15, "8f"},
+		{16, "de0010"},
+	} {
+		document, keys := jsonMap(tt.entries)
+		want := tt.header
+		for _, key := range keys {
+			want += "a3" + hex.EncodeToString([]byte(key)) + "00"
+		}
+		if got := msgpackHex(t, document); got != want {
+			t.Errorf("map with %d entries = %s, want %s", tt.entries, got, want)
+		}
+	}
+}
+
+func TestMsgpackPayloadDeterministic(t *testing.T) {
+	payload := models.Payload{
+		Host:      "web-01",
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		System: models.SystemMetrics{
+			CPUPercent:   12.5,
+			MemoryUsedMB: 1 << 40,
+			DiskUsage:    map[string]float64{"/var": 40, "/": 71.5, "/home": 3},
+		},
+		Ports: []models.PortInfo{{Protocol: "tcp", Port: 443, Process: "nginx"}},
+	}
+
+	first, err := MsgpackSerializer{}.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		again, err := MsgpackSerializer{}.Marshal(payload)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if !bytes.Equal(again, first) {
+			t.Fatal("Marshal() of the same payload produced different bytes")
+		}
+	}
+
+	// Field names and time formatting follow the JSON form
+	for _, want := range []string{"host", "timestamp", "2026-01-02T03:04:05Z", "disk_usage"} {
+		if !bytes.Contains(first, []byte(want)) {
+			t.Errorf("encoded payload is missing %q", want)
+		}
+	}
+}
+
+func TestMsgpackUnsupportedType(t *testing.T) {
+	var buf bytes.Buffer
+	if err := encodeMsgpack(&buf, 1.5); err == nil {
+		t.Error("encodeMsgpack(float64) succeeded, want an error (only JSON-decoded values are supported)")
+	}
+}
+
+func TestNewSerializer(t *testing.T) {
+	tests := []struct {
+		format      string
+		contentType string
+		wantErr     bool
+	}{
+		{"", "application/json", false},
+		{"json", "application/json", false},
+		{"msgpack", "application/msgpack", false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		s, err := NewSerializer(tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewSerializer(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			continue
+		}
+		if err == nil && s.ContentType() != tt.contentType {
+			t.Errorf("NewSerializer(%q).ContentType() = %q, want %q", tt.format, s.ContentType(), tt.contentType)
+		}
+	}
+}
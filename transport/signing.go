@@ -0,0 +1,38 @@
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// signatureHeader carries the HMAC of the request body (GitHub webhook style)
+	signatureHeader = "X-VPSentinel-Signature"
+	signaturePrefix = "sha256="
+)
+
+// signBody computes the signature header value for a request body
+func signBody(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a signature header value against the request body
+// Uses a constant-time comparison to avoid timing attacks
+func VerifySignature(secret, body []byte, header string) bool {
+	if !strings.HasPrefix(header, signaturePrefix) {
+		return false
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(header, signaturePrefix))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
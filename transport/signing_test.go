@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"vpsentinel-agent/models"
+)
+
+func TestSignBody(t *testing.T) {
+	// Example from GitHub's webhook signature documentation
+	secret := []byte("It's a Secret to Everybody")
+	body := []byte("Hello, World!")
+	want := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	if got := signBody(secret, body); got != want {
+		t.Errorf("signBody() = %q, want %q", got, want)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("It's a Secret to Everybody")
+	body := []byte("Hello, World!")
+	valid := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	tests := []struct {
+		name   string
+		secret []byte
+		body   []byte
+		header string
+		want   bool
+	}{
+		{"valid", secret, body, valid, true},
+		{"tampered body", secret, []byte("Hello, World?"), valid, false},
+		{"wrong secret", []byte("another secret"), body, valid, false},
+		{"missing prefix", secret, body, valid[len(signaturePrefix):], false},
+		{"not hex", secret, body, "sha256=not-hex", false},
+		{"empty", secret, body, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature(tt.secret, tt.body, tt.header); got != tt.want {
+				t.Errorf("VerifySignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendRequestSignsBody(t *testing.T) {
+	const secret = "signing-secret"
+
+	var gotBody []byte
+	var gotSignature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(signatureHeader)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "1.0.0")
+	client.SetSigningSecret(secret)
+
+	payload := models.Payload{
+		Host:      "web-01",
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := client.sendRequest(payload); err != nil {
+		t.Fatalf("sendRequest() error = %v", err)
+	}
+
+	// The header must be the HMAC of exactly the bytes that were sent
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(gotBody)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if gotSignature != want {
+		t.Errorf("%s = %q, want %q", signatureHeader, gotSignature, want)
+	}
+	if !VerifySignature([]byte(secret), gotBody, gotSignature) {
+		t.Error("VerifySignature() rejected the signature sent by the client")
+	}
+}
+
+func TestSendRequestUnsigned(t *testing.T) {
+	var gotSignature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(signatureHeader)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "1.0.0")
+	if err := client.sendRequest(models.Payload{Host: "web-01"}); err != nil {
+		t.Fatalf("sendRequest() error = %v", err)
+	}
+	if gotSignature != "" {
+		t.Errorf("%s = %q, want no header without a signing secret", signatureHeader, gotSignature)
+	}
+}
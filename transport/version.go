@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// versionResponse is the body returned by GET /api/agent/version
+type versionResponse struct {
+	Version string `json:"version"`
+}
+
+// CheckLatestVersion asks the backend for the latest agent release
+// Returns the latest version and whether it is newer than the running agent
+func (c *Client) CheckLatestVersion() (string, bool, error) {
+	req, err := http.NewRequest("GET", c.url+"api/agent/version", nil)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", false, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", false, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result versionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Version == "" {
+		return "", false, fmt.Errorf("backend returned no version")
+	}
+
+	return result.Version, isNewerVersion(result.Version, c.agentVersion), nil
+}
+
+// isNewerVersion reports whether latest is a higher release than current
+// Non-numeric versions (e.g. "dev" builds) are never considered outdated
+func isNewerVersion(latest, current string) bool {
+	latestParts, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	currentParts, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+
+	for i := 0; i < len(latestParts) || i < len(currentParts); i++ {
+		var l, c int
+		if i < len(latestParts) {
+			l = latestParts[i]
+		}
+		if i < len(currentParts) {
+			c = currentParts[i]
+		}
+		if l != c {
+			return l > c
+		}
+	}
+	return false
+}
+
+// parseVersion splits "v1.2.3" into its numeric parts, ignoring any pre-release suffix
+func parseVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	if version == "" {
+		return nil, false
+	}
+
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}